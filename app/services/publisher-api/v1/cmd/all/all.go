@@ -2,7 +2,11 @@
 package all
 
 import (
+	"github.com/vikaskumar1187/publisher_saas/app/services/publisher-api/v1/handlers/apikeygrp"
 	"github.com/vikaskumar1187/publisher_saas/app/services/publisher-api/v1/handlers/checkgrp"
+	"github.com/vikaskumar1187/publisher_saas/app/services/publisher-api/v1/handlers/pagegrp"
+	"github.com/vikaskumar1187/publisher_saas/app/services/publisher-api/v1/handlers/schedulegrp"
+	"github.com/vikaskumar1187/publisher_saas/app/services/publisher-api/v1/handlers/webhookgrp"
 	v1 "github.com/vikaskumar1187/publisher_saas/business/web/v1"
 	"github.com/vikaskumar1187/publisher_saas/foundation/web"
 )
@@ -22,6 +26,33 @@ func (add) Add(app *web.App, cfg v1.APIMuxConfig) {
 		Build:       cfg.Build,
 		Log:         cfg.Log,
 		DB:          cfg.DB,
+		Replica:     cfg.Replica,
+		Migrations:  cfg.Migrations,
 	})
 
+	apikeygrp.Routes(app, apikeygrp.Config{
+		Log:  cfg.Log,
+		Auth: cfg.Auth,
+		DB:   cfg.DB,
+	})
+
+	pagegrp.Routes(app, pagegrp.Config{
+		Log:  cfg.Log,
+		Auth: cfg.Auth,
+		DB:   cfg.DB,
+		Tran: cfg.Tran,
+	})
+
+	schedulegrp.Routes(app, schedulegrp.Config{
+		Log:  cfg.Log,
+		Auth: cfg.Auth,
+		DB:   cfg.DB,
+	})
+
+	webhookgrp.Routes(app, webhookgrp.Config{
+		Log:  cfg.Log,
+		Auth: cfg.Auth,
+		DB:   cfg.DB,
+		Tran: cfg.Tran,
+	})
 }
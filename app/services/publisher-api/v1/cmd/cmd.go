@@ -3,6 +3,8 @@ package cmd
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"expvar"
 	"fmt"
@@ -11,24 +13,55 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/ardanlabs/conf/v3"
+	"github.com/jmoiron/sqlx"
+	"github.com/navigacontentlab/panurge/navigaid"
 
+	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
 	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
-
-	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey"
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey/stores/apikeydb"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication/stores/publicationdb"
+	"github.com/vikaskumar1187/publisher_saas/business/core/schedule"
+	"github.com/vikaskumar1187/publisher_saas/business/core/schedule/stores/scheduledb"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook/stores/webhookdb"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/dbmetrics"
+	database "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/leader"
+	"github.com/vikaskumar1187/publisher_saas/business/data/migrate"
+	"github.com/vikaskumar1187/publisher_saas/business/data/outbox"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
 	v1 "github.com/vikaskumar1187/publisher_saas/business/web/v1"
 	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
 	"github.com/vikaskumar1187/publisher_saas/business/web/v1/debug"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/metrics"
+	"github.com/vikaskumar1187/publisher_saas/foundation/keystore"
 	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger/alert"
 	"github.com/vikaskumar1187/publisher_saas/foundation/web"
 )
 
@@ -36,10 +69,13 @@ import (
 func Main(build string, routeAdder v1.RouteAdder) error {
 	var log *logger.Logger
 
+	// The alerts are configured once the configuration is parsed. Until then
+	// the records are only logged.
+	alerts := alert.New()
+
 	events := logger.Events{
-		Error: func(ctx context.Context, r logger.Record) {
-			log.Info(ctx, "******* SEND ALERT ******")
-		},
+		Warn:  alerts.Alert,
+		Error: alerts.Alert,
 	}
 
 	traceIDFunc := func(ctx context.Context) string {
@@ -52,7 +88,7 @@ func Main(build string, routeAdder v1.RouteAdder) error {
 
 	ctx := context.Background()
 
-	if err := run(ctx, log, build, routeAdder, nil, nil, false); err != nil {
+	if err := run(ctx, log, alerts, build, routeAdder, nil, nil, false); err != nil {
 		log.Error(ctx, "startup", "msg", err)
 		return err
 	}
@@ -64,10 +100,13 @@ func Main(build string, routeAdder v1.RouteAdder) error {
 func MainServiceWeaver(build string, routeAdder v1.RouteAdder, debug net.Listener, app net.Listener) error {
 	var log *logger.Logger
 
+	// The alerts are configured once the configuration is parsed. Until then
+	// the records are only logged.
+	alerts := alert.New()
+
 	events := logger.Events{
-		Error: func(ctx context.Context, r logger.Record) {
-			log.Info(ctx, "******* SEND ALERT ******")
-		},
+		Warn:  alerts.Alert,
+		Error: alerts.Alert,
 	}
 
 	traceIDFunc := func(ctx context.Context) string {
@@ -80,7 +119,7 @@ func MainServiceWeaver(build string, routeAdder v1.RouteAdder, debug net.Listene
 
 	ctx := context.Background()
 
-	if err := run(ctx, log, build, routeAdder, debug, app, true); err != nil {
+	if err := run(ctx, log, alerts, build, routeAdder, debug, app, true); err != nil {
 		log.Error(ctx, "startup", "msg", err)
 		return err
 	}
@@ -88,7 +127,7 @@ func MainServiceWeaver(build string, routeAdder v1.RouteAdder, debug net.Listene
 	return nil
 }
 
-func run(ctx context.Context, log *logger.Logger, build string, routeAdder v1.RouteAdder, debugLis net.Listener, appLis net.Listener, usingWeaver bool) error {
+func run(ctx context.Context, log *logger.Logger, alerts *alert.Alerter, build string, routeAdder v1.RouteAdder, debugLis net.Listener, appLis net.Listener, usingWeaver bool) error {
 
 	// -------------------------------------------------------------------------
 	// GOMAXPROCS
@@ -117,16 +156,111 @@ func run(ctx context.Context, log *logger.Logger, build string, routeAdder v1.Ro
 			MaxIdleConns int    `conf:"default:2"`
 			MaxOpenConns int    `conf:"default:0"`
 			DisableTLS   bool   `conf:"default:true"`
+
+			ConnMaxLifetime    time.Duration `conf:"default:30m"`
+			ConnMaxIdleTime    time.Duration `conf:"default:5m"`
+			SSLMode            string        `conf:"default:require,help:require, verify-ca or verify-full"`
+			SSLRootCert        string
+			SSLCert            string
+			SSLKey             string
+			TargetSessionAttrs string        `conf:"help:read-write to fail over to the primary of a comma separated host list"`
+			StatementTimeout   time.Duration `conf:"default:30s"`
+			IdleInTxTimeout    time.Duration `conf:"default:1m"`
+			ApplicationName    string        `conf:"default:publisher-api"`
+
+			ReplicaHost          string        `conf:"help:host of the read replica, none when empty"`
+			ReplicaMaxLag        time.Duration `conf:"default:5s"`
+			ReplicaCheckInterval time.Duration `conf:"default:5s"`
+
+			Pool              bool          `conf:"default:false,help:use the native pgx pool backend"`
+			HealthCheckPeriod time.Duration `conf:"default:1m"`
+
+			LogMode             string        `conf:"default:values,help:values or placeholders"`
+			LogMaxLength        int           `conf:"default:2048"`
+			LogAtDebug          bool          `conf:"default:false"`
+			LogSensitiveColumns []string      `conf:"default:email;password;password_hash;secret;token;key_hash;content"`
+			SlowQueryThreshold  time.Duration `conf:"default:500ms"`
+
+			MigrateOnStartup bool          `conf:"default:false"`
+			MigrateTimeout   time.Duration `conf:"default:2m"`
 		}
 		Auth struct {
-			Env         string `conf:"dev"`
-			ImasURL     string `conf:"https://imas.dev.imid.infomaker.io"`
-			Permissions string `conf:"pagehub:publish"`
+			Env                    string        `conf:"default:dev"`
+			AllowLocal             bool          `conf:"default:false,help:allow the local token issuer, for development only"`
+			ImasURL                string        `conf:"default:https://imas.dev.imid.infomaker.io"`
+			JWKSRefreshInterval    time.Duration `conf:"default:10m"`
+			JWKSMinRefreshInterval time.Duration `conf:"default:30s"`
+			JWKSRetryInterval      time.Duration `conf:"default:5s"`
+			KeysFolder             string        `conf:"default:zarf/keys/"`
+			ActiveKID              string        `conf:"help:key id of the local token issuer, generated with publisher-admin genkey"`
+			Issuer                 string        `conf:"default:publisher-api"`
+		}
+		Jobs struct {
+			Workers           int           `conf:"default:4"`
+			PollInterval      time.Duration `conf:"default:1s"`
+			VisibilityTimeout time.Duration `conf:"default:5m"`
+			BackoffBase       time.Duration `conf:"default:5s"`
+			BackoffMax        time.Duration `conf:"default:10m"`
+			ShutdownTimeout   time.Duration `conf:"default:20s"`
+		}
+		Scheduler struct {
+			Enabled          bool          `conf:"default:true"`
+			Interval         time.Duration `conf:"default:15s"`
+			CatchUp          string        `conf:"default:latest"`
+			MisfireThreshold time.Duration `conf:"default:1m"`
+			MaxCatchUp       int           `conf:"default:100"`
+			LeaderCheck      time.Duration `conf:"default:5s"`
+		}
+		Outbox struct {
+			Enabled      bool          `conf:"default:true"`
+			PollInterval time.Duration `conf:"default:1s"`
+			BatchSize    int           `conf:"default:100"`
+			Retention    time.Duration `conf:"default:168h"`
+			MaxAttempts  int           `conf:"default:10"`
+			BackoffBase  time.Duration `conf:"default:1s"`
+			BackoffMax   time.Duration `conf:"default:5m"`
+			File         string
+			LeaderCheck  time.Duration `conf:"default:5s"`
 		}
 		Tempo struct {
-			ReporterURI string  `conf:"default:tempo.publisher-system.svc.cluster.local:4317"`
-			ServiceName string  `conf:"default:publisher-api"`
-			Probability float64 `conf:"default:1"` // Shouldn't use a high value in non-developer systems. 0.05 should be enough for most systems. Some might want to have this even lower
+			ReporterURI        string   `conf:"default:tempo.publisher-system.svc.cluster.local:4317,help:tracing is off when empty"`
+			Protocol           string   `conf:"default:grpc,help:grpc or http"`
+			Insecure           bool     `conf:"default:true"`
+			CACert             string   `conf:"help:path to the CA certificate of the collector"`
+			Headers            []string `conf:"mask,help:key=value headers sent with the spans"`
+			ServiceName        string   `conf:"default:publisher-api"`
+			Probability        float64  `conf:"default:1"` // Shouldn't use a high value in non-developer systems. 0.05 should be enough for most systems. Some might want to have this even lower
+			RouteProbabilities []string `conf:"help:path=probability overrides by path prefix"`
+		}
+		Alerts struct {
+			BatchInterval   time.Duration `conf:"default:10s"`
+			BatchSize       int           `conf:"default:50"`
+			DedupWindow     time.Duration `conf:"default:5m,help:repeats of an alert within the window are sent as one"`
+			RateLimit       int           `conf:"default:6,help:batches per minute per notifier"`
+			WebhookURL      string        `conf:"help:alerts are posted as JSON when set"`
+			WebhookHeaders  []string      `conf:"mask,help:key=value headers sent with the alerts"`
+			WebhookLevel    string        `conf:"default:error,help:warn or error"`
+			SlackURL        string        `conf:"mask,help:alerts are posted to the incoming webhook when set"`
+			SlackLevel      string        `conf:"default:error,help:warn or error"`
+			SMTPAddr        string        `conf:"help:alerts are mailed through the server when set"`
+			SMTPUser        string
+			SMTPPassword    string `conf:"mask"`
+			SMTPFrom        string `conf:"default:alerts@publisher.local"`
+			SMTPTo          []string
+			SMTPLevel       string        `conf:"default:error,help:warn or error"`
+			ShutdownTimeout time.Duration `conf:"default:5s"`
+		}
+		Logs struct {
+			Exporter string `conf:"default:none,help:otlp-grpc, otlp-http or none"`
+			Endpoint string `conf:"default:otel-collector.publisher-system.svc.cluster.local:4317"`
+			Insecure bool   `conf:"default:true"`
+		}
+		Metrics struct {
+			Exporter  string        `conf:"default:none,help:otlp-grpc, otlp-http, stdout or none"`
+			Endpoint  string        `conf:"default:otel-collector.publisher-system.svc.cluster.local:4317"`
+			Insecure  bool          `conf:"default:true"`
+			Interval  time.Duration `conf:"default:30s"`
+			Exemplars bool          `conf:"default:true,help:link the recorded values to the sampled traces"`
 		}
 	}{
 		Version: conf.Version{
@@ -159,38 +293,294 @@ func run(ctx context.Context, log *logger.Logger, build string, routeAdder v1.Ro
 
 	expvar.NewString("build").Set(build)
 
+	// -------------------------------------------------------------------------
+	// Start Alerting Support
+
+	alertRoutes, err := newAlertRoutes(cfg.Alerts.WebhookURL, cfg.Alerts.WebhookHeaders, cfg.Alerts.WebhookLevel, cfg.Alerts.SlackURL, cfg.Alerts.SlackLevel, alert.SMTPConfig{
+		Addr:     cfg.Alerts.SMTPAddr,
+		User:     cfg.Alerts.SMTPUser,
+		Password: cfg.Alerts.SMTPPassword,
+		From:     cfg.Alerts.SMTPFrom,
+		To:       cfg.Alerts.SMTPTo,
+	}, cfg.Alerts.SMTPLevel)
+	if err != nil {
+		return fmt.Errorf("configuring alerts: %w", err)
+	}
+
+	log.Info(ctx, "startup", "status", "initializing alerting support", "notifiers", len(alertRoutes))
+
+	err = alerts.Start(alert.Config{
+		Log:           log,
+		Service:       cfg.Tempo.ServiceName,
+		Routes:        alertRoutes,
+		BatchInterval: cfg.Alerts.BatchInterval,
+		BatchSize:     cfg.Alerts.BatchSize,
+		DedupWindow:   cfg.Alerts.DedupWindow,
+		RateLimit:     cfg.Alerts.RateLimit,
+	})
+	if err != nil {
+		return fmt.Errorf("starting alerts: %w", err)
+	}
+
+	defer func() {
+		log.Info(ctx, "shutdown", "status", "stopping alerting support")
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Alerts.ShutdownTimeout)
+		defer cancel()
+
+		if err := alerts.Shutdown(ctx); err != nil {
+			log.Info(ctx, "shutdown", "status", "stopping alerting support", "msg", err)
+		}
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Log Export Support
+
+	// The logs, traces and metrics describe the same service, so they carry
+	// the same resource attributes.
+	res := newResource(cfg.Tempo.ServiceName, build)
+
+	if cfg.Logs.Exporter != "none" {
+		log.Info(ctx, "startup", "status", "initializing OT log export support", "exporter", cfg.Logs.Exporter, "endpoint", cfg.Logs.Endpoint)
+
+		logProvider, err := startLogs(res, cfg.Logs.Exporter, cfg.Logs.Endpoint, cfg.Logs.Insecure)
+		if err != nil {
+			return fmt.Errorf("starting log export: %w", err)
+		}
+		defer logProvider.Shutdown(context.Background())
+
+		// The records also go to stdout, with the trace and span ids that
+		// link them to the traces.
+		log = log.WithHandler(otelslog.NewHandler(cfg.Tempo.ServiceName, otelslog.WithLoggerProvider(logProvider)))
+	}
+
 	// -------------------------------------------------------------------------
 	// Database Support
 
 	log.Info(ctx, "startup", "status", "initializing database support", "host", cfg.DB.Host)
 
-	db, err := db.Open(db.Config{
-		User:         cfg.DB.User,
-		Password:     cfg.DB.Password,
-		Host:         cfg.DB.Host,
-		Name:         cfg.DB.Name,
-		MaxIdleConns: cfg.DB.MaxIdleConns,
-		MaxOpenConns: cfg.DB.MaxOpenConns,
-		DisableTLS:   cfg.DB.DisableTLS,
-	})
+	logMode, err := database.ParseLogMode(cfg.DB.LogMode)
 	if err != nil {
-		return fmt.Errorf("connecting to db: %w", err)
+		return fmt.Errorf("parsing db log mode: %w", err)
+	}
+
+	logLevel := logger.LevelInfo
+	if cfg.DB.LogAtDebug {
+		logLevel = logger.LevelDebug
+	}
+
+	database.SetLogPolicy(database.LogPolicy{
+		Mode:             logMode,
+		SensitiveColumns: cfg.DB.LogSensitiveColumns,
+		MaxLength:        cfg.DB.LogMaxLength,
+		Level:            logLevel,
+		SlowThreshold:    cfg.DB.SlowQueryThreshold,
+	})
+
+	dbCfg := database.Config{
+		User:               cfg.DB.User,
+		Password:           cfg.DB.Password,
+		Host:               cfg.DB.Host,
+		Name:               cfg.DB.Name,
+		MaxIdleConns:       cfg.DB.MaxIdleConns,
+		MaxOpenConns:       cfg.DB.MaxOpenConns,
+		ConnMaxLifetime:    cfg.DB.ConnMaxLifetime,
+		ConnMaxIdleTime:    cfg.DB.ConnMaxIdleTime,
+		DisableTLS:         cfg.DB.DisableTLS,
+		SSLMode:            cfg.DB.SSLMode,
+		SSLRootCert:        cfg.DB.SSLRootCert,
+		SSLCert:            cfg.DB.SSLCert,
+		SSLKey:             cfg.DB.SSLKey,
+		TargetSessionAttrs: cfg.DB.TargetSessionAttrs,
+		StatementTimeout:   cfg.DB.StatementTimeout,
+		IdleInTxTimeout:    cfg.DB.IdleInTxTimeout,
+		ApplicationName:    cfg.DB.ApplicationName,
+		HealthCheckPeriod:  cfg.DB.HealthCheckPeriod,
 	}
+
+	// The pool backend runs the same helpers over pgxpool connections and
+	// gives access to COPY and batches through the pool.
+
+	var db *sqlx.DB
+	var pool *database.Pool
+	var closeDB func() error
+
+	if cfg.DB.Pool {
+		pool, err = database.OpenPool(ctx, dbCfg)
+		if err != nil {
+			return fmt.Errorf("connecting to db pool: %w", err)
+		}
+		db, closeDB = pool.DB, pool.Close
+	} else {
+		db, err = database.Open(dbCfg)
+		if err != nil {
+			return fmt.Errorf("connecting to db: %w", err)
+		}
+		closeDB = db.Close
+	}
+
 	defer func() {
 		log.Info(ctx, "shutdown", "status", "stopping database support", "host", cfg.DB.Host)
-		db.Close()
+		closeDB()
 	}()
 
+	if err := metrics.RegisterDB("primary", db.DB); err != nil {
+		return fmt.Errorf("registering db metrics: %w", err)
+	}
+
+	if err := metrics.Register(dbmetrics.NewCollector()); err != nil {
+		return fmt.Errorf("registering query metrics: %w", err)
+	}
+
+	// -------------------------------------------------------------------------
+	// Read Replica Support
+
+	// Read only queries run outside of a transaction go to the replica while
+	// it is healthy and within the maximum lag, and to the primary otherwise.
+
+	var replica *database.Replica
+
+	if cfg.DB.ReplicaHost != "" {
+		log.Info(ctx, "startup", "status", "initializing read replica support", "host", cfg.DB.ReplicaHost)
+
+		replicaCfg := dbCfg
+		replicaCfg.Host = cfg.DB.ReplicaHost
+		replicaCfg.TargetSessionAttrs = ""
+
+		var replicaDB *sqlx.DB
+		var closeReplica func() error
+
+		if cfg.DB.Pool {
+			replicaPool, err := database.OpenPool(ctx, replicaCfg)
+			if err != nil {
+				return fmt.Errorf("connecting to replica pool: %w", err)
+			}
+			replicaDB, closeReplica = replicaPool.DB, replicaPool.Close
+		} else {
+			replicaDB, err = database.Open(replicaCfg)
+			if err != nil {
+				return fmt.Errorf("connecting to replica: %w", err)
+			}
+			closeReplica = replicaDB.Close
+		}
+
+		if err := metrics.RegisterDB("replica", replicaDB.DB); err != nil {
+			return fmt.Errorf("registering replica metrics: %w", err)
+		}
+
+		replica = database.NewReplica(database.ReplicaConfig{
+			Log:           log,
+			Primary:       db,
+			Replica:       replicaDB,
+			MaxLag:        cfg.DB.ReplicaMaxLag,
+			CheckInterval: cfg.DB.ReplicaCheckInterval,
+		})
+		database.UseReplica(replica)
+
+		replicaCtx, replicaCancel := context.WithCancel(context.Background())
+		replicaDone := make(chan struct{})
+
+		go func() {
+			defer close(replicaDone)
+			replica.Run(replicaCtx)
+		}()
+
+		defer func() {
+			log.Info(ctx, "shutdown", "status", "stopping read replica support", "host", cfg.DB.ReplicaHost)
+			database.UseReplica(nil)
+			replicaCancel()
+			<-replicaDone
+			closeReplica()
+		}()
+	}
+
+	// -------------------------------------------------------------------------
+	// Database Migrations
+
+	// The migrations run in the background so liveness can be reported while
+	// the readiness check holds traffic back until the schema is current.
+
+	var migrationGate *migrate.Gate
+	migrationErrors := make(chan error, 1)
+
+	if cfg.DB.MigrateOnStartup {
+		log.Info(ctx, "startup", "status", "applying database migrations", "timeout", cfg.DB.MigrateTimeout)
+
+		migrationGate = migrate.NewGate()
+
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, cfg.DB.MigrateTimeout)
+			defer cancel()
+
+			if err := migrationGate.Run(ctx, log, db); err != nil {
+				migrationErrors <- err
+			}
+		}()
+	}
+
 	// -------------------------------------------------------------------------
 	// Initialize authentication support
 
 	log.Info(ctx, "startup", "status", "initializing authentication support")
 
+	var authenticator auth.Authenticator
+
+	switch cfg.Auth.Env {
+	case "local":
+		// The local issuer mints tokens with any claims, so it must never be
+		// turned on in a deployed service by the env alone.
+		if !cfg.Auth.AllowLocal {
+			return errors.New("local auth requires allow local to be set, for development only")
+		}
+
+		if cfg.Auth.ActiveKID == "" {
+			return errors.New("local auth requires an active kid, generate a key with publisher-admin genkey")
+		}
+
+		log.Warn(ctx, "startup", "status", "using local token issuer, insecure and for development only", "keysfolder", cfg.Auth.KeysFolder, "activekid", cfg.Auth.ActiveKID)
+
+		ks, err := keystore.NewFS(os.DirFS(cfg.Auth.KeysFolder))
+		if err != nil {
+			return fmt.Errorf("reading keys: %w", err)
+		}
+
+		local, err := auth.NewLocal(auth.LocalConfig{
+			Log:       log,
+			KeyLookup: ks,
+			ActiveKID: cfg.Auth.ActiveKID,
+			Issuer:    cfg.Auth.Issuer,
+		})
+		if err != nil {
+			return fmt.Errorf("constructing local issuer: %w", err)
+		}
+		authenticator = local
+
+	default:
+		log.Info(ctx, "startup", "status", "using imas", "imasurl", cfg.Auth.ImasURL)
+
+		keySet, err := auth.NewKeySet(ctx, auth.KeySetConfig{
+			Log:                log,
+			URL:                navigaid.ImasJWKSEndpoint(cfg.Auth.ImasURL),
+			RefreshInterval:    cfg.Auth.JWKSRefreshInterval,
+			MinRefreshInterval: cfg.Auth.JWKSMinRefreshInterval,
+			RetryInterval:      cfg.Auth.JWKSRetryInterval,
+		})
+		if err != nil {
+			return fmt.Errorf("loading jwks: %w", err)
+		}
+		defer func() {
+			log.Info(ctx, "shutdown", "status", "stopping jwks refresh", "imasurl", cfg.Auth.ImasURL)
+			keySet.Shutdown()
+		}()
+
+		authenticator = auth.NewIMAS(keySet)
+	}
+
 	authCfg := auth.Config{
-		Log:         log,
-		Env:         cfg.Auth.Env,
-		ImasURL:     cfg.Auth.ImasURL,
-		Permissions: cfg.Auth.Permissions,
+		Log:           log,
+		Env:           cfg.Auth.Env,
+		Authenticator: authenticator,
+		APIKeys:       apikey.NewCore(log, apikeydb.NewStore(log, db)),
 	}
 
 	auth, err := auth.New(authCfg)
@@ -201,13 +591,31 @@ func run(ctx context.Context, log *logger.Logger, build string, routeAdder v1.Ro
 	// -------------------------------------------------------------------------
 	// Start Tracing Support
 
-	log.Info(ctx, "startup", "status", "initializing OT/Tempo tracing support")
+	log.Info(ctx, "startup", "status", "initializing OT/Tempo tracing support", "reporter", cfg.Tempo.ReporterURI, "protocol", cfg.Tempo.Protocol)
 
-	traceProvider, err := startTracing(
-		cfg.Tempo.ServiceName,
-		cfg.Tempo.ReporterURI,
-		cfg.Tempo.Probability,
-	)
+	headers, err := parsePairs(cfg.Tempo.Headers)
+	if err != nil {
+		return fmt.Errorf("parsing tracing headers: %w", err)
+	}
+
+	routes, err := parseProbabilities(cfg.Tempo.RouteProbabilities)
+	if err != nil {
+		return fmt.Errorf("parsing tracing route probabilities: %w", err)
+	}
+
+	// The liveness probe runs every few seconds and would drown out the
+	// traces worth looking at.
+	routes["/v1/liveness"] = 0
+
+	traceProvider, err := startTracing(res, tracingConfig{
+		ReporterURI: cfg.Tempo.ReporterURI,
+		Protocol:    cfg.Tempo.Protocol,
+		Insecure:    cfg.Tempo.Insecure,
+		CACert:      cfg.Tempo.CACert,
+		Headers:     headers,
+		Probability: cfg.Tempo.Probability,
+		Routes:      routes,
+	})
 	if err != nil {
 		return fmt.Errorf("starting tracing: %w", err)
 	}
@@ -215,6 +623,199 @@ func run(ctx context.Context, log *logger.Logger, build string, routeAdder v1.Ro
 
 	tracer := traceProvider.Tracer("service")
 
+	// -------------------------------------------------------------------------
+	// Start Metrics Support
+
+	log.Info(ctx, "startup", "status", "initializing OT metrics support", "exporter", cfg.Metrics.Exporter, "endpoint", cfg.Metrics.Endpoint)
+
+	// Exemplars are still experimental in the metrics SDK and can only be
+	// turned on through the environment. Only the values recorded under a
+	// sampled span get one.
+	if cfg.Metrics.Exemplars {
+		os.Setenv("OTEL_GO_X_EXEMPLAR", "true")
+	}
+
+	meterProvider, err := startMetrics(
+		res,
+		cfg.Metrics.Exporter,
+		cfg.Metrics.Endpoint,
+		cfg.Metrics.Insecure,
+		cfg.Metrics.Interval,
+	)
+	if err != nil {
+		return fmt.Errorf("starting metrics: %w", err)
+	}
+	defer meterProvider.Shutdown(context.Background())
+
+	// -------------------------------------------------------------------------
+	// Start Job Workers
+
+	// The workers are stopped after the api so no new jobs are accepted while
+	// the in-flight jobs finish. Jobs still running when the timeout expires
+	// are released back to the queue for another instance to pick up.
+
+	log.Info(ctx, "startup", "status", "starting job workers", "workers", cfg.Jobs.Workers)
+
+	workers := queue.NewWorkers(queue.WorkersConfig{
+		Log:               log,
+		DB:                db,
+		Concurrency:       cfg.Jobs.Workers,
+		PollInterval:      cfg.Jobs.PollInterval,
+		VisibilityTimeout: cfg.Jobs.VisibilityTimeout,
+		BackoffBase:       cfg.Jobs.BackoffBase,
+		BackoffMax:        cfg.Jobs.BackoffMax,
+	})
+
+	whCore := webhook.NewCore(log, webhookdb.NewStore(log, db))
+	pubCore := publication.NewCore(log, database.NewBeginner(db), whCore, publicationdb.NewStore(log, db))
+	workers.Register(publication.JobPublishPage, pubCore.HandlePublishJob)
+	workers.Register(publication.JobPublishEdition, pubCore.HandlePublishEditionJob)
+	workers.Register(webhook.JobDeliver, whCore.HandleDeliveryJob)
+
+	// The background work polls tables the migrations create, so it is
+	// started once the migrations are done.
+	starts := []func(){workers.Start}
+
+	defer func() {
+		log.Info(ctx, "shutdown", "status", "stopping job workers", "timeout", cfg.Jobs.ShutdownTimeout)
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Jobs.ShutdownTimeout)
+		defer cancel()
+
+		if err := workers.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "stopping job workers", "msg", err)
+		}
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Scheduler
+
+	// Every replica campaigns for the scheduler lock but only the leader fires
+	// the due schedules, so each run is queued once.
+
+	if cfg.Scheduler.Enabled {
+		catchUp, err := schedule.ParseCatchUp(cfg.Scheduler.CatchUp)
+		if err != nil {
+			return fmt.Errorf("parsing scheduler catch up policy: %w", err)
+		}
+
+		log.Info(ctx, "startup", "status", "starting scheduler", "interval", cfg.Scheduler.Interval, "catchup", cfg.Scheduler.CatchUp)
+
+		schCore := schedule.NewCore(log, database.NewBeginner(db), pubCore, scheduledb.NewStore(log, db))
+
+		runCfg := schedule.RunConfig{
+			Interval:         cfg.Scheduler.Interval,
+			CatchUp:          catchUp,
+			MisfireThreshold: cfg.Scheduler.MisfireThreshold,
+			MaxCatchUp:       cfg.Scheduler.MaxCatchUp,
+		}
+
+		schLeader := leader.New(leader.Config{
+			Log:           log,
+			DB:            db,
+			Name:          "scheduler",
+			LockID:        schedule.LockID,
+			CheckInterval: cfg.Scheduler.LeaderCheck,
+		})
+
+		// The due schedules are read from the primary so a run isn't fired
+		// twice off a lagging replica.
+		starts = append(starts, func() {
+			schLeader.Start(func(ctx context.Context) {
+				schCore.Run(database.WithPrimary(ctx), runCfg)
+			})
+		})
+		defer func() {
+			log.Info(ctx, "shutdown", "status", "stopping scheduler")
+			schLeader.Shutdown()
+		}()
+	}
+
+	// -------------------------------------------------------------------------
+	// Start Outbox Relay
+
+	// Like the scheduler, the relay runs on the leader only. A single relay
+	// keeps the events of each aggregate in order. In process consumers
+	// subscribe to the bus, and setting the file appends every event to it as
+	// a line of JSON.
+
+	if cfg.Outbox.Enabled {
+		log.Info(ctx, "startup", "status", "starting outbox relay", "interval", cfg.Outbox.PollInterval)
+
+		sinks := []outbox.Sink{outbox.NewBus()}
+
+		if cfg.Outbox.File != "" {
+			fileSink, err := outbox.OpenFileSink(cfg.Outbox.File)
+			if err != nil {
+				return err
+			}
+			defer fileSink.Close()
+
+			sinks = append(sinks, fileSink)
+		}
+
+		relay := outbox.NewRelay(outbox.RelayConfig{
+			Log:          log,
+			DB:           db,
+			Pool:         pool,
+			Sinks:        sinks,
+			PollInterval: cfg.Outbox.PollInterval,
+			BatchSize:    cfg.Outbox.BatchSize,
+			Retention:    cfg.Outbox.Retention,
+			MaxAttempts:  cfg.Outbox.MaxAttempts,
+			BackoffBase:  cfg.Outbox.BackoffBase,
+			BackoffMax:   cfg.Outbox.BackoffMax,
+		})
+
+		relayLeader := leader.New(leader.Config{
+			Log:           log,
+			DB:            db,
+			Name:          "outbox",
+			LockID:        outbox.LockID,
+			CheckInterval: cfg.Outbox.LeaderCheck,
+		})
+
+		starts = append(starts, func() {
+			relayLeader.Start(relay.Run)
+		})
+		defer func() {
+			log.Info(ctx, "shutdown", "status", "stopping outbox relay")
+			relayLeader.Shutdown()
+		}()
+	}
+
+	// -------------------------------------------------------------------------
+	// Start Background Work
+
+	// With migrations applied at startup, the job workers, the scheduler and
+	// the outbox relay wait for the gate to open. If the migrations fail they
+	// are never started and the service shuts down. This is deferred last so
+	// it is done before the background work is stopped.
+
+	startCtx, startCancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+
+	go func() {
+		defer close(started)
+
+		if migrationGate != nil {
+			log.Info(ctx, "startup", "status", "background work waiting for migrations")
+
+			if err := migrationGate.Wait(startCtx); err != nil {
+				return
+			}
+		}
+
+		for _, start := range starts {
+			start()
+		}
+	}()
+
+	defer func() {
+		startCancel()
+		<-started
+	}()
+
 	// -------------------------------------------------------------------------
 	// Start Debug Service
 
@@ -248,6 +849,8 @@ func run(ctx context.Context, log *logger.Logger, build string, routeAdder v1.Ro
 		Log:         log,
 		Auth:        auth,
 		DB:          db,
+		Replica:     replica,
+		Migrations:  migrationGate,
 		Tracer:      tracer,
 	}
 
@@ -282,6 +885,9 @@ func run(ctx context.Context, log *logger.Logger, build string, routeAdder v1.Ro
 	case err := <-serverErrors:
 		return fmt.Errorf("server error: %w", err)
 
+	case err := <-migrationErrors:
+		return fmt.Errorf("migrating database: %w", err)
+
 	case sig := <-shutdown:
 		log.Info(ctx, "shutdown", "status", "shutdown started", "signal", sig)
 		defer log.Info(ctx, "shutdown", "status", "shutdown complete", "signal", sig)
@@ -300,38 +906,118 @@ func run(ctx context.Context, log *logger.Logger, build string, routeAdder v1.Ro
 
 // =============================================================================
 
-// startTracing configure open telemetry to be used with Grafana Tempo.
-func startTracing(serviceName string, reporterURI string, probability float64) (*trace.TracerProvider, error) {
+// newAlertRoutes constructs a route for every notifier that is configured.
+func newAlertRoutes(webhookURL string, webhookHeaders []string, webhookLevel string, slackURL string, slackLevel string, smtpCfg alert.SMTPConfig, smtpLevel string) ([]alert.Route, error) {
+	var routes []alert.Route
 
-	// WARNING: The current settings are using defaults which may not be
-	// compatible with your project. Please review the documentation for
-	// opentelemetry.
+	add := func(notifier alert.Notifier, level string) error {
+		lvl, err := alert.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("%s: %w", notifier.Name(), err)
+		}
 
-	exporter, err := otlptrace.New(
-		context.Background(),
-		otlptracegrpc.NewClient(
-			otlptracegrpc.WithInsecure(), // This should be configurable
-			otlptracegrpc.WithEndpoint(reporterURI),
-		),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("creating new exporter: %w", err)
+		routes = append(routes, alert.Route{Notifier: notifier, Level: lvl})
+		return nil
 	}
 
-	traceProvider := trace.NewTracerProvider(
-		trace.WithSampler(trace.TraceIDRatioBased(probability)),
-		trace.WithBatcher(exporter,
-			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
-			trace.WithBatchTimeout(trace.DefaultScheduleDelay*time.Millisecond),
-			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
-		),
-		trace.WithResource(
-			resource.NewWithAttributes(
-				semconv.SchemaURL,
-				semconv.ServiceNameKey.String(serviceName),
+	if webhookURL != "" {
+		headers, err := parsePairs(webhookHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("webhook headers: %w", err)
+		}
+
+		if err := add(alert.NewWebhook(webhookURL, headers), webhookLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	if slackURL != "" {
+		if err := add(alert.NewSlack(slackURL), slackLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	if smtpCfg.Addr != "" {
+		if err := add(alert.NewSMTP(smtpCfg), smtpLevel); err != nil {
+			return nil, err
+		}
+	}
+
+	return routes, nil
+}
+
+// newResource describes the service to open telemetry. The kubernetes
+// attributes come from the environment set up by the deployment and are
+// left out when they are not set.
+func newResource(serviceName string, build string) *resource.Resource {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceNameKey.String(serviceName),
+		semconv.ServiceVersionKey.String(build),
+	}
+
+	if host, err := os.Hostname(); err == nil {
+		attrs = append(attrs, semconv.HostNameKey.String(host))
+	}
+
+	env := []struct {
+		key attribute.Key
+		env string
+	}{
+		{semconv.K8SPodNameKey, "KUBERNETES_NAME"},
+		{semconv.K8SNamespaceNameKey, "KUBERNETES_NAMESPACE"},
+		{semconv.K8SNodeNameKey, "KUBERNETES_NODE_NAME"},
+		{attribute.Key("k8s.pod.ip"), "KUBERNETES_POD_IP"},
+	}
+
+	for _, e := range env {
+		if v := os.Getenv(e.env); v != "" {
+			attrs = append(attrs, e.key.String(v))
+		}
+	}
+
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+}
+
+// tracingConfig represents the settings of the trace exporter and sampler.
+type tracingConfig struct {
+	ReporterURI string
+	Protocol    string
+	Insecure    bool
+	CACert      string
+	Headers     map[string]string
+	Probability float64
+	Routes      map[string]float64
+}
+
+// startTracing configure open telemetry to be used with Grafana Tempo. The
+// root spans are sampled by path and the other spans follow their parent.
+// Without a reporter no span is sampled, but the trace ids are still
+// generated and propagated.
+func startTracing(res *resource.Resource, cfg tracingConfig) (*trace.TracerProvider, error) {
+	var opts []trace.TracerProviderOption
+
+	switch cfg.ReporterURI {
+	case "":
+		opts = append(opts, trace.WithSampler(trace.NeverSample()))
+
+	default:
+		exporter, err := newTraceExporter(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("creating new exporter: %w", err)
+		}
+
+		opts = append(opts,
+			trace.WithSampler(web.NewRouteSampler(cfg.Probability, cfg.Routes)),
+			trace.WithBatcher(exporter,
+				trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
+				trace.WithBatchTimeout(trace.DefaultScheduleDelay*time.Millisecond),
 			),
-		),
-	)
+		)
+	}
+
+	opts = append(opts, trace.WithResource(res))
+
+	traceProvider := trace.NewTracerProvider(opts...)
 
 	// We must set this provider as the global provider for things to work,
 	// but we pass this provider around the program where needed to collect
@@ -347,3 +1033,184 @@ func startTracing(serviceName string, reporterURI string, probability float64) (
 
 	return traceProvider, nil
 }
+
+// newTraceExporter constructs the OTLP exporter for the protocol. The
+// connection is in plain text when insecure, otherwise it is verified with
+// the CA certificate or the system roots.
+func newTraceExporter(cfg tracingConfig) (*otlptrace.Exporter, error) {
+	var tlsCfg *tls.Config
+	if !cfg.Insecure {
+		tlsCfg = &tls.Config{MinVersion: tls.VersionTLS12}
+
+		if cfg.CACert != "" {
+			pem, err := os.ReadFile(cfg.CACert)
+			if err != nil {
+				return nil, fmt.Errorf("reading ca cert: %w", err)
+			}
+
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", cfg.CACert)
+			}
+			tlsCfg.RootCAs = pool
+		}
+	}
+
+	var client otlptrace.Client
+
+	switch cfg.Protocol {
+	case "grpc":
+		opts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(cfg.ReporterURI),
+			otlptracegrpc.WithHeaders(cfg.Headers),
+		}
+
+		switch tlsCfg {
+		case nil:
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		default:
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+		}
+
+		client = otlptracegrpc.NewClient(opts...)
+
+	case "http":
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.ReporterURI),
+			otlptracehttp.WithHeaders(cfg.Headers),
+		}
+
+		switch tlsCfg {
+		case nil:
+			opts = append(opts, otlptracehttp.WithInsecure())
+		default:
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		}
+
+		client = otlptracehttp.NewClient(opts...)
+
+	default:
+		return nil, fmt.Errorf("unknown tracing protocol %q", cfg.Protocol)
+	}
+
+	return otlptrace.New(context.Background(), client)
+}
+
+// parsePairs parses a list of key=value pairs.
+func parsePairs(pairs []string) (map[string]string, error) {
+	m := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid pair %q, expected key=value", pair)
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	return m, nil
+}
+
+// parseProbabilities parses a list of path=probability pairs.
+func parseProbabilities(pairs []string) (map[string]float64, error) {
+	kv, err := parsePairs(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]float64, len(kv))
+	for k, v := range kv {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil || p < 0 || p > 1 {
+			return nil, fmt.Errorf("invalid probability %q for %s", v, k)
+		}
+		m[k] = p
+	}
+
+	return m, nil
+}
+
+// startLogs configure open telemetry to export the log records handed to the
+// slog bridge.
+func startLogs(res *resource.Resource, exporter string, endpoint string, insecure bool) (*sdklog.LoggerProvider, error) {
+	var exp sdklog.Exporter
+	var err error
+
+	switch exporter {
+	case "otlp-grpc":
+		opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlploggrpc.WithInsecure())
+		}
+		exp, err = otlploggrpc.New(context.Background(), opts...)
+
+	case "otlp-http":
+		opts := []otlploghttp.Option{otlploghttp.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlploghttp.WithInsecure())
+		}
+		exp, err = otlploghttp.New(context.Background(), opts...)
+
+	default:
+		return nil, fmt.Errorf("unknown log exporter %q", exporter)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("creating new exporter: %w", err)
+	}
+
+	logProvider := sdklog.NewLoggerProvider(
+		sdklog.WithResource(res),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exp)),
+	)
+
+	return logProvider, nil
+}
+
+// startMetrics configure open telemetry to export the metrics recorded
+// through the global meter provider. The stdout exporter is meant for local
+// testing and none records the metrics without exporting them.
+func startMetrics(res *resource.Resource, exporter string, endpoint string, insecure bool, interval time.Duration) (*sdkmetric.MeterProvider, error) {
+	var exp sdkmetric.Exporter
+	var err error
+
+	switch exporter {
+	case "otlp-grpc":
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+		exp, err = otlpmetricgrpc.New(context.Background(), opts...)
+
+	case "otlp-http":
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		exp, err = otlpmetrichttp.New(context.Background(), opts...)
+
+	case "stdout":
+		exp, err = stdoutmetric.New(stdoutmetric.WithPrettyPrint())
+
+	case "none", "":
+
+	default:
+		return nil, fmt.Errorf("unknown metrics exporter %q", exporter)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("creating new exporter: %w", err)
+	}
+
+	opts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	if exp != nil {
+		opts = append(opts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp, sdkmetric.WithInterval(interval))))
+	}
+
+	meterProvider := sdkmetric.NewMeterProvider(opts...)
+
+	// The instruments of the application are created from the global meter
+	// provider, which hands their values to this provider once it is set.
+	otel.SetMeterProvider(meterProvider)
+
+	return meterProvider, nil
+}
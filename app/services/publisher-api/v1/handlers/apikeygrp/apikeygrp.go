@@ -0,0 +1,138 @@
+// Package apikeygrp maintains the group of handlers for api key access.
+package apikeygrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/response"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+// Handlers manages the set of api key endpoints.
+type Handlers struct {
+	apiKey *apikey.Core
+}
+
+// New constructs a handlers for route access.
+func New(apiKey *apikey.Core) *Handlers {
+	return &Handlers{
+		apiKey: apiKey,
+	}
+}
+
+// Create adds a new api key for the organisation of the caller. The caller
+// can't grant permissions it doesn't hold itself.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewAPIKey
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	claims := auth.GetClaims(ctx)
+
+	if !claims.HasPermissionsInOrganisation(app.Permissions...) {
+		return auth.NewForbiddenError("create: subject[%s] can't grant permissions %v", claims.Subject, app.Permissions)
+	}
+
+	key, secret, err := h.apiKey.Create(ctx, toCoreNewAPIKey(app, claims.Org, claims.Subject))
+	if err != nil {
+		return fmt.Errorf("create: app[%+v]: %w", app, err)
+	}
+
+	resp := AppAPIKeySecret{
+		AppAPIKey: toAppAPIKey(key),
+		Key:       secret,
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusCreated)
+}
+
+// Rotate replaces the secret of an api key.
+func (h *Handlers) Rotate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	key, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	rotated, secret, err := h.apiKey.Rotate(ctx, key)
+	if err != nil {
+		if errors.Is(err, apikey.ErrRevoked) {
+			return response.NewError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("rotate: keyID[%s]: %w", key.ID, err)
+	}
+
+	resp := AppAPIKeySecret{
+		AppAPIKey: toAppAPIKey(rotated),
+		Key:       secret,
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// Revoke marks an api key as revoked.
+func (h *Handlers) Revoke(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	key, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.apiKey.Revoke(ctx, key); err != nil {
+		return fmt.Errorf("revoke: keyID[%s]: %w", key.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns the list of api keys for the organisation of the caller.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims := auth.GetClaims(ctx)
+
+	keys, err := h.apiKey.QueryByOrg(ctx, claims.Org)
+	if err != nil {
+		return fmt.Errorf("query: org[%s]: %w", claims.Org, err)
+	}
+
+	return web.Respond(ctx, w, toAppAPIKeys(keys), http.StatusOK)
+}
+
+// QueryByID returns an api key by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	key, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppAPIKey(key), http.StatusOK)
+}
+
+// =============================================================================
+
+// queryByID retrieves the api key named in the route and makes sure it
+// belongs to the organisation of the caller.
+func (h *Handlers) queryByID(ctx context.Context, r *http.Request) (apikey.APIKey, error) {
+	keyID, err := uuid.Parse(web.Param(r, "key_id"))
+	if err != nil {
+		return apikey.APIKey{}, response.NewError(fmt.Errorf("invalid key id: %w", err), http.StatusBadRequest)
+	}
+
+	key, err := h.apiKey.QueryByID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, apikey.ErrNotFound) {
+			return apikey.APIKey{}, response.NewError(err, http.StatusNotFound)
+		}
+		return apikey.APIKey{}, fmt.Errorf("querybyid: keyID[%s]: %w", keyID, err)
+	}
+
+	if key.Org != auth.GetClaims(ctx).Org {
+		return apikey.APIKey{}, response.NewError(apikey.ErrNotFound, http.StatusNotFound)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,84 @@
+package apikeygrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey"
+	"github.com/vikaskumar1187/publisher_saas/foundation/validate"
+)
+
+// AppAPIKey represents information about an individual api key. The secret
+// is never part of this value.
+type AppAPIKey struct {
+	ID          string   `json:"id"`
+	Org         string   `json:"org"`
+	Name        string   `json:"name"`
+	Prefix      string   `json:"prefix"`
+	Permissions []string `json:"permissions"`
+	CreatedBy   string   `json:"createdBy"`
+	DateCreated string   `json:"dateCreated"`
+	DateUpdated string   `json:"dateUpdated"`
+	DateRevoked string   `json:"dateRevoked,omitempty"`
+}
+
+func toAppAPIKey(key apikey.APIKey) AppAPIKey {
+	app := AppAPIKey{
+		ID:          key.ID.String(),
+		Org:         key.Org,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Permissions: key.Permissions,
+		CreatedBy:   key.CreatedBy,
+		DateCreated: key.DateCreated.Format(time.RFC3339),
+		DateUpdated: key.DateUpdated.Format(time.RFC3339),
+	}
+
+	if key.Revoked() {
+		app.DateRevoked = key.DateRevoked.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppAPIKeys(keys []apikey.APIKey) []AppAPIKey {
+	items := make([]AppAPIKey, len(keys))
+	for i, key := range keys {
+		items[i] = toAppAPIKey(key)
+	}
+
+	return items
+}
+
+// AppAPIKeySecret is returned when an api key is created or rotated. This is
+// the only time the secret is available.
+type AppAPIKeySecret struct {
+	AppAPIKey
+	Key string `json:"key"`
+}
+
+// =============================================================================
+
+// AppNewAPIKey contains information needed to create a new api key.
+type AppNewAPIKey struct {
+	Name        string   `json:"name" validate:"required"`
+	Permissions []string `json:"permissions" validate:"required,min=1,dive,oneof=pagehub:read pagehub:publish pagehub:admin"`
+}
+
+func toCoreNewAPIKey(app AppNewAPIKey, org string, createdBy string) apikey.NewAPIKey {
+	return apikey.NewAPIKey{
+		Org:         org,
+		Name:        app.Name,
+		Permissions: app.Permissions,
+		CreatedBy:   createdBy,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewAPIKey) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package apikeygrp
+
+import (
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey"
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey/stores/apikeydb"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/mid"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log  *logger.Logger
+	Auth *auth.Auth
+	DB   *sqlx.DB
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	apiKeyCore := apikey.NewCore(cfg.Log, apikeydb.NewStore(cfg.Log, cfg.DB))
+
+	authen := mid.Authenticate(cfg.Auth)
+	ruleAdmin := mid.Authorize(cfg.Auth, auth.RuleAdmin)
+
+	hdl := New(apiKeyCore)
+	app.Handle(http.MethodGet, version, "/apikeys", hdl.Query, authen, ruleAdmin)
+	app.Handle(http.MethodGet, version, "/apikeys/:key_id", hdl.QueryByID, authen, ruleAdmin)
+	app.Handle(http.MethodPost, version, "/apikeys", hdl.Create, authen, ruleAdmin)
+	app.Handle(http.MethodPost, version, "/apikeys/:key_id/rotate", hdl.Rotate, authen, ruleAdmin)
+	app.Handle(http.MethodDelete, version, "/apikeys/:key_id", hdl.Revoke, authen, ruleAdmin)
+}
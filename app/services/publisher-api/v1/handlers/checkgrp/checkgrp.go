@@ -3,12 +3,14 @@ package checkgrp
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/migrate"
 
 	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
 	"github.com/vikaskumar1187/publisher_saas/foundation/web"
@@ -16,21 +18,31 @@ import (
 
 // Handlers manages the set of check endpoints.
 type Handlers struct {
-	build string
-	log   *logger.Logger
-	db    *sqlx.DB
+	build      string
+	log        *logger.Logger
+	db         *sqlx.DB
+	replica    *db.Replica
+	migrations *migrate.Gate
 }
 
-// New constructs a Handlers api for the check group.
-func New(build string, log *logger.Logger, db *sqlx.DB) *Handlers {
+// New constructs a Handlers api for the check group. The replica and the
+// migration gate are optional. The replica is only provided when one is
+// configured and the gate when migrations are applied at startup.
+func New(build string, log *logger.Logger, db *sqlx.DB, replica *db.Replica, migrations *migrate.Gate) *Handlers {
 	return &Handlers{
-		build: build,
-		db:    db,
-		log:   log,
+		build:      build,
+		db:         db,
+		replica:    replica,
+		log:        log,
+		migrations: migrations,
 	}
 }
 
 // Readiness checks if the database is ready and if not will return a 500 status.
+// When migrations are applied at startup, it also reports not ready until
+// they complete. The current schema version is part of the payload, and so
+// is the health of the read replica when there is one. An unhealthy replica
+// doesn't fail the check since the queries fall back to the primary.
 // Do not respond by just returning an error because further up in the call
 // stack it will interpret that as a non-trusted error.
 func (h *Handlers) Readiness(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -42,16 +54,71 @@ func (h *Handlers) Readiness(ctx context.Context, w http.ResponseWriter, r *http
 
 	status := "ok"
 	statusCode := http.StatusOK
-	if err := db.StatusCheck(ctx, h.db); err != nil {
-		status = "db not ready"
-		statusCode = http.StatusInternalServerError
+	var version int
+
+	switch {
+	case h.migrations != nil && !h.migrations.Ready():
+		status = "migrations not complete"
+		if err := h.migrations.Err(); err != nil {
+			status = "migrations failed"
+		}
+		statusCode = http.StatusServiceUnavailable
 		h.log.Info(ctx, "readiness failure", "status", status)
+
+	default:
+		if err := db.StatusCheck(ctx, h.db); err != nil {
+			status = "db not ready"
+			statusCode = http.StatusInternalServerError
+			h.log.Info(ctx, "readiness failure", "status", status)
+			break
+		}
+
+		// The gate knows the version the migrations brought the schema to,
+		// so the version table is only queried when there is no gate.
+		if h.migrations != nil {
+			version = h.migrations.Version()
+			break
+		}
+
+		v, err := migrate.Version(ctx, h.db)
+		if err != nil {
+			status = "schema version unavailable"
+			statusCode = http.StatusInternalServerError
+			h.log.Info(ctx, "readiness failure", "status", status, "msg", err)
+			break
+		}
+		version = v
+	}
+
+	type replicaStatus struct {
+		Status     string  `json:"status"`
+		LagSeconds float64 `json:"lagSeconds"`
+	}
+
+	var replica *replicaStatus
+	if h.replica != nil {
+		replica = &replicaStatus{
+			Status:     "ok",
+			LagSeconds: h.replica.Lag().Seconds(),
+		}
+
+		if err := h.replica.StatusCheck(); err != nil {
+			replica.Status = "unhealthy"
+			if errors.Is(err, db.ErrReplicaLagging) {
+				replica.Status = "lagging"
+			}
+			h.log.Info(ctx, "readiness replica", "status", replica.Status, "msg", err)
+		}
 	}
 
 	data := struct {
-		Status string `json:"status"`
+		Status        string         `json:"status"`
+		SchemaVersion int            `json:"schemaVersion"`
+		Replica       *replicaStatus `json:"replica,omitempty"`
 	}{
-		Status: status,
+		Status:        status,
+		SchemaVersion: version,
+		Replica:       replica,
 	}
 
 	return web.Respond(ctx, w, data, statusCode)
@@ -0,0 +1,67 @@
+package checkgrp_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/app/services/publisher-api/v1/handlers/checkgrp"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/migrate"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+func Test_ReadinessGate(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	unreachable, err := db.Open(db.Config{Host: "127.0.0.1:1", Name: "postgres", DisableTLS: true})
+	if err != nil {
+		t.Fatalf("Should be able to open the database handle: %v", err)
+	}
+	defer unreachable.Close()
+
+	failed := migrate.NewGate()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	failed.Run(ctx, log, unreachable)
+
+	tests := []struct {
+		name   string
+		gate   *migrate.Gate
+		code   int
+		status string
+	}{
+		{"pending", migrate.NewGate(), http.StatusServiceUnavailable, "migrations not complete"},
+		{"failed", failed, http.StatusServiceUnavailable, "migrations failed"},
+		{"no gate", nil, http.StatusInternalServerError, "db not ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := checkgrp.New("test", log, unreachable, nil, tt.gate)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/v1/readiness", nil)
+
+			if err := h.Readiness(context.Background(), w, r); err != nil {
+				t.Fatalf("Should respond without an error: %v", err)
+			}
+
+			var doc struct {
+				Status string `json:"status"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+				t.Fatalf("Should respond with json: %v", err)
+			}
+
+			if w.Code != tt.code || doc.Status != tt.status {
+				t.Fatalf("Should respond %d %q: got %d %q", tt.code, tt.status, w.Code, doc.Status)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/ServiceWeaver/weaver"
 	"github.com/jmoiron/sqlx"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/migrate"
 
 	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
 	"github.com/vikaskumar1187/publisher_saas/foundation/web"
@@ -16,13 +18,15 @@ type Config struct {
 	Build       string
 	Log         *logger.Logger
 	DB          *sqlx.DB
+	Replica     *db.Replica
+	Migrations  *migrate.Gate
 }
 
 // Routes adds specific routes for this group.
 func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
-	hdl := New(cfg.Build, cfg.Log, cfg.DB)
+	hdl := New(cfg.Build, cfg.Log, cfg.DB, cfg.Replica, cfg.Migrations)
 	app.HandleNoMiddleware(http.MethodGet, version, "/readiness", hdl.Readiness)
 	app.HandleNoMiddleware(http.MethodGet, version, "/liveness", hdl.Liveness)
 
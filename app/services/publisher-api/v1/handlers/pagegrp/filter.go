@@ -0,0 +1,35 @@
+package pagegrp
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	"github.com/vikaskumar1187/publisher_saas/foundation/validate"
+)
+
+func parseFilter(r *http.Request, org string) (publication.QueryFilter, error) {
+	values := r.URL.Query()
+
+	filter := publication.QueryFilter{
+		Org: org,
+	}
+
+	if status := values.Get("status"); status != "" {
+		s, err := publication.ParseStatus(status)
+		if err != nil {
+			return publication.QueryFilter{}, validate.NewFieldsError("status", err)
+		}
+		filter.WithStatus(s)
+	}
+
+	if editionID := values.Get("edition_id"); editionID != "" {
+		id, err := uuid.Parse(editionID)
+		if err != nil {
+			return publication.QueryFilter{}, validate.NewFieldsError("edition_id", err)
+		}
+		filter.WithEditionID(id)
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,299 @@
+package pagegrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/foundation/validate"
+)
+
+// dateLayout is the layout used for edition issue dates.
+const dateLayout = "2006-01-02"
+
+// AppPage represents information about an individual page.
+type AppPage struct {
+	ID          string `json:"id"`
+	Org         string `json:"org"`
+	EditionID   string `json:"editionId,omitempty"`
+	Title       string `json:"title"`
+	Slug        string `json:"slug"`
+	Content     string `json:"content"`
+	Status      string `json:"status"`
+	ScheduledAt string `json:"scheduledAt,omitempty"`
+	PublishedAt string `json:"publishedAt,omitempty"`
+	CreatedBy   string `json:"createdBy"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
+}
+
+func toAppPage(page publication.Page) AppPage {
+	app := AppPage{
+		ID:          page.ID.String(),
+		Org:         page.Org,
+		Title:       page.Title,
+		Slug:        page.Slug,
+		Content:     page.Content,
+		Status:      page.Status.Name(),
+		CreatedBy:   page.CreatedBy,
+		DateCreated: page.DateCreated.Format(time.RFC3339),
+		DateUpdated: page.DateUpdated.Format(time.RFC3339),
+	}
+
+	if page.EditionID != uuid.Nil {
+		app.EditionID = page.EditionID.String()
+	}
+
+	if !page.ScheduledAt.IsZero() {
+		app.ScheduledAt = page.ScheduledAt.Format(time.RFC3339)
+	}
+
+	if !page.PublishedAt.IsZero() {
+		app.PublishedAt = page.PublishedAt.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+// pageCSVHeader is the header of the CSV export of the pages.
+var pageCSVHeader = []string{"id", "org", "editionId", "title", "slug", "content", "status", "scheduledAt", "publishedAt", "createdBy", "dateCreated", "dateUpdated"}
+
+func toPageCSVRecord(app AppPage) []string {
+	return []string{
+		app.ID,
+		app.Org,
+		app.EditionID,
+		app.Title,
+		app.Slug,
+		app.Content,
+		app.Status,
+		app.ScheduledAt,
+		app.PublishedAt,
+		app.CreatedBy,
+		app.DateCreated,
+		app.DateUpdated,
+	}
+}
+
+func toAppPages(pages []publication.Page) []AppPage {
+	items := make([]AppPage, len(pages))
+	for i, page := range pages {
+		items[i] = toAppPage(page)
+	}
+
+	return items
+}
+
+// AppNewPage contains information needed to create a new page.
+type AppNewPage struct {
+	EditionID string `json:"editionId" validate:"omitempty,uuid"`
+	Title     string `json:"title" validate:"required"`
+	Slug      string `json:"slug" validate:"required,max=200"`
+	Content   string `json:"content"`
+}
+
+func toCoreNewPage(app AppNewPage, org string, createdBy string) (publication.NewPage, error) {
+	var editionID uuid.UUID
+	if app.EditionID != "" {
+		var err error
+		editionID, err = uuid.Parse(app.EditionID)
+		if err != nil {
+			return publication.NewPage{}, fmt.Errorf("parse editionID: %w", err)
+		}
+	}
+
+	np := publication.NewPage{
+		Org:       org,
+		EditionID: editionID,
+		Title:     app.Title,
+		Slug:      app.Slug,
+		Content:   app.Content,
+		CreatedBy: createdBy,
+	}
+
+	return np, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewPage) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// AppUpdatePage contains information needed to update a page. An empty
+// edition id removes the page from its edition.
+type AppUpdatePage struct {
+	EditionID *string `json:"editionId"`
+	Title     *string `json:"title" validate:"omitempty,min=1"`
+	Slug      *string `json:"slug" validate:"omitempty,min=1,max=200"`
+	Content   *string `json:"content"`
+}
+
+func toCoreUpdatePage(app AppUpdatePage) (publication.UpdatePage, error) {
+	var editionID *uuid.UUID
+	if app.EditionID != nil {
+		var id uuid.UUID
+		if *app.EditionID != "" {
+			var err error
+			id, err = uuid.Parse(*app.EditionID)
+			if err != nil {
+				return publication.UpdatePage{}, fmt.Errorf("parse editionID: %w", err)
+			}
+		}
+		editionID = &id
+	}
+
+	up := publication.UpdatePage{
+		EditionID: editionID,
+		Title:     app.Title,
+		Slug:      app.Slug,
+		Content:   app.Content,
+	}
+
+	return up, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdatePage) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// AppSchedulePage contains the time a page should go live.
+type AppSchedulePage struct {
+	PublishAt string `json:"publishAt" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppSchedulePage) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppPublishJob represents the acknowledgement of a queued publish.
+type AppPublishJob struct {
+	JobID  string `json:"jobId"`
+	PageID string `json:"pageId"`
+	Status string `json:"status"`
+	RunAt  string `json:"runAt"`
+}
+
+func toAppPublishJob(page publication.Page, job queue.Job) AppPublishJob {
+	return AppPublishJob{
+		JobID:  job.ID.String(),
+		PageID: page.ID.String(),
+		Status: job.Status,
+		RunAt:  job.RunAt.Format(time.RFC3339),
+	}
+}
+
+// =============================================================================
+
+// AppEdition represents information about an individual edition.
+type AppEdition struct {
+	ID          string `json:"id"`
+	Org         string `json:"org"`
+	Name        string `json:"name"`
+	IssueDate   string `json:"issueDate"`
+	CreatedBy   string `json:"createdBy"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
+}
+
+func toAppEdition(edition publication.Edition) AppEdition {
+	return AppEdition{
+		ID:          edition.ID.String(),
+		Org:         edition.Org,
+		Name:        edition.Name,
+		IssueDate:   edition.IssueDate.Format(dateLayout),
+		CreatedBy:   edition.CreatedBy,
+		DateCreated: edition.DateCreated.Format(time.RFC3339),
+		DateUpdated: edition.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppEditions(editions []publication.Edition) []AppEdition {
+	items := make([]AppEdition, len(editions))
+	for i, edition := range editions {
+		items[i] = toAppEdition(edition)
+	}
+
+	return items
+}
+
+// AppNewEdition contains information needed to create a new edition.
+type AppNewEdition struct {
+	Name      string `json:"name" validate:"required"`
+	IssueDate string `json:"issueDate" validate:"required,datetime=2006-01-02"`
+}
+
+func toCoreNewEdition(app AppNewEdition, org string, createdBy string) (publication.NewEdition, error) {
+	issueDate, err := time.Parse(dateLayout, app.IssueDate)
+	if err != nil {
+		return publication.NewEdition{}, fmt.Errorf("parse issueDate: %w", err)
+	}
+
+	ne := publication.NewEdition{
+		Org:       org,
+		Name:      app.Name,
+		IssueDate: issueDate,
+		CreatedBy: createdBy,
+	}
+
+	return ne, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewEdition) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// AppUpdateEdition contains information needed to update an edition.
+type AppUpdateEdition struct {
+	Name      *string `json:"name" validate:"omitempty,min=1"`
+	IssueDate *string `json:"issueDate" validate:"omitempty,datetime=2006-01-02"`
+}
+
+func toCoreUpdateEdition(app AppUpdateEdition) (publication.UpdateEdition, error) {
+	var issueDate *time.Time
+	if app.IssueDate != nil {
+		t, err := time.Parse(dateLayout, *app.IssueDate)
+		if err != nil {
+			return publication.UpdateEdition{}, fmt.Errorf("parse issueDate: %w", err)
+		}
+		issueDate = &t
+	}
+
+	ue := publication.UpdateEdition{
+		Name:      app.Name,
+		IssueDate: issueDate,
+	}
+
+	return ue, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateEdition) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,466 @@
+// Package pagegrp maintains the group of handlers for pages and editions.
+package pagegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/paging"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/response"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+// Handlers manages the set of page and edition endpoints.
+type Handlers struct {
+	publication *publication.Core
+}
+
+// New constructs a handlers for route access.
+func New(publication *publication.Core) *Handlers {
+	return &Handlers{
+		publication: publication,
+	}
+}
+
+// =============================================================================
+// Pages
+
+// CreatePage adds a new page in the draft status.
+func (h *Handlers) CreatePage(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	var app AppNewPage
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	claims := auth.GetClaims(ctx)
+
+	np, err := toCoreNewPage(app, claims.Org, claims.Subject)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	page, err := h.publication.CreatePage(ctx, np)
+	if err != nil {
+		return toWebError(fmt.Errorf("create: app[%+v]: %w", app, err))
+	}
+
+	return web.Respond(ctx, w, toAppPage(page), http.StatusCreated)
+}
+
+// UpdatePage updates a page in the system.
+func (h *Handlers) UpdatePage(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	var app AppUpdatePage
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	up, err := toCoreUpdatePage(app)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	page, err := h.queryPage(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	updated, err := h.publication.UpdatePage(ctx, page, up)
+	if err != nil {
+		return toWebError(fmt.Errorf("update: pageID[%s] app[%+v]: %w", page.ID, app, err))
+	}
+
+	return web.Respond(ctx, w, toAppPage(updated), http.StatusOK)
+}
+
+// DeletePage removes a page from the system.
+func (h *Handlers) DeletePage(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.queryPage(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.publication.DeletePage(ctx, page); err != nil {
+		return toWebError(fmt.Errorf("delete: pageID[%s]: %w", page.ID, err))
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// QueryPages returns a list of pages with paging.
+func (h *Handlers) QueryPages(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	filter, err := parseFilter(r, auth.GetClaims(ctx).Org)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	return h.respondPages(ctx, w, filter, page)
+}
+
+// QueryPageByID returns a page by its ID.
+func (h *Handlers) QueryPageByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := h.queryPage(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppPage(page), http.StatusOK)
+}
+
+// ExportPages streams every page that matches the filter, without paging, as
+// newline delimited JSON or as CSV when the format is csv.
+func (h *Handlers) ExportPages(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	filter, err := parseFilter(r, auth.GetClaims(ctx).Org)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "ndjson":
+		stream := web.NewNDJSONStream(ctx, w, http.StatusOK)
+
+		err := h.publication.ExportPages(ctx, filter, func(page publication.Page) error {
+			return stream.Send(toAppPage(page))
+		})
+
+		return stream.Close(err)
+
+	case "csv":
+		stream := web.NewCSVStream(ctx, w, http.StatusOK, pageCSVHeader)
+
+		err := h.publication.ExportPages(ctx, filter, func(page publication.Page) error {
+			return stream.Send(toPageCSVRecord(toAppPage(page)))
+		})
+
+		return stream.Close(err)
+
+	default:
+		return response.NewError(fmt.Errorf("invalid format %q", format), http.StatusBadRequest)
+	}
+}
+
+// =============================================================================
+// Lifecycle
+
+// SchedulePage schedules a page to go live at the specified time.
+func (h *Handlers) SchedulePage(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	var app AppSchedulePage
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	publishAt, err := time.Parse(time.RFC3339, app.PublishAt)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	page, err := h.queryPage(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	scheduled, err := h.publication.SchedulePage(ctx, page, publishAt)
+	if err != nil {
+		return toWebError(fmt.Errorf("schedule: pageID[%s]: %w", page.ID, err))
+	}
+
+	return web.Respond(ctx, w, toAppPage(scheduled), http.StatusOK)
+}
+
+// PublishPage queues the page to be published. Publishing runs in the
+// background so the response only acknowledges the request.
+func (h *Handlers) PublishPage(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := h.queryPage(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	job, err := h.publication.RequestPublish(ctx, page)
+	if err != nil {
+		return toWebError(fmt.Errorf("publish: pageID[%s]: %w", page.ID, err))
+	}
+
+	return web.Respond(ctx, w, toAppPublishJob(page, job), http.StatusAccepted)
+}
+
+// UnpublishPage takes a published page offline.
+func (h *Handlers) UnpublishPage(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.queryPage(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	unpublished, err := h.publication.UnpublishPage(ctx, page)
+	if err != nil {
+		return toWebError(fmt.Errorf("unpublish: pageID[%s]: %w", page.ID, err))
+	}
+
+	return web.Respond(ctx, w, toAppPage(unpublished), http.StatusOK)
+}
+
+// RevertPage moves a scheduled or unpublished page back to draft.
+func (h *Handlers) RevertPage(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.queryPage(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	reverted, err := h.publication.RevertPageToDraft(ctx, page)
+	if err != nil {
+		return toWebError(fmt.Errorf("revert: pageID[%s]: %w", page.ID, err))
+	}
+
+	return web.Respond(ctx, w, toAppPage(reverted), http.StatusOK)
+}
+
+// =============================================================================
+// Editions
+
+// CreateEdition adds a new edition.
+func (h *Handlers) CreateEdition(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	var app AppNewEdition
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	claims := auth.GetClaims(ctx)
+
+	ne, err := toCoreNewEdition(app, claims.Org, claims.Subject)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	edition, err := h.publication.CreateEdition(ctx, ne)
+	if err != nil {
+		return toWebError(fmt.Errorf("create: app[%+v]: %w", app, err))
+	}
+
+	return web.Respond(ctx, w, toAppEdition(edition), http.StatusCreated)
+}
+
+// UpdateEdition updates an edition in the system.
+func (h *Handlers) UpdateEdition(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	var app AppUpdateEdition
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	ue, err := toCoreUpdateEdition(app)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	edition, err := h.queryEdition(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	updated, err := h.publication.UpdateEdition(ctx, edition, ue)
+	if err != nil {
+		return toWebError(fmt.Errorf("update: editionID[%s] app[%+v]: %w", edition.ID, app, err))
+	}
+
+	return web.Respond(ctx, w, toAppEdition(updated), http.StatusOK)
+}
+
+// DeleteEdition removes an edition from the system.
+func (h *Handlers) DeleteEdition(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	edition, err := h.queryEdition(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.publication.DeleteEdition(ctx, edition); err != nil {
+		return toWebError(fmt.Errorf("delete: editionID[%s]: %w", edition.ID, err))
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// QueryEditions returns a list of editions with paging.
+func (h *Handlers) QueryEditions(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	org := auth.GetClaims(ctx).Org
+
+	editions, err := h.publication.QueryEditions(ctx, org, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.publication.CountEditions(ctx, org)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, response.NewPageDocument(toAppEditions(editions), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryEditionByID returns an edition by its ID.
+func (h *Handlers) QueryEditionByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	edition, err := h.queryEdition(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppEdition(edition), http.StatusOK)
+}
+
+// QueryEditionPages returns the pages of an edition with paging.
+func (h *Handlers) QueryEditionPages(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	edition, err := h.queryEdition(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r, edition.Org)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+	filter.WithEditionID(edition.ID)
+
+	return h.respondPages(ctx, w, filter, page)
+}
+
+// =============================================================================
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *Handlers) executeUnderTransaction(ctx context.Context) (*Handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		publication, err := h.publication.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		return New(publication), nil
+	}
+
+	return h, nil
+}
+
+func (h *Handlers) respondPages(ctx context.Context, w http.ResponseWriter, filter publication.QueryFilter, page paging.Page) error {
+	pages, err := h.publication.QueryPages(ctx, filter, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.publication.CountPages(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, response.NewPageDocument(toAppPages(pages), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+func (h *Handlers) queryPage(ctx context.Context, r *http.Request) (publication.Page, error) {
+	pageID, err := uuid.Parse(web.Param(r, "page_id"))
+	if err != nil {
+		return publication.Page{}, response.NewError(fmt.Errorf("invalid page id: %w", err), http.StatusBadRequest)
+	}
+
+	page, err := h.publication.QueryPageByID(ctx, auth.GetClaims(ctx).Org, pageID)
+	if err != nil {
+		return publication.Page{}, toWebError(fmt.Errorf("querybyid: pageID[%s]: %w", pageID, err))
+	}
+
+	return page, nil
+}
+
+func (h *Handlers) queryEdition(ctx context.Context, r *http.Request) (publication.Edition, error) {
+	editionID, err := uuid.Parse(web.Param(r, "edition_id"))
+	if err != nil {
+		return publication.Edition{}, response.NewError(fmt.Errorf("invalid edition id: %w", err), http.StatusBadRequest)
+	}
+
+	edition, err := h.publication.QueryEditionByID(ctx, auth.GetClaims(ctx).Org, editionID)
+	if err != nil {
+		return publication.Edition{}, toWebError(fmt.Errorf("querybyid: editionID[%s]: %w", editionID, err))
+	}
+
+	return edition, nil
+}
+
+// toWebError maps the expected errors of the publication core to the
+// response status the client should see.
+func toWebError(err error) error {
+	switch {
+	case errors.Is(err, publication.ErrNotFound):
+		return response.NewError(publication.ErrNotFound, http.StatusNotFound)
+
+	case errors.Is(err, publication.ErrUniqueSlug):
+		return response.NewError(publication.ErrUniqueSlug, http.StatusConflict)
+
+	case errors.Is(err, publication.ErrInvalidTransition):
+		return response.NewError(err, http.StatusConflict)
+
+	case errors.Is(err, publication.ErrScheduleInPast):
+		return response.NewError(publication.ErrScheduleInPast, http.StatusBadRequest)
+	}
+
+	return err
+}
@@ -0,0 +1,55 @@
+package pagegrp
+
+import (
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication/stores/publicationdb"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook/stores/webhookdb"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/mid"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log  *logger.Logger
+	Auth *auth.Auth
+	DB   *sqlx.DB
+	Tran web.Middleware
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	whCore := webhook.NewCore(cfg.Log, webhookdb.NewStore(cfg.Log, cfg.DB))
+	pubCore := publication.NewCore(cfg.Log, db.NewBeginner(cfg.DB), whCore, publicationdb.NewStore(cfg.Log, cfg.DB))
+
+	authen := mid.Authenticate(cfg.Auth)
+	ruleRead := mid.Authorize(cfg.Auth, auth.RuleRead)
+	rulePublish := mid.Authorize(cfg.Auth, auth.RulePublish)
+
+	hdl := New(pubCore)
+	app.Handle(http.MethodGet, version, "/pages", hdl.QueryPages, authen, ruleRead)
+	app.Handle(http.MethodGet, version, "/pages/export", hdl.ExportPages, authen, ruleRead)
+	app.Handle(http.MethodGet, version, "/pages/:page_id", hdl.QueryPageByID, authen, ruleRead)
+	app.Handle(http.MethodPost, version, "/pages", hdl.CreatePage, authen, rulePublish, cfg.Tran)
+	app.Handle(http.MethodPut, version, "/pages/:page_id", hdl.UpdatePage, authen, rulePublish, cfg.Tran)
+	app.Handle(http.MethodDelete, version, "/pages/:page_id", hdl.DeletePage, authen, rulePublish, cfg.Tran)
+	app.Handle(http.MethodPost, version, "/pages/:page_id/schedule", hdl.SchedulePage, authen, rulePublish, cfg.Tran)
+	app.Handle(http.MethodPost, version, "/pages/:page_id/publish", hdl.PublishPage, authen, rulePublish)
+	app.Handle(http.MethodPost, version, "/pages/:page_id/unpublish", hdl.UnpublishPage, authen, rulePublish, cfg.Tran)
+	app.Handle(http.MethodPost, version, "/pages/:page_id/draft", hdl.RevertPage, authen, rulePublish, cfg.Tran)
+
+	app.Handle(http.MethodGet, version, "/editions", hdl.QueryEditions, authen, ruleRead)
+	app.Handle(http.MethodGet, version, "/editions/:edition_id", hdl.QueryEditionByID, authen, ruleRead)
+	app.Handle(http.MethodGet, version, "/editions/:edition_id/pages", hdl.QueryEditionPages, authen, ruleRead)
+	app.Handle(http.MethodPost, version, "/editions", hdl.CreateEdition, authen, rulePublish, cfg.Tran)
+	app.Handle(http.MethodPut, version, "/editions/:edition_id", hdl.UpdateEdition, authen, rulePublish, cfg.Tran)
+	app.Handle(http.MethodDelete, version, "/editions/:edition_id", hdl.DeleteEdition, authen, rulePublish, cfg.Tran)
+}
@@ -0,0 +1,203 @@
+package schedulegrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/schedule"
+	"github.com/vikaskumar1187/publisher_saas/foundation/validate"
+)
+
+// AppSchedule represents information about an individual schedule.
+type AppSchedule struct {
+	ID          string `json:"id"`
+	Org         string `json:"org"`
+	Name        string `json:"name"`
+	TargetType  string `json:"targetType"`
+	TargetID    string `json:"targetId,omitempty"`
+	Spec        string `json:"spec,omitempty"`
+	RunAt       string `json:"runAt,omitempty"`
+	TimeZone    string `json:"timeZone"`
+	CatchUp     string `json:"catchUp,omitempty"`
+	Enabled     bool   `json:"enabled"`
+	NextRunAt   string `json:"nextRunAt,omitempty"`
+	LastRunAt   string `json:"lastRunAt,omitempty"`
+	CreatedBy   string `json:"createdBy"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
+}
+
+func toAppSchedule(sch schedule.Schedule) AppSchedule {
+	app := AppSchedule{
+		ID:          sch.ID.String(),
+		Org:         sch.Org,
+		Name:        sch.Name,
+		TargetType:  sch.TargetType.Name(),
+		Spec:        sch.Spec,
+		TimeZone:    sch.TimeZone,
+		CatchUp:     sch.CatchUp.Name(),
+		Enabled:     sch.Enabled,
+		CreatedBy:   sch.CreatedBy,
+		DateCreated: sch.DateCreated.Format(time.RFC3339),
+		DateUpdated: sch.DateUpdated.Format(time.RFC3339),
+	}
+
+	if sch.TargetID != uuid.Nil {
+		app.TargetID = sch.TargetID.String()
+	}
+
+	// Run times are reported in the time zone of the schedule so clients see
+	// the local time the schedule was set up with.
+	loc, err := schedule.ParseTimeZone(sch.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	if !sch.RunAt.IsZero() {
+		app.RunAt = sch.RunAt.In(loc).Format(time.RFC3339)
+	}
+
+	if !sch.NextRunAt.IsZero() {
+		app.NextRunAt = sch.NextRunAt.In(loc).Format(time.RFC3339)
+	}
+
+	if !sch.LastRunAt.IsZero() {
+		app.LastRunAt = sch.LastRunAt.In(loc).Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppSchedules(schedules []schedule.Schedule) []AppSchedule {
+	items := make([]AppSchedule, len(schedules))
+	for i, sch := range schedules {
+		items[i] = toAppSchedule(sch)
+	}
+
+	return items
+}
+
+// =============================================================================
+
+// AppNewSchedule contains information needed to create a new schedule.
+// Either a cron spec or a run time is required. The spec is evaluated in the
+// time zone, which is an IANA name like Europe/Stockholm.
+type AppNewSchedule struct {
+	Name       string `json:"name" validate:"required"`
+	TargetType string `json:"targetType" validate:"required"`
+	TargetID   string `json:"targetId"`
+	Spec       string `json:"spec"`
+	RunAt      string `json:"runAt"`
+	TimeZone   string `json:"timeZone" validate:"required"`
+	CatchUp    string `json:"catchUp"`
+}
+
+func toCoreNewSchedule(app AppNewSchedule, org string, createdBy string) (schedule.NewSchedule, error) {
+	targetType, err := schedule.ParseTargetType(app.TargetType)
+	if err != nil {
+		return schedule.NewSchedule{}, validate.NewFieldsError("targetType", err)
+	}
+
+	var targetID uuid.UUID
+	if app.TargetID != "" {
+		targetID, err = uuid.Parse(app.TargetID)
+		if err != nil {
+			return schedule.NewSchedule{}, validate.NewFieldsError("targetId", err)
+		}
+	}
+
+	var runAt time.Time
+	if app.RunAt != "" {
+		runAt, err = time.Parse(time.RFC3339, app.RunAt)
+		if err != nil {
+			return schedule.NewSchedule{}, validate.NewFieldsError("runAt", err)
+		}
+	}
+
+	catchUp, err := schedule.ParseCatchUp(app.CatchUp)
+	if err != nil {
+		return schedule.NewSchedule{}, validate.NewFieldsError("catchUp", err)
+	}
+
+	ns := schedule.NewSchedule{
+		Org:        org,
+		Name:       app.Name,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Spec:       app.Spec,
+		RunAt:      runAt,
+		TimeZone:   app.TimeZone,
+		CatchUp:    catchUp,
+		CreatedBy:  createdBy,
+	}
+
+	return ns, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewSchedule) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdateSchedule contains information needed to update a schedule.
+// Setting a spec turns the schedule into a recurring one and setting a run
+// time turns it into a one-off.
+type AppUpdateSchedule struct {
+	Name     *string `json:"name" validate:"omitempty,min=1"`
+	Spec     *string `json:"spec"`
+	RunAt    *string `json:"runAt"`
+	TimeZone *string `json:"timeZone" validate:"omitempty,min=1"`
+	CatchUp  *string `json:"catchUp"`
+	Enabled  *bool   `json:"enabled"`
+}
+
+func toCoreUpdateSchedule(app AppUpdateSchedule) (schedule.UpdateSchedule, error) {
+	var runAt *time.Time
+	if app.RunAt != nil {
+		var t time.Time
+		if *app.RunAt != "" {
+			var err error
+			t, err = time.Parse(time.RFC3339, *app.RunAt)
+			if err != nil {
+				return schedule.UpdateSchedule{}, validate.NewFieldsError("runAt", err)
+			}
+		}
+		runAt = &t
+	}
+
+	var catchUp *schedule.CatchUp
+	if app.CatchUp != nil {
+		cu, err := schedule.ParseCatchUp(*app.CatchUp)
+		if err != nil {
+			return schedule.UpdateSchedule{}, validate.NewFieldsError("catchUp", err)
+		}
+		catchUp = &cu
+	}
+
+	us := schedule.UpdateSchedule{
+		Name:     app.Name,
+		Spec:     app.Spec,
+		RunAt:    runAt,
+		TimeZone: app.TimeZone,
+		CatchUp:  catchUp,
+		Enabled:  app.Enabled,
+	}
+
+	return us, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateSchedule) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package schedulegrp
+
+import (
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication/stores/publicationdb"
+	"github.com/vikaskumar1187/publisher_saas/business/core/schedule"
+	"github.com/vikaskumar1187/publisher_saas/business/core/schedule/stores/scheduledb"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook/stores/webhookdb"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/mid"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log  *logger.Logger
+	Auth *auth.Auth
+	DB   *sqlx.DB
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	whCore := webhook.NewCore(cfg.Log, webhookdb.NewStore(cfg.Log, cfg.DB))
+	pubCore := publication.NewCore(cfg.Log, db.NewBeginner(cfg.DB), whCore, publicationdb.NewStore(cfg.Log, cfg.DB))
+	schCore := schedule.NewCore(cfg.Log, db.NewBeginner(cfg.DB), pubCore, scheduledb.NewStore(cfg.Log, cfg.DB))
+
+	authen := mid.Authenticate(cfg.Auth)
+	ruleRead := mid.Authorize(cfg.Auth, auth.RuleRead)
+	rulePublish := mid.Authorize(cfg.Auth, auth.RulePublish)
+
+	hdl := New(schCore)
+	app.Handle(http.MethodGet, version, "/schedules", hdl.Query, authen, ruleRead)
+	app.Handle(http.MethodGet, version, "/schedules/:schedule_id", hdl.QueryByID, authen, ruleRead)
+	app.Handle(http.MethodPost, version, "/schedules", hdl.Create, authen, rulePublish)
+	app.Handle(http.MethodPut, version, "/schedules/:schedule_id", hdl.Update, authen, rulePublish)
+	app.Handle(http.MethodDelete, version, "/schedules/:schedule_id", hdl.Delete, authen, rulePublish)
+}
@@ -0,0 +1,157 @@
+// Package schedulegrp maintains the group of handlers for publish schedules.
+package schedulegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/schedule"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/paging"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/response"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+// Handlers manages the set of schedule endpoints.
+type Handlers struct {
+	schedule *schedule.Core
+}
+
+// New constructs a handlers for route access.
+func New(schedule *schedule.Core) *Handlers {
+	return &Handlers{
+		schedule: schedule,
+	}
+}
+
+// Create adds a new schedule to the system.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewSchedule
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	claims := auth.GetClaims(ctx)
+
+	ns, err := toCoreNewSchedule(app, claims.Org, claims.Subject)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	sch, err := h.schedule.Create(ctx, ns)
+	if err != nil {
+		return toWebError(err, "create: app[%+v]", app)
+	}
+
+	return web.Respond(ctx, w, toAppSchedule(sch), http.StatusCreated)
+}
+
+// Update updates a schedule in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateSchedule
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	us, err := toCoreUpdateSchedule(app)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	sch, err := h.querySchedule(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	sch, err = h.schedule.Update(ctx, sch, us)
+	if err != nil {
+		return toWebError(err, "update: scheduleID[%s] app[%+v]", sch.ID, app)
+	}
+
+	return web.Respond(ctx, w, toAppSchedule(sch), http.StatusOK)
+}
+
+// Delete removes a schedule from the system.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	sch, err := h.querySchedule(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.schedule.Delete(ctx, sch); err != nil {
+		return fmt.Errorf("delete: scheduleID[%s]: %w", sch.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns a list of schedules with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	org := auth.GetClaims(ctx).Org
+
+	schedules, err := h.schedule.Query(ctx, org, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.schedule.Count(ctx, org)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, response.NewPageDocument(toAppSchedules(schedules), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a schedule by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	sch, err := h.querySchedule(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppSchedule(sch), http.StatusOK)
+}
+
+// =============================================================================
+
+func (h *Handlers) querySchedule(ctx context.Context, r *http.Request) (schedule.Schedule, error) {
+	scheduleID, err := uuid.Parse(web.Param(r, "schedule_id"))
+	if err != nil {
+		return schedule.Schedule{}, response.NewError(fmt.Errorf("invalid schedule id: %w", err), http.StatusBadRequest)
+	}
+
+	sch, err := h.schedule.QueryByID(ctx, auth.GetClaims(ctx).Org, scheduleID)
+	if err != nil {
+		return schedule.Schedule{}, toWebError(err, "querybyid: scheduleID[%s]", scheduleID)
+	}
+
+	return sch, nil
+}
+
+// toWebError maps the expected errors of the schedule core to the response
+// status the client should see. Any other error is wrapped with the context
+// described by the format and args.
+func toWebError(err error, format string, args ...any) error {
+	switch {
+	case errors.Is(err, schedule.ErrNotFound):
+		return response.NewError(schedule.ErrNotFound, http.StatusNotFound)
+
+	case errors.Is(err, schedule.ErrTargetNotFound),
+		errors.Is(err, schedule.ErrInvalidTarget),
+		errors.Is(err, schedule.ErrInvalidTiming),
+		errors.Is(err, schedule.ErrInvalidSpec),
+		errors.Is(err, schedule.ErrInvalidTimeZone),
+		errors.Is(err, schedule.ErrRunInPast):
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	return fmt.Errorf(format+": %w", append(args, err)...)
+}
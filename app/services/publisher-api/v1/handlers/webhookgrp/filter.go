@@ -0,0 +1,29 @@
+package webhookgrp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/foundation/validate"
+)
+
+func parseFilter(r *http.Request, sub webhook.Subscription) (webhook.DeliveryFilter, error) {
+	values := r.URL.Query()
+
+	filter := webhook.DeliveryFilter{
+		Org:            sub.Org,
+		SubscriptionID: sub.ID,
+	}
+
+	if status := values.Get("status"); status != "" {
+		switch status {
+		case webhook.DeliveryPending, webhook.DeliverySucceeded, webhook.DeliveryFailed:
+			filter.WithStatus(status)
+		default:
+			return webhook.DeliveryFilter{}, validate.NewFieldsError("status", fmt.Errorf("invalid status %q", status))
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,156 @@
+package webhookgrp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/foundation/validate"
+)
+
+// AppWebhook represents information about an individual webhook
+// subscription. The secret is only returned when it is created or rotated.
+type AppWebhook struct {
+	ID          string   `json:"id"`
+	Org         string   `json:"org"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Secret      string   `json:"secret,omitempty"`
+	Active      bool     `json:"active"`
+	CreatedBy   string   `json:"createdBy"`
+	DateCreated string   `json:"dateCreated"`
+	DateUpdated string   `json:"dateUpdated"`
+}
+
+func toAppWebhook(sub webhook.Subscription) AppWebhook {
+	return AppWebhook{
+		ID:          sub.ID.String(),
+		Org:         sub.Org,
+		URL:         sub.URL,
+		Events:      sub.Events,
+		Active:      sub.Active,
+		CreatedBy:   sub.CreatedBy,
+		DateCreated: sub.DateCreated.Format(time.RFC3339),
+		DateUpdated: sub.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppWebhookWithSecret(sub webhook.Subscription) AppWebhook {
+	app := toAppWebhook(sub)
+	app.Secret = sub.Secret
+
+	return app
+}
+
+func toAppWebhooks(subs []webhook.Subscription) []AppWebhook {
+	items := make([]AppWebhook, len(subs))
+	for i, sub := range subs {
+		items[i] = toAppWebhook(sub)
+	}
+
+	return items
+}
+
+// =============================================================================
+
+// AppNewWebhook contains information needed to create a new webhook
+// subscription.
+type AppNewWebhook struct {
+	URL    string   `json:"url" validate:"required,url,startswith=https://"`
+	Events []string `json:"events" validate:"required,min=1"`
+}
+
+func toCoreNewSubscription(app AppNewWebhook, org string, createdBy string) webhook.NewSubscription {
+	return webhook.NewSubscription{
+		Org:       org,
+		URL:       app.URL,
+		Events:    app.Events,
+		CreatedBy: createdBy,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewWebhook) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdateWebhook contains information needed to update a webhook
+// subscription.
+type AppUpdateWebhook struct {
+	URL    *string  `json:"url" validate:"omitempty,url,startswith=https://"`
+	Events []string `json:"events" validate:"omitempty,min=1"`
+	Active *bool    `json:"active"`
+}
+
+func toCoreUpdateSubscription(app AppUpdateWebhook) webhook.UpdateSubscription {
+	return webhook.UpdateSubscription{
+		URL:    app.URL,
+		Events: app.Events,
+		Active: app.Active,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateWebhook) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppDelivery represents an entry in the delivery log of a webhook.
+type AppDelivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhookId"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"lastStatusCode,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	DateCreated    string          `json:"dateCreated"`
+	DateUpdated    string          `json:"dateUpdated"`
+	DateDelivered  string          `json:"dateDelivered,omitempty"`
+}
+
+func toAppDelivery(dlv webhook.Delivery) AppDelivery {
+	app := AppDelivery{
+		ID:             dlv.ID.String(),
+		WebhookID:      dlv.SubscriptionID.String(),
+		EventID:        dlv.EventID.String(),
+		EventType:      dlv.EventType,
+		Payload:        dlv.Payload,
+		Status:         dlv.Status,
+		Attempts:       dlv.Attempts,
+		LastStatusCode: dlv.LastStatusCode,
+		LastError:      dlv.LastError,
+		DateCreated:    dlv.DateCreated.Format(time.RFC3339),
+		DateUpdated:    dlv.DateUpdated.Format(time.RFC3339),
+	}
+
+	if !dlv.DateDelivered.IsZero() {
+		app.DateDelivered = dlv.DateDelivered.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppDeliveries(dlvs []webhook.Delivery) []AppDelivery {
+	items := make([]AppDelivery, len(dlvs))
+	for i, dlv := range dlvs {
+		items[i] = toAppDelivery(dlv)
+	}
+
+	return items
+}
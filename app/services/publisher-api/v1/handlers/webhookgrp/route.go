@@ -0,0 +1,42 @@
+package webhookgrp
+
+import (
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook/stores/webhookdb"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/mid"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log  *logger.Logger
+	Auth *auth.Auth
+	DB   *sqlx.DB
+	Tran web.Middleware
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	whCore := webhook.NewCore(cfg.Log, webhookdb.NewStore(cfg.Log, cfg.DB))
+
+	authen := mid.Authenticate(cfg.Auth)
+	ruleAdmin := mid.Authorize(cfg.Auth, auth.RuleAdmin)
+
+	hdl := New(whCore)
+	app.Handle(http.MethodGet, version, "/webhooks", hdl.Query, authen, ruleAdmin)
+	app.Handle(http.MethodGet, version, "/webhooks/:webhook_id", hdl.QueryByID, authen, ruleAdmin)
+	app.Handle(http.MethodPost, version, "/webhooks", hdl.Create, authen, ruleAdmin)
+	app.Handle(http.MethodPut, version, "/webhooks/:webhook_id", hdl.Update, authen, ruleAdmin)
+	app.Handle(http.MethodDelete, version, "/webhooks/:webhook_id", hdl.Delete, authen, ruleAdmin)
+	app.Handle(http.MethodPost, version, "/webhooks/:webhook_id/rotate", hdl.Rotate, authen, ruleAdmin)
+	app.Handle(http.MethodGet, version, "/webhooks/:webhook_id/deliveries", hdl.QueryDeliveries, authen, ruleAdmin)
+	app.Handle(http.MethodGet, version, "/webhooks/:webhook_id/deliveries/:delivery_id", hdl.QueryDeliveryByID, authen, ruleAdmin)
+	app.Handle(http.MethodPost, version, "/webhooks/:webhook_id/deliveries/:delivery_id/replay", hdl.Replay, authen, ruleAdmin, cfg.Tran)
+}
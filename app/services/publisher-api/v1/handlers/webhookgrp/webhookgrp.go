@@ -0,0 +1,272 @@
+// Package webhookgrp maintains the group of handlers for webhook
+// subscriptions and their delivery log.
+package webhookgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/paging"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/response"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+// Handlers manages the set of webhook endpoints.
+type Handlers struct {
+	webhook *webhook.Core
+}
+
+// New constructs a handlers for route access.
+func New(webhook *webhook.Core) *Handlers {
+	return &Handlers{
+		webhook: webhook,
+	}
+}
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *Handlers) executeUnderTransaction(ctx context.Context) (*Handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		webhook, err := h.webhook.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		return New(webhook), nil
+	}
+
+	return h, nil
+}
+
+// Create adds a new webhook subscription to the system. The response holds
+// the signing secret, which is not returned again.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewWebhook
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	claims := auth.GetClaims(ctx)
+
+	sub, err := h.webhook.CreateSubscription(ctx, toCoreNewSubscription(app, claims.Org, claims.Subject))
+	if err != nil {
+		return toWebError(err, "create: app[%+v]", app)
+	}
+
+	return web.Respond(ctx, w, toAppWebhookWithSecret(sub), http.StatusCreated)
+}
+
+// Update updates a webhook subscription in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateWebhook
+	if err := web.Decode(r, &app); err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	sub, err = h.webhook.UpdateSubscription(ctx, sub, toCoreUpdateSubscription(app))
+	if err != nil {
+		return toWebError(err, "update: webhookID[%s] app[%+v]", sub.ID, app)
+	}
+
+	return web.Respond(ctx, w, toAppWebhook(sub), http.StatusOK)
+}
+
+// Rotate replaces the signing secret of a webhook subscription. The response
+// holds the new secret.
+func (h *Handlers) Rotate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	sub, err = h.webhook.RotateSecret(ctx, sub)
+	if err != nil {
+		return fmt.Errorf("rotate: webhookID[%s]: %w", sub.ID, err)
+	}
+
+	return web.Respond(ctx, w, toAppWebhookWithSecret(sub), http.StatusOK)
+}
+
+// Delete removes a webhook subscription and its delivery log from the
+// system.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.webhook.DeleteSubscription(ctx, sub); err != nil {
+		return fmt.Errorf("delete: webhookID[%s]: %w", sub.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns a list of webhook subscriptions with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	org := auth.GetClaims(ctx).Org
+
+	subs, err := h.webhook.QuerySubscriptions(ctx, org, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.webhook.CountSubscriptions(ctx, org)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, response.NewPageDocument(toAppWebhooks(subs), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a webhook subscription by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppWebhook(sub), http.StatusOK)
+}
+
+// =============================================================================
+
+// QueryDeliveries returns the delivery log of a webhook subscription with
+// paging, newest first.
+func (h *Handlers) QueryDeliveries(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r, sub)
+	if err != nil {
+		return response.NewError(err, http.StatusBadRequest)
+	}
+
+	dlvs, err := h.webhook.QueryDeliveries(ctx, filter, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.webhook.CountDeliveries(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, response.NewPageDocument(toAppDeliveries(dlvs), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryDeliveryByID returns a delivery of a webhook subscription by its ID.
+func (h *Handlers) QueryDeliveryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	dlv, err := h.queryDelivery(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppDelivery(dlv), http.StatusOK)
+}
+
+// Replay sends a delivery again with its original payload.
+func (h *Handlers) Replay(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	dlv, err := h.queryDelivery(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	replayed, err := h.webhook.Replay(ctx, dlv)
+	if err != nil {
+		return toWebError(err, "replay: deliveryID[%s]", dlv.ID)
+	}
+
+	return web.Respond(ctx, w, toAppDelivery(replayed), http.StatusAccepted)
+}
+
+// =============================================================================
+
+func (h *Handlers) querySubscription(ctx context.Context, r *http.Request) (webhook.Subscription, error) {
+	webhookID, err := uuid.Parse(web.Param(r, "webhook_id"))
+	if err != nil {
+		return webhook.Subscription{}, response.NewError(fmt.Errorf("invalid webhook id: %w", err), http.StatusBadRequest)
+	}
+
+	sub, err := h.webhook.QuerySubscriptionByID(ctx, auth.GetClaims(ctx).Org, webhookID)
+	if err != nil {
+		return webhook.Subscription{}, toWebError(err, "querybyid: webhookID[%s]", webhookID)
+	}
+
+	return sub, nil
+}
+
+func (h *Handlers) queryDelivery(ctx context.Context, r *http.Request) (webhook.Delivery, error) {
+	sub, err := h.querySubscription(ctx, r)
+	if err != nil {
+		return webhook.Delivery{}, err
+	}
+
+	deliveryID, err := uuid.Parse(web.Param(r, "delivery_id"))
+	if err != nil {
+		return webhook.Delivery{}, response.NewError(fmt.Errorf("invalid delivery id: %w", err), http.StatusBadRequest)
+	}
+
+	dlv, err := h.webhook.QueryDeliveryByID(ctx, sub.Org, deliveryID)
+	if err != nil {
+		return webhook.Delivery{}, toWebError(err, "querybyid: deliveryID[%s]", deliveryID)
+	}
+
+	if dlv.SubscriptionID != sub.ID {
+		return webhook.Delivery{}, response.NewError(webhook.ErrDeliveryNotFound, http.StatusNotFound)
+	}
+
+	return dlv, nil
+}
+
+// toWebError maps the expected errors of the webhook core to the response
+// status the client should see. Any other error is wrapped with the context
+// described by the format and args.
+func toWebError(err error, format string, args ...any) error {
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		return response.NewError(webhook.ErrNotFound, http.StatusNotFound)
+
+	case errors.Is(err, webhook.ErrDeliveryNotFound):
+		return response.NewError(webhook.ErrDeliveryNotFound, http.StatusNotFound)
+
+	case errors.Is(err, webhook.ErrUnknownEvent):
+		return response.NewError(err, http.StatusBadRequest)
+
+	case errors.Is(err, webhook.ErrInvalidURL):
+		return response.NewError(webhook.ErrInvalidURL, http.StatusBadRequest)
+
+	case errors.Is(err, webhook.ErrReplayPending):
+		return response.NewError(err, http.StatusConflict)
+	}
+
+	return fmt.Errorf(format+": %w", append(args, err)...)
+}
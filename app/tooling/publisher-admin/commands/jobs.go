@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// DeadJobs prints the most recent dead-lettered jobs of the queue.
+func DeadJobs(cfg db.Config, queueName string) error {
+	if queueName == "" {
+		queueName = queue.DefaultQueue
+	}
+
+	db, err := db.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	log := logger.New(io.Discard, logger.LevelInfo, "ADMIN", func(context.Context) string { return "" })
+
+	jobs, err := queue.QueryDead(ctx, log, db, queueName, 50)
+	if err != nil {
+		return fmt.Errorf("query dead jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		fmt.Printf("%s %-30s attempts=%d/%d failed=%s\n\t%s\n", job.ID, job.Kind, job.Attempt, job.MaxAttempts, job.DateUpdated.Format(time.RFC3339), job.LastError)
+	}
+
+	return nil
+}
+
+// Requeue moves a dead-lettered job back to the queue.
+func Requeue(cfg db.Config, id string) error {
+	jobID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("parse job id: %w", err)
+	}
+
+	db, err := db.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	log := logger.New(io.Discard, logger.LevelInfo, "ADMIN", func(context.Context) string { return "" })
+
+	if err := queue.Requeue(ctx, log, db, jobID); err != nil {
+		return fmt.Errorf("requeue: %w", err)
+	}
+
+	fmt.Printf("job %s requeued\n", jobID)
+	return nil
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/migrate"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Migrate creates the schema in the database.
+func Migrate(cfg db.Config) error {
+	db, err := db.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	log := logger.New(io.Discard, logger.LevelInfo, "ADMIN", func(context.Context) string { return "" })
+
+	applied, err := migrate.Migrate(ctx, log, db)
+	if err != nil {
+		return fmt.Errorf("migrate database: %w", err)
+	}
+
+	for _, m := range applied {
+		fmt.Printf("applied migration %04d %s\n", m.Version, m.Name)
+	}
+
+	fmt.Println("migrations complete")
+	return nil
+}
+
+// Status prints the state of each migration in the database.
+func Status(cfg db.Config) error {
+	db, err := db.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	statuses, err := migrate.Statuses(ctx, db)
+	if err != nil {
+		return fmt.Errorf("query statuses: %w", err)
+	}
+
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Modified:
+			state = "modified since applied " + s.DateApplied.Format(time.RFC3339)
+		case s.Applied:
+			state = "applied " + s.DateApplied.Format(time.RFC3339)
+		}
+
+		fmt.Printf("%04d %-40s %s\n", s.Version, s.Name, state)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+// This program performs administrative tasks for the publisher service.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ardanlabs/conf/v3"
+	"github.com/vikaskumar1187/publisher_saas/app/tooling/publisher-admin/commands"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+var build = "develop"
+
+type config struct {
+	conf.Version
+	Args conf.Args
+	DB   struct {
+		User         string `conf:"default:postgres"`
+		Password     string `conf:"default:postgres,mask"`
+		Host         string `conf:"default:database-service.publisher-system.svc.cluster.local"`
+		Name         string `conf:"default:postgres"`
+		MaxIdleConns int    `conf:"default:2"`
+		MaxOpenConns int    `conf:"default:0"`
+		DisableTLS   bool   `conf:"default:true"`
+
+		SSLMode            string `conf:"default:require,help:require, verify-ca or verify-full"`
+		SSLRootCert        string
+		SSLCert            string
+		SSLKey             string
+		TargetSessionAttrs string `conf:"help:read-write to fail over to the primary of a comma separated host list"`
+		ApplicationName    string `conf:"default:publisher-admin"`
+	}
+	Auth struct {
+		KeysFolder string        `conf:"default:zarf/keys/"`
+		ActiveKID  string        `conf:"help:key id printed by genkey"`
+		Issuer     string        `conf:"default:publisher-api"`
+		TokenTTL   time.Duration `conf:"default:8760h"`
+	}
+}
+
+func main() {
+	log := logger.New(io.Discard, logger.LevelInfo, "ADMIN", func(context.Context) string { return "" })
+
+	if err := run(log); err != nil {
+		if !errors.Is(err, commands.ErrHelp) {
+			fmt.Println("msg", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(log *logger.Logger) error {
+	cfg := config{
+		Version: conf.Version{
+			Build: build,
+			Desc:  "Vikas Kumar",
+		},
+	}
+
+	const prefix = "PUBLISHER"
+	help, err := conf.Parse(prefix, &cfg)
+	if err != nil {
+		if errors.Is(err, conf.ErrHelpWanted) {
+			fmt.Println(help)
+			return nil
+		}
+
+		out, err := conf.String(&cfg)
+		if err != nil {
+			return fmt.Errorf("generating config for output: %w", err)
+		}
+		log.Info(context.Background(), "startup", "config", out)
+
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	return processCommands(cfg.Args, log, cfg)
+}
+
+// processCommands handles the execution of the commands specified on
+// the command line.
+func processCommands(args conf.Args, log *logger.Logger, cfg config) error {
+	dbConfig := db.Config{
+		User:         cfg.DB.User,
+		Password:     cfg.DB.Password,
+		Host:         cfg.DB.Host,
+		Name:         cfg.DB.Name,
+		MaxIdleConns: cfg.DB.MaxIdleConns,
+		MaxOpenConns: cfg.DB.MaxOpenConns,
+		DisableTLS:   cfg.DB.DisableTLS,
+
+		SSLMode:            cfg.DB.SSLMode,
+		SSLRootCert:        cfg.DB.SSLRootCert,
+		SSLCert:            cfg.DB.SSLCert,
+		SSLKey:             cfg.DB.SSLKey,
+		TargetSessionAttrs: cfg.DB.TargetSessionAttrs,
+		ApplicationName:    cfg.DB.ApplicationName,
+	}
+
+	switch args.Num(0) {
+	case "migrate":
+		if err := commands.Migrate(dbConfig); err != nil {
+			return fmt.Errorf("migrating database: %w", err)
+		}
+
+	case "status":
+		if err := commands.Status(dbConfig); err != nil {
+			return fmt.Errorf("migration status: %w", err)
+		}
+
+	case "seed":
+		if err := commands.Seed(dbConfig); err != nil {
+			return fmt.Errorf("seeding database: %w", err)
+		}
+
+	case "deadjobs":
+		if err := commands.DeadJobs(dbConfig, args.Num(1)); err != nil {
+			return fmt.Errorf("listing dead jobs: %w", err)
+		}
+
+	case "requeue":
+		if err := commands.Requeue(dbConfig, args.Num(1)); err != nil {
+			return fmt.Errorf("requeueing job: %w", err)
+		}
+
+	case "genkey":
+		if err := commands.GenKey(cfg.Auth.KeysFolder); err != nil {
+			return fmt.Errorf("key generation: %w", err)
+		}
+
+	case "gentoken":
+		var permissions []string
+		if p := args.Num(3); p != "" {
+			permissions = strings.Split(p, ",")
+		}
+
+		tokenCfg := commands.TokenConfig{
+			KeysFolder:  cfg.Auth.KeysFolder,
+			ActiveKID:   cfg.Auth.ActiveKID,
+			Issuer:      cfg.Auth.Issuer,
+			TTL:         cfg.Auth.TokenTTL,
+			Org:         args.Num(1),
+			Subject:     args.Num(2),
+			Permissions: permissions,
+		}
+
+		if err := commands.GenToken(log, tokenCfg); err != nil {
+			return fmt.Errorf("generating token: %w", err)
+		}
+
+	default:
+		fmt.Println("migrate:  create the schema in the database")
+		fmt.Println("status:   show the state of each migration in the database")
+		fmt.Println("seed:     add data to the database")
+		fmt.Println("deadjobs: list the dead-lettered jobs: deadjobs [queue]")
+		fmt.Println("requeue:  move a dead-lettered job back to the queue: requeue <job_id>")
+		fmt.Println("genkey:   generate a set of private/public key files")
+		fmt.Println("gentoken: generate a local token: gentoken <org> <subject> [permission,...]")
+		fmt.Println("provide a command to get more help.")
+		return commands.ErrHelp
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+// Package apikey provides the core business API for service account
+// credentials used by machine clients such as CI and print automation.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// keyPrefix identifies a value as an api key of this service.
+const keyPrefix = "pub"
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound   = errors.New("api key not found")
+	ErrRevoked    = errors.New("api key revoked")
+	ErrInvalidKey = errors.New("api key invalid")
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	Create(ctx context.Context, key APIKey) error
+	Update(ctx context.Context, key APIKey) error
+	QueryByOrg(ctx context.Context, org string) ([]APIKey, error)
+	QueryByID(ctx context.Context, keyID uuid.UUID) (APIKey, error)
+	QueryByPrefix(ctx context.Context, prefix string) (APIKey, error)
+}
+
+// Core manages the set of APIs for api key access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs a core for api key api access.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	storer, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCore(c.log, storer), nil
+}
+
+// Create adds a new api key to the system. The secret is returned only once
+// and can't be recovered afterwards.
+func (c *Core) Create(ctx context.Context, nk NewAPIKey) (APIKey, string, error) {
+	prefix, secret, hash, err := generate()
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("generate: %w", err)
+	}
+
+	now := time.Now()
+
+	key := APIKey{
+		ID:          uuid.New(),
+		Org:         nk.Org,
+		Name:        nk.Name,
+		Prefix:      prefix,
+		Hash:        hash,
+		Permissions: nk.Permissions,
+		CreatedBy:   nk.CreatedBy,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.storer.Create(ctx, key); err != nil {
+		return APIKey{}, "", fmt.Errorf("create: %w", err)
+	}
+
+	return key, secret, nil
+}
+
+// Rotate replaces the secret of an api key. The previous secret stops working
+// immediately and the new secret is returned only once.
+func (c *Core) Rotate(ctx context.Context, key APIKey) (APIKey, string, error) {
+	if key.Revoked() {
+		return APIKey{}, "", ErrRevoked
+	}
+
+	prefix, secret, hash, err := generate()
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("generate: %w", err)
+	}
+
+	key.Prefix = prefix
+	key.Hash = hash
+	key.DateUpdated = time.Now()
+
+	if err := c.storer.Update(ctx, key); err != nil {
+		return APIKey{}, "", fmt.Errorf("update: %w", err)
+	}
+
+	return key, secret, nil
+}
+
+// Revoke marks the api key as revoked so it can no longer be used.
+func (c *Core) Revoke(ctx context.Context, key APIKey) (APIKey, error) {
+	if key.Revoked() {
+		return key, nil
+	}
+
+	now := time.Now()
+	key.DateRevoked = now
+	key.DateUpdated = now
+
+	if err := c.storer.Update(ctx, key); err != nil {
+		return APIKey{}, fmt.Errorf("update: %w", err)
+	}
+
+	return key, nil
+}
+
+// QueryByOrg retrieves the list of api keys for the specified organisation.
+func (c *Core) QueryByOrg(ctx context.Context, org string) ([]APIKey, error) {
+	keys, err := c.storer.QueryByOrg(ctx, org)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return keys, nil
+}
+
+// QueryByID finds the api key by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, keyID uuid.UUID) (APIKey, error) {
+	key, err := c.storer.QueryByID(ctx, keyID)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("query: keyID[%s]: %w", keyID, err)
+	}
+
+	return key, nil
+}
+
+// Authenticate finds the api key for the specified secret and validates it.
+func (c *Core) Authenticate(ctx context.Context, secret string) (APIKey, error) {
+	prefix, ok := parse(secret)
+	if !ok {
+		return APIKey{}, ErrInvalidKey
+	}
+
+	key, err := c.storer.QueryByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return APIKey{}, ErrInvalidKey
+		}
+		return APIKey{}, fmt.Errorf("query: prefix[%s]: %w", prefix, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.Hash)) != 1 {
+		return APIKey{}, ErrInvalidKey
+	}
+
+	if key.Revoked() {
+		return APIKey{}, ErrRevoked
+	}
+
+	return key, nil
+}
+
+// =============================================================================
+
+// generate constructs a new secret in the form pub_<prefix>.<random>. The
+// prefix is used to look up the key and the hash is what gets stored.
+func generate() (prefix string, secret string, hash string, err error) {
+	p := make([]byte, 6)
+	if _, err := rand.Read(p); err != nil {
+		return "", "", "", err
+	}
+
+	s := make([]byte, 32)
+	if _, err := rand.Read(s); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(p)
+	secret = fmt.Sprintf("%s_%s.%s", keyPrefix, prefix, base64.RawURLEncoding.EncodeToString(s))
+
+	return prefix, secret, hashSecret(secret), nil
+}
+
+// parse extracts the lookup prefix from a secret.
+func parse(secret string) (string, bool) {
+	rest, ok := strings.CutPrefix(secret, keyPrefix+"_")
+	if !ok {
+		return "", false
+	}
+
+	prefix, _, ok := strings.Cut(rest, ".")
+	if !ok || prefix == "" {
+		return "", false
+	}
+
+	return prefix, true
+}
+
+// hashSecret returns the hex encoded sha256 of the secret. The secrets are
+// random with high entropy so a fast hash is sufficient.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,210 @@
+package apikey_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+func Test_APIKey(t *testing.T) {
+	newCore := func() *apikey.Core {
+		log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+		return apikey.NewCore(log, newStore())
+	}
+
+	nk := apikey.NewAPIKey{
+		Org:         "sample",
+		Name:        "ci",
+		Permissions: []string{"pagehub:read"},
+		CreatedBy:   "user",
+	}
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, core *apikey.Core, key apikey.APIKey, secret string)
+	}{
+		{
+			name: "hash",
+			test: func(t *testing.T, core *apikey.Core, key apikey.APIKey, secret string) {
+				if !strings.HasPrefix(secret, "pub_"+key.Prefix+".") {
+					t.Fatalf("Should carry the lookup prefix in the secret: %s", secret)
+				}
+
+				if strings.Contains(key.Hash, secret) {
+					t.Fatal("Should not store the secret")
+				}
+
+				sum := sha256.Sum256([]byte(secret))
+				if key.Hash != hex.EncodeToString(sum[:]) {
+					t.Fatalf("Should store the sha256 of the secret: got %s", key.Hash)
+				}
+			},
+		},
+		{
+			name: "authenticate",
+			test: func(t *testing.T, core *apikey.Core, key apikey.APIKey, secret string) {
+				got, err := core.Authenticate(context.Background(), secret)
+				if err != nil {
+					t.Fatalf("Should authenticate with the secret: %v", err)
+				}
+
+				if got.ID != key.ID {
+					t.Fatalf("Should find the key: got %s, exp %s", got.ID, key.ID)
+				}
+			},
+		},
+		{
+			name: "invalid secrets",
+			test: func(t *testing.T, core *apikey.Core, key apikey.APIKey, secret string) {
+				secrets := []string{
+					"",
+					"nope",
+					"pub_.abc",
+					"pub_" + key.Prefix,
+					secret[:len(secret)-1] + "x",
+					"pub_000000000000.abc",
+				}
+
+				for _, s := range secrets {
+					if _, err := core.Authenticate(context.Background(), s); !errors.Is(err, apikey.ErrInvalidKey) {
+						t.Errorf("Should reject the secret %q: %v", s, err)
+					}
+				}
+			},
+		},
+		{
+			name: "revoke",
+			test: func(t *testing.T, core *apikey.Core, key apikey.APIKey, secret string) {
+				if _, err := core.Revoke(context.Background(), key); err != nil {
+					t.Fatalf("Should be able to revoke the key: %v", err)
+				}
+
+				if _, err := core.Authenticate(context.Background(), secret); !errors.Is(err, apikey.ErrRevoked) {
+					t.Fatalf("Should reject a revoked key: %v", err)
+				}
+
+				revoked, err := core.QueryByID(context.Background(), key.ID)
+				if err != nil {
+					t.Fatalf("Should be able to query the key: %v", err)
+				}
+
+				if _, _, err := core.Rotate(context.Background(), revoked); !errors.Is(err, apikey.ErrRevoked) {
+					t.Fatalf("Should not rotate a revoked key: %v", err)
+				}
+			},
+		},
+		{
+			name: "rotate",
+			test: func(t *testing.T, core *apikey.Core, key apikey.APIKey, secret string) {
+				rotated, newSecret, err := core.Rotate(context.Background(), key)
+				if err != nil {
+					t.Fatalf("Should be able to rotate the key: %v", err)
+				}
+
+				if rotated.ID != key.ID || newSecret == secret {
+					t.Fatalf("Should keep the key and replace the secret: got %s", rotated.ID)
+				}
+
+				if _, err := core.Authenticate(context.Background(), secret); !errors.Is(err, apikey.ErrInvalidKey) {
+					t.Fatalf("Should reject the previous secret: %v", err)
+				}
+
+				if _, err := core.Authenticate(context.Background(), newSecret); err != nil {
+					t.Fatalf("Should authenticate with the new secret: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := newCore()
+
+			key, secret, err := core.Create(context.Background(), nk)
+			if err != nil {
+				t.Fatalf("Should be able to create a key: %v", err)
+			}
+
+			tt.test(t, core, key, secret)
+		})
+	}
+}
+
+// =============================================================================
+
+// store is an in memory Storer.
+type store struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID]apikey.APIKey
+}
+
+func newStore() *store {
+	return &store{
+		keys: make(map[uuid.UUID]apikey.APIKey),
+	}
+}
+
+func (s *store) ExecuteUnderTransaction(tx transaction.Transaction) (apikey.Storer, error) {
+	return s, nil
+}
+
+func (s *store) Create(ctx context.Context, key apikey.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key.ID] = key
+	return nil
+}
+
+func (s *store) Update(ctx context.Context, key apikey.APIKey) error {
+	return s.Create(ctx, key)
+}
+
+func (s *store) QueryByOrg(ctx context.Context, org string) ([]apikey.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []apikey.APIKey
+	for _, key := range s.keys {
+		if key.Org == org {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *store) QueryByID(ctx context.Context, keyID uuid.UUID) (apikey.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.keys[keyID]
+	if !exists {
+		return apikey.APIKey{}, apikey.ErrNotFound
+	}
+
+	return key, nil
+}
+
+func (s *store) QueryByPrefix(ctx context.Context, prefix string) (apikey.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+
+	return apikey.APIKey{}, apikey.ErrNotFound
+}
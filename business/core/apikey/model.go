@@ -0,0 +1,35 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey represents a service account credential used by machine clients.
+// The secret is never stored, only a hash of it.
+type APIKey struct {
+	ID          uuid.UUID
+	Org         string
+	Name        string
+	Prefix      string
+	Hash        string
+	Permissions []string
+	CreatedBy   string
+	DateCreated time.Time
+	DateUpdated time.Time
+	DateRevoked time.Time
+}
+
+// Revoked reports whether the key has been revoked.
+func (k APIKey) Revoked() bool {
+	return !k.DateRevoked.IsZero()
+}
+
+// NewAPIKey is what we require from clients when adding an APIKey.
+type NewAPIKey struct {
+	Org         string
+	Name        string
+	Permissions []string
+	CreatedBy   string
+}
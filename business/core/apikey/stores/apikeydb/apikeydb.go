@@ -0,0 +1,166 @@
+// Package apikeydb contains api key related CRUD functionality.
+package apikeydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Store manages the set of APIs for api key database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (apikey.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new api key into the database.
+func (s *Store) Create(ctx context.Context, key apikey.APIKey) error {
+	const q = `
+	INSERT INTO api_keys
+		(key_id, org, name, prefix, key_hash, permissions, created_by, date_created, date_updated, date_revoked)
+	VALUES
+		(:key_id, :org, :name, :prefix, :key_hash, :permissions, :created_by, :date_created, :date_updated, :date_revoked)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBAPIKey(key)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces an api key document in the database.
+func (s *Store) Update(ctx context.Context, key apikey.APIKey) error {
+	const q = `
+	UPDATE
+		api_keys
+	SET 
+		"name" = :name,
+		"prefix" = :prefix,
+		"key_hash" = :key_hash,
+		"permissions" = :permissions,
+		"date_updated" = :date_updated,
+		"date_revoked" = :date_revoked
+	WHERE
+		key_id = :key_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBAPIKey(key)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByOrg retrieves the list of api keys for the specified organisation.
+func (s *Store) QueryByOrg(ctx context.Context, org string) ([]apikey.APIKey, error) {
+	data := struct {
+		Org string `db:"org"`
+	}{
+		Org: org,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_keys
+	WHERE 
+		org = :org
+	ORDER BY
+		date_created`
+
+	var dbKeys []dbAPIKey
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbKeys); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreAPIKeySlice(dbKeys), nil
+}
+
+// QueryByID gets the specified api key from the database.
+func (s *Store) QueryByID(ctx context.Context, keyID uuid.UUID) (apikey.APIKey, error) {
+	data := struct {
+		ID string `db:"key_id"`
+	}{
+		ID: keyID.String(),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_keys
+	WHERE 
+		key_id = :key_id`
+
+	var dbKey dbAPIKey
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbKey); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", apikey.ErrNotFound)
+		}
+		return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreAPIKey(dbKey), nil
+}
+
+// QueryByPrefix gets the api key with the specified lookup prefix from the
+// database. The lookup always runs on the primary, so a key that was just
+// revoked or rotated is never accepted from a replica that is behind.
+func (s *Store) QueryByPrefix(ctx context.Context, prefix string) (apikey.APIKey, error) {
+	ctx = db.WithPrimary(ctx)
+
+	data := struct {
+		Prefix string `db:"prefix"`
+	}{
+		Prefix: prefix,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_keys
+	WHERE
+		prefix = :prefix`
+
+	var dbKey dbAPIKey
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbKey); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", apikey.ErrNotFound)
+		}
+		return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreAPIKey(dbKey), nil
+}
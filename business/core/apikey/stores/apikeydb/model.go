@@ -0,0 +1,73 @@
+package apikeydb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx/dbarray"
+)
+
+// dbAPIKey represent the structure we need for moving data
+// between the app and the database.
+type dbAPIKey struct {
+	ID          uuid.UUID      `db:"key_id"`
+	Org         string         `db:"org"`
+	Name        string         `db:"name"`
+	Prefix      string         `db:"prefix"`
+	Hash        string         `db:"key_hash" log:"redact"`
+	Permissions dbarray.String `db:"permissions"`
+	CreatedBy   string         `db:"created_by"`
+	DateCreated time.Time      `db:"date_created"`
+	DateUpdated time.Time      `db:"date_updated"`
+	DateRevoked sql.NullTime   `db:"date_revoked"`
+}
+
+func toDBAPIKey(key apikey.APIKey) dbAPIKey {
+	dbKey := dbAPIKey{
+		ID:          key.ID,
+		Org:         key.Org,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Hash:        key.Hash,
+		Permissions: key.Permissions,
+		CreatedBy:   key.CreatedBy,
+		DateCreated: key.DateCreated.UTC(),
+		DateUpdated: key.DateUpdated.UTC(),
+	}
+
+	if !key.DateRevoked.IsZero() {
+		dbKey.DateRevoked = sql.NullTime{Time: key.DateRevoked.UTC(), Valid: true}
+	}
+
+	return dbKey
+}
+
+func toCoreAPIKey(dbKey dbAPIKey) apikey.APIKey {
+	key := apikey.APIKey{
+		ID:          dbKey.ID,
+		Org:         dbKey.Org,
+		Name:        dbKey.Name,
+		Prefix:      dbKey.Prefix,
+		Hash:        dbKey.Hash,
+		Permissions: dbKey.Permissions,
+		CreatedBy:   dbKey.CreatedBy,
+		DateCreated: dbKey.DateCreated.In(time.Local),
+		DateUpdated: dbKey.DateUpdated.In(time.Local),
+	}
+
+	if dbKey.DateRevoked.Valid {
+		key.DateRevoked = dbKey.DateRevoked.Time.In(time.Local)
+	}
+
+	return key
+}
+
+func toCoreAPIKeySlice(dbKeys []dbAPIKey) []apikey.APIKey {
+	keys := make([]apikey.APIKey, len(dbKeys))
+	for i, dbKey := range dbKeys {
+		keys[i] = toCoreAPIKey(dbKey)
+	}
+	return keys
+}
@@ -0,0 +1,127 @@
+package publication
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/data/outbox"
+)
+
+// Set of aggregate types the publication core writes events for.
+const (
+	AggregatePage    = "page"
+	AggregateEdition = "edition"
+)
+
+// Set of domain events the publication core writes to the outbox.
+const (
+	EventPageCreated     = "publication.page_created"
+	EventPageUpdated     = "publication.page_updated"
+	EventPageDeleted     = "publication.page_deleted"
+	EventPageScheduled   = "publication.page_scheduled"
+	EventPagePublished   = "publication.page_published"
+	EventPageUnpublished = "publication.page_unpublished"
+	EventPageDrafted     = "publication.page_drafted"
+
+	EventEditionCreated = "publication.edition_created"
+	EventEditionUpdated = "publication.edition_updated"
+	EventEditionDeleted = "publication.edition_deleted"
+)
+
+// transitionEvents maps the status a page moves to onto the event written
+// for it.
+var transitionEvents = map[Status]string{
+	StatusScheduled:   EventPageScheduled,
+	StatusPublished:   EventPagePublished,
+	StatusUnpublished: EventPageUnpublished,
+	StatusDraft:       EventPageDrafted,
+}
+
+// PageEvent is the data of the page events and the page webhook events.
+type PageEvent struct {
+	PageID      string `json:"pageId"`
+	EditionID   string `json:"editionId,omitempty"`
+	Title       string `json:"title"`
+	Slug        string `json:"slug"`
+	Status      string `json:"status"`
+	ScheduledAt string `json:"scheduledAt,omitempty"`
+	PublishedAt string `json:"publishedAt,omitempty"`
+}
+
+func toPageEvent(page Page) PageEvent {
+	evt := PageEvent{
+		PageID: page.ID.String(),
+		Title:  page.Title,
+		Slug:   page.Slug,
+		Status: page.Status.Name(),
+	}
+
+	if page.EditionID != uuid.Nil {
+		evt.EditionID = page.EditionID.String()
+	}
+
+	if !page.ScheduledAt.IsZero() {
+		evt.ScheduledAt = page.ScheduledAt.UTC().Format(time.RFC3339)
+	}
+
+	if !page.PublishedAt.IsZero() {
+		evt.PublishedAt = page.PublishedAt.UTC().Format(time.RFC3339)
+	}
+
+	return evt
+}
+
+// EditionEvent is the data of the edition events.
+type EditionEvent struct {
+	EditionID string `json:"editionId"`
+	Name      string `json:"name"`
+	IssueDate string `json:"issueDate"`
+}
+
+func toEditionEvent(edition Edition) EditionEvent {
+	return EditionEvent{
+		EditionID: edition.ID.String(),
+		Name:      edition.Name,
+		IssueDate: edition.IssueDate.Format(issueDateLayout),
+	}
+}
+
+// =============================================================================
+
+// writePageEvent writes the page event to the outbox through the store, so
+// it is part of the transaction the core is bound to.
+func (c *Core) writePageEvent(ctx context.Context, eventType string, page Page) error {
+	ne := outbox.NewEvent{
+		Org:           page.Org,
+		AggregateType: AggregatePage,
+		AggregateID:   page.ID.String(),
+		Type:          eventType,
+		Data:          toPageEvent(page),
+	}
+
+	if _, err := c.storer.WriteEvent(ctx, ne); err != nil {
+		return fmt.Errorf("write event: type[%s] pageID[%s]: %w", eventType, page.ID, err)
+	}
+
+	return nil
+}
+
+// writeEditionEvent writes the edition event to the outbox through the
+// store, so it is part of the transaction the core is bound to.
+func (c *Core) writeEditionEvent(ctx context.Context, eventType string, edition Edition) error {
+	ne := outbox.NewEvent{
+		Org:           edition.Org,
+		AggregateType: AggregateEdition,
+		AggregateID:   edition.ID.String(),
+		Type:          eventType,
+		Data:          toEditionEvent(edition),
+	}
+
+	if _, err := c.storer.WriteEvent(ctx, ne); err != nil {
+		return fmt.Errorf("write event: type[%s] editionID[%s]: %w", eventType, edition.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package publication
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/foundation/validate"
+)
+
+// QueryFilter holds the available fields a query can be filtered on. The
+// organisation is always required so queries never cross organisations.
+type QueryFilter struct {
+	Org       string     `validate:"required"`
+	Status    *Status    `validate:"omitempty"`
+	EditionID *uuid.UUID `validate:"omitempty"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (qf *QueryFilter) Validate() error {
+	if err := validate.Check(qf); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	return nil
+}
+
+// WithStatus sets the Status field of the QueryFilter value.
+func (qf *QueryFilter) WithStatus(status Status) {
+	qf.Status = &status
+}
+
+// WithEditionID sets the EditionID field of the QueryFilter value.
+func (qf *QueryFilter) WithEditionID(editionID uuid.UUID) {
+	qf.EditionID = &editionID
+}
@@ -0,0 +1,213 @@
+package publication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+)
+
+// Set of job kinds the publication core queues and handles.
+const (
+	JobPublishPage    = "publication.publish_page"
+	JobPublishEdition = "publication.publish_edition"
+)
+
+// issueDateLayout is the layout of the issue date in job payloads.
+const issueDateLayout = "2006-01-02"
+
+// publishPayload is the payload of a JobPublishPage job.
+type publishPayload struct {
+	Org         string     `json:"org"`
+	PageID      uuid.UUID  `json:"pageId"`
+	ScheduledAt *time.Time `json:"scheduledAt,omitempty"`
+}
+
+// publishEditionPayload is the payload of a JobPublishEdition job.
+type publishEditionPayload struct {
+	Org       string    `json:"org"`
+	EditionID uuid.UUID `json:"editionId,omitempty"`
+	IssueDate string    `json:"issueDate,omitempty"`
+}
+
+// PublishPageJob constructs the job that publishes the page as soon as a
+// worker picks it up.
+func PublishPageJob(org string, pageID uuid.UUID) queue.NewJob {
+	return queue.NewJob{
+		Kind: JobPublishPage,
+		Payload: publishPayload{
+			Org:    org,
+			PageID: pageID,
+		},
+	}
+}
+
+// PublishEditionJob constructs the job that publishes the pages of the
+// edition. When the edition id is uuid.Nil, the editions of the organisation
+// issued on the date of the issue time are published instead.
+func PublishEditionJob(org string, editionID uuid.UUID, issue time.Time) queue.NewJob {
+	pl := publishEditionPayload{
+		Org:       org,
+		EditionID: editionID,
+	}
+
+	if editionID == uuid.Nil {
+		pl.IssueDate = issue.Format(issueDateLayout)
+	}
+
+	return queue.NewJob{
+		Kind:    JobPublishEdition,
+		Payload: pl,
+	}
+}
+
+// =============================================================================
+
+// HandlePublishJob is the queue handler for JobPublishPage. The job is
+// idempotent: a page that is already published is left alone, and a job
+// queued by a schedule that has since been changed is skipped.
+func (c *Core) HandlePublishJob(ctx context.Context, job queue.Job) error {
+	var pl publishPayload
+	if err := job.Decode(&pl); err != nil {
+		return queue.Permanent(err)
+	}
+
+	page, err := c.storer.QueryPageByID(ctx, pl.Org, pl.PageID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return queue.Permanent(fmt.Errorf("query: pageID[%s]: %w", pl.PageID, err))
+		}
+		return fmt.Errorf("query: pageID[%s]: %w", pl.PageID, err)
+	}
+
+	if page.Status.Equal(StatusPublished) {
+		return nil
+	}
+
+	if pl.ScheduledAt != nil {
+		current := page.Status.Equal(StatusScheduled) && page.ScheduledAt.Truncate(time.Microsecond).Equal(pl.ScheduledAt.Truncate(time.Microsecond))
+		if !current {
+			c.log.Info(ctx, "publish job", "status", "schedule changed, skipping", "pageID", page.ID, "jobID", job.ID)
+			return nil
+		}
+	}
+
+	if _, err := c.PublishPage(ctx, page); err != nil {
+		if errors.Is(err, ErrInvalidTransition) {
+			// Another job may have published the page since it was read.
+			current, qErr := c.storer.QueryPageByID(ctx, pl.Org, pl.PageID)
+			if qErr == nil && current.Status.Equal(StatusPublished) {
+				return nil
+			}
+
+			return queue.Permanent(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// HandlePublishEditionJob is the queue handler for JobPublishEdition. It fans
+// out a JobPublishPage job for every page of the edition that can still be
+// published, so each page is retried on its own.
+func (c *Core) HandlePublishEditionJob(ctx context.Context, job queue.Job) error {
+	var pl publishEditionPayload
+	if err := job.Decode(&pl); err != nil {
+		return queue.Permanent(err)
+	}
+
+	var editions []Edition
+
+	switch pl.EditionID {
+	case uuid.Nil:
+		issueDate, err := time.Parse(issueDateLayout, pl.IssueDate)
+		if err != nil {
+			return queue.Permanent(fmt.Errorf("parse issue date: %w", err))
+		}
+
+		editions, err = c.storer.QueryEditionsByIssueDate(ctx, pl.Org, issueDate)
+		if err != nil {
+			return fmt.Errorf("query: issueDate[%s]: %w", pl.IssueDate, err)
+		}
+
+		if len(editions) == 0 {
+			c.log.Info(ctx, "publish edition job", "status", "no edition issued, skipping", "org", pl.Org, "issueDate", pl.IssueDate, "jobID", job.ID)
+			return nil
+		}
+
+	default:
+		edition, err := c.storer.QueryEditionByID(ctx, pl.Org, pl.EditionID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return queue.Permanent(fmt.Errorf("query: editionID[%s]: %w", pl.EditionID, err))
+			}
+			return fmt.Errorf("query: editionID[%s]: %w", pl.EditionID, err)
+		}
+
+		editions = []Edition{edition}
+	}
+
+	for _, edition := range editions {
+		if err := c.fanOutEdition(ctx, edition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// fanOutEdition queues a publish job for each page of the edition that can be
+// published. Pages already published are skipped.
+func (c *Core) fanOutEdition(ctx context.Context, edition Edition) error {
+	const rowsPerPage = 100
+
+	filter := QueryFilter{Org: edition.Org}
+	filter.WithEditionID(edition.ID)
+
+	for pageNumber := 1; ; pageNumber++ {
+		pages, err := c.storer.QueryPages(ctx, filter, pageNumber, rowsPerPage)
+		if err != nil {
+			return fmt.Errorf("query pages: editionID[%s]: %w", edition.ID, err)
+		}
+
+		for _, page := range pages {
+			if !page.Status.CanTransitionTo(StatusPublished) {
+				continue
+			}
+
+			if _, err := c.storer.EnqueueJob(ctx, PublishPageJob(page.Org, page.ID)); err != nil {
+				return fmt.Errorf("enqueue: pageID[%s]: %w", page.ID, err)
+			}
+		}
+
+		if len(pages) < rowsPerPage {
+			return nil
+		}
+	}
+}
+
+func (c *Core) enqueuePublish(ctx context.Context, page Page, at time.Time) (queue.Job, error) {
+	nj := PublishPageJob(page.Org, page.ID)
+
+	if !at.IsZero() {
+		nj.RunAt = at
+		nj.Payload = publishPayload{
+			Org:         page.Org,
+			PageID:      page.ID,
+			ScheduledAt: &at,
+		}
+	}
+
+	job, err := c.storer.EnqueueJob(ctx, nj)
+	if err != nil {
+		return queue.Job{}, fmt.Errorf("enqueue: pageID[%s]: %w", page.ID, err)
+	}
+
+	return job, nil
+}
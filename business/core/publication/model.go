@@ -0,0 +1,74 @@
+package publication
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Page represents an individual page that goes through the publishing
+// lifecycle. A page can optionally belong to an edition.
+type Page struct {
+	ID          uuid.UUID
+	Org         string
+	EditionID   uuid.UUID
+	Title       string
+	Slug        string
+	Content     string
+	Status      Status
+	ScheduledAt time.Time
+	PublishedAt time.Time
+	CreatedBy   string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewPage is what we require from clients when adding a Page.
+type NewPage struct {
+	Org       string
+	EditionID uuid.UUID
+	Title     string
+	Slug      string
+	Content   string
+	CreatedBy string
+}
+
+// UpdatePage defines what information may be provided to modify an existing
+// Page. All fields are optional so clients can send just the fields they want
+// changed. It uses pointer fields so we can differentiate between a field that
+// was not provided and a field that was provided as explicitly blank.
+type UpdatePage struct {
+	EditionID *uuid.UUID
+	Title     *string
+	Slug      *string
+	Content   *string
+}
+
+// =============================================================================
+
+// Edition represents a group of pages that are published together, such as
+// the daily print edition.
+type Edition struct {
+	ID          uuid.UUID
+	Org         string
+	Name        string
+	IssueDate   time.Time
+	CreatedBy   string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewEdition is what we require from clients when adding an Edition.
+type NewEdition struct {
+	Org       string
+	Name      string
+	IssueDate time.Time
+	CreatedBy string
+}
+
+// UpdateEdition defines what information may be provided to modify an
+// existing Edition.
+type UpdateEdition struct {
+	Name      *string
+	IssueDate *time.Time
+}
@@ -0,0 +1,452 @@
+// Package publication provides the core business API for pages and editions
+// and the lifecycle pages go through: draft, scheduled, published and
+// unpublished. Every change writes a domain event to the outbox through the
+// store, so run changes under a transaction to commit the change and its
+// event together.
+package publication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/business/data/outbox"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrUniqueSlug        = errors.New("slug is already in use")
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrScheduleInPast    = errors.New("schedule time is in the past")
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+
+	CreatePage(ctx context.Context, page Page) error
+	UpdatePage(ctx context.Context, page Page) error
+	TransitionPage(ctx context.Context, page Page, from Status) error
+	DeletePage(ctx context.Context, page Page) error
+	QueryPages(ctx context.Context, filter QueryFilter, pageNumber int, rowsPerPage int) ([]Page, error)
+	CountPages(ctx context.Context, filter QueryFilter) (int, error)
+	ExportPages(ctx context.Context, filter QueryFilter, fn func(Page) error) error
+	QueryPageByID(ctx context.Context, org string, pageID uuid.UUID) (Page, error)
+
+	CreateEdition(ctx context.Context, edition Edition) error
+	UpdateEdition(ctx context.Context, edition Edition) error
+	DeleteEdition(ctx context.Context, edition Edition) error
+	QueryEditions(ctx context.Context, org string, pageNumber int, rowsPerPage int) ([]Edition, error)
+	CountEditions(ctx context.Context, org string) (int, error)
+	QueryEditionByID(ctx context.Context, org string, editionID uuid.UUID) (Edition, error)
+	QueryEditionsByIssueDate(ctx context.Context, org string, issueDate time.Time) ([]Edition, error)
+
+	EnqueueJob(ctx context.Context, nj queue.NewJob) (queue.Job, error)
+	WriteEvent(ctx context.Context, ne outbox.NewEvent) (outbox.Event, error)
+}
+
+// Core manages the set of APIs for publication access.
+type Core struct {
+	log      *logger.Logger
+	bgn      transaction.Beginner
+	webhooks *webhook.Core
+	storer   Storer
+	tx       transaction.Transaction
+}
+
+// NewCore constructs a core for publication api access.
+func NewCore(log *logger.Logger, bgn transaction.Beginner, webhooks *webhook.Core, storer Storer) *Core {
+	return &Core{
+		log:      log,
+		bgn:      bgn,
+		webhooks: webhooks,
+		storer:   storer,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	storer, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := c.webhooks.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	core := NewCore(c.log, c.bgn, webhooks, storer)
+	core.tx = tx
+
+	return core, nil
+}
+
+// =============================================================================
+// Pages
+
+// CreatePage adds a new page to the system in the draft status.
+func (c *Core) CreatePage(ctx context.Context, np NewPage) (Page, error) {
+	if np.EditionID != uuid.Nil {
+		if _, err := c.storer.QueryEditionByID(ctx, np.Org, np.EditionID); err != nil {
+			return Page{}, fmt.Errorf("query edition: editionID[%s]: %w", np.EditionID, err)
+		}
+	}
+
+	now := time.Now()
+
+	page := Page{
+		ID:          uuid.New(),
+		Org:         np.Org,
+		EditionID:   np.EditionID,
+		Title:       np.Title,
+		Slug:        np.Slug,
+		Content:     np.Content,
+		Status:      StatusDraft,
+		CreatedBy:   np.CreatedBy,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.storer.CreatePage(ctx, page); err != nil {
+		return Page{}, fmt.Errorf("create: %w", err)
+	}
+
+	if err := c.writePageEvent(ctx, EventPageCreated, page); err != nil {
+		return Page{}, err
+	}
+
+	return page, nil
+}
+
+// UpdatePage modifies information about a page.
+func (c *Core) UpdatePage(ctx context.Context, page Page, up UpdatePage) (Page, error) {
+	if up.EditionID != nil {
+		if *up.EditionID != uuid.Nil {
+			if _, err := c.storer.QueryEditionByID(ctx, page.Org, *up.EditionID); err != nil {
+				return Page{}, fmt.Errorf("query edition: editionID[%s]: %w", *up.EditionID, err)
+			}
+		}
+		page.EditionID = *up.EditionID
+	}
+
+	if up.Title != nil {
+		page.Title = *up.Title
+	}
+
+	if up.Slug != nil {
+		page.Slug = *up.Slug
+	}
+
+	if up.Content != nil {
+		page.Content = *up.Content
+	}
+
+	page.DateUpdated = time.Now()
+
+	if err := c.storer.UpdatePage(ctx, page); err != nil {
+		return Page{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.writePageEvent(ctx, EventPageUpdated, page); err != nil {
+		return Page{}, err
+	}
+
+	return page, nil
+}
+
+// DeletePage removes the specified page.
+func (c *Core) DeletePage(ctx context.Context, page Page) error {
+	if err := c.storer.DeletePage(ctx, page); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	if err := c.writePageEvent(ctx, EventPageDeleted, page); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// QueryPages retrieves a list of existing pages.
+func (c *Core) QueryPages(ctx context.Context, filter QueryFilter, pageNumber int, rowsPerPage int) ([]Page, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	pages, err := c.storer.QueryPages(ctx, filter, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return pages, nil
+}
+
+// CountPages returns the total number of pages.
+func (c *Core) CountPages(ctx context.Context, filter QueryFilter) (int, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, err
+	}
+
+	return c.storer.CountPages(ctx, filter)
+}
+
+// ExportPages calls the function with every page that matches the filter,
+// one at a time as they are read, so all the pages of an organization can be
+// exported without holding them in memory.
+func (c *Core) ExportPages(ctx context.Context, filter QueryFilter, fn func(Page) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.storer.ExportPages(ctx, filter, fn); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	return nil
+}
+
+// QueryPageByID finds the page by the specified ID within the organisation.
+func (c *Core) QueryPageByID(ctx context.Context, org string, pageID uuid.UUID) (Page, error) {
+	page, err := c.storer.QueryPageByID(ctx, org, pageID)
+	if err != nil {
+		return Page{}, fmt.Errorf("query: pageID[%s]: %w", pageID, err)
+	}
+
+	return page, nil
+}
+
+// =============================================================================
+// Lifecycle
+
+// SchedulePage moves the page to the scheduled status and queues the job that
+// publishes it at the specified time. Run this under a transaction so the
+// status change and the job are committed together.
+func (c *Core) SchedulePage(ctx context.Context, page Page, at time.Time) (Page, error) {
+	if !at.After(time.Now()) {
+		return Page{}, ErrScheduleInPast
+	}
+
+	page.ScheduledAt = at
+
+	page, err := c.transition(ctx, page, StatusScheduled)
+	if err != nil {
+		return Page{}, err
+	}
+
+	if _, err := c.enqueuePublish(ctx, page, at); err != nil {
+		return Page{}, err
+	}
+
+	return page, nil
+}
+
+// RequestPublish queues the job that publishes the page. Publishing to the
+// print and web targets is slow, so clients follow the page status to learn
+// when it has gone live.
+func (c *Core) RequestPublish(ctx context.Context, page Page) (queue.Job, error) {
+	if !page.Status.CanTransitionTo(StatusPublished) {
+		return queue.Job{}, fmt.Errorf("%s -> %s: %w", page.Status.Name(), StatusPublished.Name(), ErrInvalidTransition)
+	}
+
+	return c.enqueuePublish(ctx, page, time.Time{})
+}
+
+// PublishPage moves the page to the published status and captures the
+// page.published webhook event in the same transaction.
+func (c *Core) PublishPage(ctx context.Context, page Page) (Page, error) {
+	page.PublishedAt = time.Now()
+
+	return c.transitionWithEvent(ctx, page, StatusPublished, webhook.EventPagePublished)
+}
+
+// UnpublishPage takes a published page offline and captures the
+// page.unpublished webhook event in the same transaction.
+func (c *Core) UnpublishPage(ctx context.Context, page Page) (Page, error) {
+	return c.transitionWithEvent(ctx, page, StatusUnpublished, webhook.EventPageUnpublished)
+}
+
+// RevertPageToDraft moves a scheduled or unpublished page back to draft.
+func (c *Core) RevertPageToDraft(ctx context.Context, page Page) (Page, error) {
+	page.ScheduledAt = time.Time{}
+
+	return c.transition(ctx, page, StatusDraft)
+}
+
+// transition moves the page to the specified status. The page is only
+// updated while it is still in the status it was read with, so when two
+// changes race for the same page only one of them goes through and the other
+// gets ErrInvalidTransition.
+func (c *Core) transition(ctx context.Context, page Page, to Status) (Page, error) {
+	from := page.Status
+	if !from.CanTransitionTo(to) {
+		return Page{}, fmt.Errorf("%s -> %s: %w", from.Name(), to.Name(), ErrInvalidTransition)
+	}
+
+	page.Status = to
+	page.DateUpdated = time.Now()
+
+	if err := c.storer.TransitionPage(ctx, page, from); err != nil {
+		return Page{}, fmt.Errorf("transition: %s -> %s: %w", from.Name(), to.Name(), err)
+	}
+
+	if err := c.writePageEvent(ctx, transitionEvents[to], page); err != nil {
+		return Page{}, err
+	}
+
+	return page, nil
+}
+
+// transitionWithEvent moves the page to the specified status and captures the
+// webhook event for it. Both happen in one transaction, the one the core is
+// bound to or a new one, so subscribers are only told about changes that are
+// committed.
+func (c *Core) transitionWithEvent(ctx context.Context, page Page, to Status, eventType string) (Page, error) {
+	var updated Page
+
+	// The function may run more than once when the transaction is retried,
+	// so it always starts from the page that was passed in.
+	f := func(c *Core) error {
+		var err error
+		if updated, err = c.transition(ctx, page, to); err != nil {
+			return err
+		}
+
+		evt := webhook.Event{
+			Type: eventType,
+			Org:  updated.Org,
+			Data: toPageEvent(updated),
+		}
+
+		if err := c.webhooks.Capture(ctx, evt); err != nil {
+			return fmt.Errorf("capture: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.withTransaction(ctx, f); err != nil {
+		return Page{}, err
+	}
+
+	return updated, nil
+}
+
+// withTransaction executes the function with a core bound to a transaction.
+// A core that is already bound uses its own transaction.
+func (c *Core) withTransaction(ctx context.Context, fn func(c *Core) error) error {
+	if c.tx != nil {
+		return fn(c)
+	}
+
+	f := func(tx transaction.Transaction) error {
+		core, err := c.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return err
+		}
+
+		return fn(core)
+	}
+
+	return transaction.ExecuteUnderTransaction(ctx, c.log, c.bgn, f)
+}
+
+// =============================================================================
+// Editions
+
+// CreateEdition adds a new edition to the system.
+func (c *Core) CreateEdition(ctx context.Context, ne NewEdition) (Edition, error) {
+	now := time.Now()
+
+	edition := Edition{
+		ID:          uuid.New(),
+		Org:         ne.Org,
+		Name:        ne.Name,
+		IssueDate:   ne.IssueDate,
+		CreatedBy:   ne.CreatedBy,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.storer.CreateEdition(ctx, edition); err != nil {
+		return Edition{}, fmt.Errorf("create: %w", err)
+	}
+
+	if err := c.writeEditionEvent(ctx, EventEditionCreated, edition); err != nil {
+		return Edition{}, err
+	}
+
+	return edition, nil
+}
+
+// UpdateEdition modifies information about an edition.
+func (c *Core) UpdateEdition(ctx context.Context, edition Edition, ue UpdateEdition) (Edition, error) {
+	if ue.Name != nil {
+		edition.Name = *ue.Name
+	}
+
+	if ue.IssueDate != nil {
+		edition.IssueDate = *ue.IssueDate
+	}
+
+	edition.DateUpdated = time.Now()
+
+	if err := c.storer.UpdateEdition(ctx, edition); err != nil {
+		return Edition{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.writeEditionEvent(ctx, EventEditionUpdated, edition); err != nil {
+		return Edition{}, err
+	}
+
+	return edition, nil
+}
+
+// DeleteEdition removes the specified edition. Pages in the edition are kept
+// and no longer belong to an edition.
+func (c *Core) DeleteEdition(ctx context.Context, edition Edition) error {
+	if err := c.storer.DeleteEdition(ctx, edition); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	if err := c.writeEditionEvent(ctx, EventEditionDeleted, edition); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// QueryEditions retrieves a list of existing editions.
+func (c *Core) QueryEditions(ctx context.Context, org string, pageNumber int, rowsPerPage int) ([]Edition, error) {
+	editions, err := c.storer.QueryEditions(ctx, org, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return editions, nil
+}
+
+// CountEditions returns the total number of editions.
+func (c *Core) CountEditions(ctx context.Context, org string) (int, error) {
+	return c.storer.CountEditions(ctx, org)
+}
+
+// QueryEditionByID finds the edition by the specified ID within the
+// organisation.
+func (c *Core) QueryEditionByID(ctx context.Context, org string, editionID uuid.UUID) (Edition, error) {
+	edition, err := c.storer.QueryEditionByID(ctx, org, editionID)
+	if err != nil {
+		return Edition{}, fmt.Errorf("query: editionID[%s]: %w", editionID, err)
+	}
+
+	return edition, nil
+}
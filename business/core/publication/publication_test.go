@@ -0,0 +1,276 @@
+package publication_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	"github.com/vikaskumar1187/publisher_saas/business/data/outbox"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+func Test_Lifecycle(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		status publication.Status
+		act    func(ctx context.Context, core *publication.Core, page publication.Page) (publication.Page, error)
+		err    error
+		exp    publication.Status
+		events []string
+		jobs   int
+	}{
+		{
+			name:   "schedule draft",
+			status: publication.StatusDraft,
+			act: func(ctx context.Context, core *publication.Core, page publication.Page) (publication.Page, error) {
+				return core.SchedulePage(ctx, page, future)
+			},
+			exp:    publication.StatusScheduled,
+			events: []string{publication.EventPageScheduled},
+			jobs:   1,
+		},
+		{
+			name:   "schedule in the past",
+			status: publication.StatusDraft,
+			act: func(ctx context.Context, core *publication.Core, page publication.Page) (publication.Page, error) {
+				return core.SchedulePage(ctx, page, time.Now().Add(-time.Minute))
+			},
+			err: publication.ErrScheduleInPast,
+		},
+		{
+			name:   "schedule published",
+			status: publication.StatusPublished,
+			act: func(ctx context.Context, core *publication.Core, page publication.Page) (publication.Page, error) {
+				return core.SchedulePage(ctx, page, future)
+			},
+			err: publication.ErrInvalidTransition,
+		},
+		{
+			name:   "revert scheduled",
+			status: publication.StatusScheduled,
+			act: func(ctx context.Context, core *publication.Core, page publication.Page) (publication.Page, error) {
+				return core.RevertPageToDraft(ctx, page)
+			},
+			exp:    publication.StatusDraft,
+			events: []string{publication.EventPageDrafted},
+		},
+		{
+			name:   "revert published",
+			status: publication.StatusPublished,
+			act: func(ctx context.Context, core *publication.Core, page publication.Page) (publication.Page, error) {
+				return core.RevertPageToDraft(ctx, page)
+			},
+			err: publication.ErrInvalidTransition,
+		},
+		{
+			name:   "request publish draft",
+			status: publication.StatusDraft,
+			act: func(ctx context.Context, core *publication.Core, page publication.Page) (publication.Page, error) {
+				_, err := core.RequestPublish(ctx, page)
+				return page, err
+			},
+			exp:  publication.StatusDraft,
+			jobs: 1,
+		},
+		{
+			name:   "request publish published",
+			status: publication.StatusPublished,
+			act: func(ctx context.Context, core *publication.Core, page publication.Page) (publication.Page, error) {
+				_, err := core.RequestPublish(ctx, page)
+				return page, err
+			},
+			err: publication.ErrInvalidTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStore()
+			core := publication.NewCore(logger.New(io.Discard, logger.LevelInfo, "TEST", nil), nil, nil, store)
+
+			page := publication.Page{
+				ID:     uuid.New(),
+				Org:    "sample",
+				Status: tt.status,
+			}
+			store.pages[page.ID] = page
+
+			got, err := tt.act(context.Background(), core, page)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Should get error %v: got %v", tt.err, err)
+			}
+
+			if tt.err != nil {
+				if !store.pages[page.ID].Status.Equal(tt.status) || len(store.events) != 0 || len(store.jobs) != 0 {
+					t.Fatal("Should leave the page alone on a failed transition")
+				}
+				return
+			}
+
+			if !got.Status.Equal(tt.exp) || !store.pages[page.ID].Status.Equal(tt.exp) {
+				t.Fatalf("Should move the page to %s: got %s", tt.exp.Name(), got.Status.Name())
+			}
+
+			if len(store.events) != len(tt.events) {
+				t.Fatalf("Should write events %v: got %v", tt.events, store.events)
+			}
+			for i := range tt.events {
+				if store.events[i] != tt.events[i] {
+					t.Fatalf("Should write events %v: got %v", tt.events, store.events)
+				}
+			}
+
+			if len(store.jobs) != tt.jobs {
+				t.Fatalf("Should queue %d jobs: got %d", tt.jobs, len(store.jobs))
+			}
+		})
+	}
+}
+
+func Test_LifecycleRace(t *testing.T) {
+	store := newStore()
+	core := publication.NewCore(logger.New(io.Discard, logger.LevelInfo, "TEST", nil), nil, nil, store)
+
+	page := publication.Page{
+		ID:     uuid.New(),
+		Org:    "sample",
+		Status: publication.StatusDraft,
+	}
+	store.pages[page.ID] = page
+
+	if _, err := core.SchedulePage(context.Background(), page, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Should be able to schedule the page: %v", err)
+	}
+
+	// A second change made with the page as it was read before the first
+	// one loses the race.
+	if _, err := core.SchedulePage(context.Background(), page, time.Now().Add(2*time.Hour)); !errors.Is(err, publication.ErrInvalidTransition) {
+		t.Fatalf("Should not schedule a page that was changed since it was read: got %v", err)
+	}
+
+	if len(store.jobs) != 1 || len(store.events) != 1 {
+		t.Fatalf("Should queue one job and write one event: got %d jobs and %v", len(store.jobs), store.events)
+	}
+}
+
+func Test_HandlePublishJobSkips(t *testing.T) {
+	at := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		page publication.Page
+		job  queue.NewJob
+	}{
+		{
+			name: "already published",
+			page: publication.Page{Status: publication.StatusPublished},
+		},
+		{
+			name: "schedule changed",
+			page: publication.Page{Status: publication.StatusScheduled, ScheduledAt: at.Add(time.Minute)},
+		},
+		{
+			name: "schedule reverted",
+			page: publication.Page{Status: publication.StatusDraft},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newStore()
+			core := publication.NewCore(logger.New(io.Discard, logger.LevelInfo, "TEST", nil), nil, nil, store)
+
+			page := tt.page
+			page.ID = uuid.New()
+			page.Org = "sample"
+			store.pages[page.ID] = page
+
+			// Scheduling queues the job with the time it was scheduled for.
+			scheduled := page
+			scheduled.Status = publication.StatusDraft
+			store.pages[page.ID] = scheduled
+			if _, err := core.SchedulePage(context.Background(), scheduled, at); err != nil {
+				t.Fatalf("Should be able to schedule the page: %v", err)
+			}
+			store.pages[page.ID] = page
+			store.events = nil
+
+			job := queue.Job{ID: uuid.New(), Kind: publication.JobPublishPage, Payload: store.jobs[0].Payload}
+
+			if err := core.HandlePublishJob(context.Background(), job); err != nil {
+				t.Fatalf("Should skip the job without an error: %v", err)
+			}
+
+			if !store.pages[page.ID].Status.Equal(page.Status) || len(store.events) != 0 {
+				t.Fatalf("Should leave the page alone: got %s", store.pages[page.ID].Status.Name())
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// store is an in memory Storer for the pages, events and jobs. The methods
+// the tests don't use panic through the embedded nil interface.
+type store struct {
+	publication.Storer
+
+	pages  map[uuid.UUID]publication.Page
+	events []string
+	jobs   []queue.Job
+}
+
+func newStore() *store {
+	return &store{
+		pages: make(map[uuid.UUID]publication.Page),
+	}
+}
+
+func (s *store) UpdatePage(ctx context.Context, page publication.Page) error {
+	s.pages[page.ID] = page
+	return nil
+}
+
+func (s *store) TransitionPage(ctx context.Context, page publication.Page, from publication.Status) error {
+	current, exists := s.pages[page.ID]
+	if !exists || !current.Status.Equal(from) {
+		return publication.ErrInvalidTransition
+	}
+
+	s.pages[page.ID] = page
+	return nil
+}
+
+func (s *store) QueryPageByID(ctx context.Context, org string, pageID uuid.UUID) (publication.Page, error) {
+	page, exists := s.pages[pageID]
+	if !exists || page.Org != org {
+		return publication.Page{}, publication.ErrNotFound
+	}
+
+	return page, nil
+}
+
+func (s *store) EnqueueJob(ctx context.Context, nj queue.NewJob) (queue.Job, error) {
+	data, err := json.Marshal(nj.Payload)
+	if err != nil {
+		return queue.Job{}, err
+	}
+
+	job := queue.Job{ID: uuid.New(), Kind: nj.Kind, Payload: data, RunAt: nj.RunAt}
+	s.jobs = append(s.jobs, job)
+
+	return job, nil
+}
+
+func (s *store) WriteEvent(ctx context.Context, ne outbox.NewEvent) (outbox.Event, error) {
+	s.events = append(s.events, ne.Type)
+	return outbox.Event{}, nil
+}
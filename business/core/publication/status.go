@@ -0,0 +1,75 @@
+package publication
+
+import "fmt"
+
+// Set of possible lifecycle states for a page.
+var (
+	StatusDraft       = Status{"draft"}
+	StatusScheduled   = Status{"scheduled"}
+	StatusPublished   = Status{"published"}
+	StatusUnpublished = Status{"unpublished"}
+)
+
+// Set of known statuses.
+var statuses = map[string]Status{
+	StatusDraft.name:       StatusDraft,
+	StatusScheduled.name:   StatusScheduled,
+	StatusPublished.name:   StatusPublished,
+	StatusUnpublished.name: StatusUnpublished,
+}
+
+// Set of allowed transitions between statuses.
+var transitions = map[Status][]Status{
+	StatusDraft:       {StatusScheduled, StatusPublished},
+	StatusScheduled:   {StatusDraft, StatusPublished},
+	StatusPublished:   {StatusUnpublished},
+	StatusUnpublished: {StatusDraft, StatusScheduled, StatusPublished},
+}
+
+// Status represents a state in the lifecycle of a page.
+type Status struct {
+	name string
+}
+
+// ParseStatus parses the string value and returns a status if one exists.
+func ParseStatus(value string) (Status, error) {
+	status, exists := statuses[value]
+	if !exists {
+		return Status{}, fmt.Errorf("invalid status %q", value)
+	}
+
+	return status, nil
+}
+
+// MustParseStatus parses the string value and returns a status if one exists.
+// If an error occurs the function panics.
+func MustParseStatus(value string) Status {
+	status, err := ParseStatus(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return status
+}
+
+// Name returns the name of the status.
+func (s Status) Name() string {
+	return s.name
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (s Status) Equal(s2 Status) bool {
+	return s.name == s2.name
+}
+
+// CanTransitionTo reports whether a page in this status can move to the
+// specified status.
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package publication_test
+
+import (
+	"testing"
+
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+)
+
+func Test_StatusTransitions(t *testing.T) {
+	var (
+		draft       = publication.StatusDraft
+		scheduled   = publication.StatusScheduled
+		published   = publication.StatusPublished
+		unpublished = publication.StatusUnpublished
+	)
+
+	tests := []struct {
+		from    publication.Status
+		to      publication.Status
+		allowed bool
+	}{
+		{draft, draft, false},
+		{draft, scheduled, true},
+		{draft, published, true},
+		{draft, unpublished, false},
+
+		{scheduled, draft, true},
+		{scheduled, scheduled, false},
+		{scheduled, published, true},
+		{scheduled, unpublished, false},
+
+		{published, draft, false},
+		{published, scheduled, false},
+		{published, published, false},
+		{published, unpublished, true},
+
+		{unpublished, draft, true},
+		{unpublished, scheduled, true},
+		{unpublished, published, true},
+		{unpublished, unpublished, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+			t.Errorf("%s -> %s: Should get allowed %t: got %t", tt.from.Name(), tt.to.Name(), tt.allowed, got)
+		}
+	}
+}
+
+func Test_ParseStatus(t *testing.T) {
+	for _, name := range []string{"draft", "scheduled", "published", "unpublished"} {
+		status, err := publication.ParseStatus(name)
+		if err != nil || status.Name() != name {
+			t.Errorf("Should parse %q: got %q: %v", name, status.Name(), err)
+		}
+	}
+
+	if _, err := publication.ParseStatus("live"); err == nil {
+		t.Error("Should not parse an unknown status")
+	}
+}
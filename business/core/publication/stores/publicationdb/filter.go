@@ -0,0 +1,26 @@
+package publicationdb
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+)
+
+func (s *Store) applyFilter(filter publication.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	wc := []string{"org = :org"}
+	data["org"] = filter.Org
+
+	if filter.Status != nil {
+		data["status"] = filter.Status.Name()
+		wc = append(wc, "status = :status")
+	}
+
+	if filter.EditionID != nil {
+		data["edition_id"] = *filter.EditionID
+		wc = append(wc, "edition_id = :edition_id")
+	}
+
+	buf.WriteString(" WHERE ")
+	buf.WriteString(strings.Join(wc, " AND "))
+}
@@ -0,0 +1,145 @@
+package publicationdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+)
+
+// dbPage represent the structure we need for moving data
+// between the app and the database.
+type dbPage struct {
+	ID          uuid.UUID     `db:"page_id"`
+	Org         string        `db:"org"`
+	EditionID   uuid.NullUUID `db:"edition_id"`
+	Title       string        `db:"title"`
+	Slug        string        `db:"slug"`
+	Content     string        `db:"content" log:"redact"`
+	Status      string        `db:"status"`
+	ScheduledAt sql.NullTime  `db:"scheduled_at"`
+	PublishedAt sql.NullTime  `db:"published_at"`
+	CreatedBy   string        `db:"created_by"`
+	DateCreated time.Time     `db:"date_created"`
+	DateUpdated time.Time     `db:"date_updated"`
+}
+
+func toDBPage(page publication.Page) dbPage {
+	return dbPage{
+		ID:          page.ID,
+		Org:         page.Org,
+		EditionID:   toNullUUID(page.EditionID),
+		Title:       page.Title,
+		Slug:        page.Slug,
+		Content:     page.Content,
+		Status:      page.Status.Name(),
+		ScheduledAt: toNullTime(page.ScheduledAt),
+		PublishedAt: toNullTime(page.PublishedAt),
+		CreatedBy:   page.CreatedBy,
+		DateCreated: page.DateCreated.UTC(),
+		DateUpdated: page.DateUpdated.UTC(),
+	}
+}
+
+func toCorePage(dbPage dbPage) (publication.Page, error) {
+	status, err := publication.ParseStatus(dbPage.Status)
+	if err != nil {
+		return publication.Page{}, err
+	}
+
+	page := publication.Page{
+		ID:          dbPage.ID,
+		Org:         dbPage.Org,
+		EditionID:   dbPage.EditionID.UUID,
+		Title:       dbPage.Title,
+		Slug:        dbPage.Slug,
+		Content:     dbPage.Content,
+		Status:      status,
+		ScheduledAt: fromNullTime(dbPage.ScheduledAt),
+		PublishedAt: fromNullTime(dbPage.PublishedAt),
+		CreatedBy:   dbPage.CreatedBy,
+		DateCreated: dbPage.DateCreated.In(time.Local),
+		DateUpdated: dbPage.DateUpdated.In(time.Local),
+	}
+
+	return page, nil
+}
+
+func toCorePageSlice(dbPages []dbPage) ([]publication.Page, error) {
+	pages := make([]publication.Page, len(dbPages))
+	for i, dbPage := range dbPages {
+		page, err := toCorePage(dbPage)
+		if err != nil {
+			return nil, err
+		}
+		pages[i] = page
+	}
+	return pages, nil
+}
+
+// =============================================================================
+
+// dbEdition represent the structure we need for moving data
+// between the app and the database.
+type dbEdition struct {
+	ID          uuid.UUID `db:"edition_id"`
+	Org         string    `db:"org"`
+	Name        string    `db:"name"`
+	IssueDate   time.Time `db:"issue_date"`
+	CreatedBy   string    `db:"created_by"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+func toDBEdition(edition publication.Edition) dbEdition {
+	return dbEdition{
+		ID:          edition.ID,
+		Org:         edition.Org,
+		Name:        edition.Name,
+		IssueDate:   edition.IssueDate.UTC(),
+		CreatedBy:   edition.CreatedBy,
+		DateCreated: edition.DateCreated.UTC(),
+		DateUpdated: edition.DateUpdated.UTC(),
+	}
+}
+
+func toCoreEdition(dbEdition dbEdition) publication.Edition {
+	return publication.Edition{
+		ID:          dbEdition.ID,
+		Org:         dbEdition.Org,
+		Name:        dbEdition.Name,
+		IssueDate:   dbEdition.IssueDate,
+		CreatedBy:   dbEdition.CreatedBy,
+		DateCreated: dbEdition.DateCreated.In(time.Local),
+		DateUpdated: dbEdition.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreEditionSlice(dbEditions []dbEdition) []publication.Edition {
+	editions := make([]publication.Edition, len(dbEditions))
+	for i, dbEdition := range dbEditions {
+		editions[i] = toCoreEdition(dbEdition)
+	}
+	return editions
+}
+
+// =============================================================================
+
+func toNullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+func toNullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func fromNullTime(nt sql.NullTime) time.Time {
+	if !nt.Valid {
+		return time.Time{}
+	}
+	return nt.Time.In(time.Local)
+}
@@ -0,0 +1,471 @@
+// Package publicationdb contains page and edition related CRUD functionality.
+package publicationdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/outbox"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Store manages the set of APIs for publication database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (publication.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// =============================================================================
+// Pages
+
+// CreatePage inserts a new page into the database.
+func (s *Store) CreatePage(ctx context.Context, page publication.Page) error {
+	const q = `
+	INSERT INTO pages
+		(page_id, org, edition_id, title, slug, content, status, scheduled_at, published_at, created_by, date_created, date_updated)
+	VALUES
+		(:page_id, :org, :edition_id, :title, :slug, :content, :status, :scheduled_at, :published_at, :created_by, :date_created, :date_updated)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBPage(page)); err != nil {
+		if errors.Is(err, db.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", publication.ErrUniqueSlug)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePage replaces a page document in the database.
+func (s *Store) UpdatePage(ctx context.Context, page publication.Page) error {
+	const q = `
+	UPDATE
+		pages
+	SET
+		"edition_id" = :edition_id,
+		"title" = :title,
+		"slug" = :slug,
+		"content" = :content,
+		"status" = :status,
+		"scheduled_at" = :scheduled_at,
+		"published_at" = :published_at,
+		"date_updated" = :date_updated
+	WHERE
+		page_id = :page_id AND org = :org`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBPage(page)); err != nil {
+		if errors.Is(err, db.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", publication.ErrUniqueSlug)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// TransitionPage replaces a page document in the database while the page is
+// still in the from status. It returns ErrInvalidTransition when the page
+// has moved on since it was read.
+func (s *Store) TransitionPage(ctx context.Context, page publication.Page, from publication.Status) error {
+	data := struct {
+		dbPage
+		FromStatus string `db:"from_status"`
+	}{
+		dbPage:     toDBPage(page),
+		FromStatus: from.Name(),
+	}
+
+	const q = `
+	UPDATE
+		pages
+	SET
+		"edition_id" = :edition_id,
+		"title" = :title,
+		"slug" = :slug,
+		"content" = :content,
+		"status" = :status,
+		"scheduled_at" = :scheduled_at,
+		"published_at" = :published_at,
+		"date_updated" = :date_updated
+	WHERE
+		page_id = :page_id AND org = :org AND status = :from_status
+	RETURNING
+		page_id`
+
+	var row struct {
+		ID uuid.UUID `db:"page_id"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &row); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", publication.ErrInvalidTransition)
+		}
+		if errors.Is(err, db.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedquerystruct: %w", publication.ErrUniqueSlug)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePage removes a page from the database.
+func (s *Store) DeletePage(ctx context.Context, page publication.Page) error {
+	data := struct {
+		ID  string `db:"page_id"`
+		Org string `db:"org"`
+	}{
+		ID:  page.ID.String(),
+		Org: page.Org,
+	}
+
+	const q = `
+	DELETE FROM
+		pages
+	WHERE
+		page_id = :page_id AND org = :org`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryPages retrieves a list of existing pages from the database.
+func (s *Store) QueryPages(ctx context.Context, filter publication.QueryFilter, pageNumber int, rowsPerPage int) ([]publication.Page, error) {
+	data := map[string]any{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		pages`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	buf.WriteString(" ORDER BY date_created DESC, page_id")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbPages []dbPage
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPages); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCorePageSlice(dbPages)
+}
+
+// CountPages returns the total number of pages in the DB.
+func (s *Store) CountPages(ctx context.Context, filter publication.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		pages`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// ExportPages reads the pages that match the filter from the database and
+// calls the function with each one as it is read.
+func (s *Store) ExportPages(ctx context.Context, filter publication.QueryFilter, fn func(publication.Page) error) error {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		pages`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	buf.WriteString(" ORDER BY date_created DESC, page_id")
+
+	f := func(dbPage dbPage) error {
+		page, err := toCorePage(dbPage)
+		if err != nil {
+			return err
+		}
+
+		return fn(page)
+	}
+
+	if err := db.NamedQueryEach(ctx, s.log, s.db, buf.String(), data, f); err != nil {
+		return fmt.Errorf("namedqueryeach: %w", err)
+	}
+
+	return nil
+}
+
+// QueryPageByID gets the specified page from the database.
+func (s *Store) QueryPageByID(ctx context.Context, org string, pageID uuid.UUID) (publication.Page, error) {
+	data := struct {
+		ID  string `db:"page_id"`
+		Org string `db:"org"`
+	}{
+		ID:  pageID.String(),
+		Org: org,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		pages
+	WHERE
+		page_id = :page_id AND org = :org`
+
+	var dbPage dbPage
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbPage); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return publication.Page{}, fmt.Errorf("namedquerystruct: %w", publication.ErrNotFound)
+		}
+		return publication.Page{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCorePage(dbPage)
+}
+
+// =============================================================================
+// Editions
+
+// CreateEdition inserts a new edition into the database.
+func (s *Store) CreateEdition(ctx context.Context, edition publication.Edition) error {
+	const q = `
+	INSERT INTO editions
+		(edition_id, org, name, issue_date, created_by, date_created, date_updated)
+	VALUES
+		(:edition_id, :org, :name, :issue_date, :created_by, :date_created, :date_updated)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBEdition(edition)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateEdition replaces an edition document in the database.
+func (s *Store) UpdateEdition(ctx context.Context, edition publication.Edition) error {
+	const q = `
+	UPDATE
+		editions
+	SET
+		"name" = :name,
+		"issue_date" = :issue_date,
+		"date_updated" = :date_updated
+	WHERE
+		edition_id = :edition_id AND org = :org`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBEdition(edition)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteEdition removes an edition from the database.
+func (s *Store) DeleteEdition(ctx context.Context, edition publication.Edition) error {
+	data := struct {
+		ID  string `db:"edition_id"`
+		Org string `db:"org"`
+	}{
+		ID:  edition.ID.String(),
+		Org: edition.Org,
+	}
+
+	const q = `
+	DELETE FROM
+		editions
+	WHERE
+		edition_id = :edition_id AND org = :org`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryEditions retrieves a list of existing editions from the database.
+func (s *Store) QueryEditions(ctx context.Context, org string, pageNumber int, rowsPerPage int) ([]publication.Edition, error) {
+	data := map[string]any{
+		"org":           org,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		editions
+	WHERE
+		org = :org
+	ORDER BY
+		issue_date DESC, edition_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var dbEditions []dbEdition
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbEditions); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreEditionSlice(dbEditions), nil
+}
+
+// CountEditions returns the total number of editions in the DB.
+func (s *Store) CountEditions(ctx context.Context, org string) (int, error) {
+	data := struct {
+		Org string `db:"org"`
+	}{
+		Org: org,
+	}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		editions
+	WHERE
+		org = :org`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryEditionByID gets the specified edition from the database.
+func (s *Store) QueryEditionByID(ctx context.Context, org string, editionID uuid.UUID) (publication.Edition, error) {
+	data := struct {
+		ID  string `db:"edition_id"`
+		Org string `db:"org"`
+	}{
+		ID:  editionID.String(),
+		Org: org,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		editions
+	WHERE
+		edition_id = :edition_id AND org = :org`
+
+	var dbEdition dbEdition
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbEdition); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return publication.Edition{}, fmt.Errorf("namedquerystruct: %w", publication.ErrNotFound)
+		}
+		return publication.Edition{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreEdition(dbEdition), nil
+}
+
+// QueryEditionsByIssueDate gets the editions issued on the specified date.
+func (s *Store) QueryEditionsByIssueDate(ctx context.Context, org string, issueDate time.Time) ([]publication.Edition, error) {
+	data := struct {
+		Org       string `db:"org"`
+		IssueDate string `db:"issue_date"`
+	}{
+		Org:       org,
+		IssueDate: issueDate.Format("2006-01-02"),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		editions
+	WHERE
+		org = :org AND issue_date = CAST(:issue_date AS DATE)
+	ORDER BY
+		edition_id`
+
+	var dbEditions []dbEdition
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbEditions); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreEditionSlice(dbEditions), nil
+}
+
+// =============================================================================
+// Jobs
+
+// EnqueueJob adds a job to the queue using the same connection, or
+// transaction, as the rest of the store.
+func (s *Store) EnqueueJob(ctx context.Context, nj queue.NewJob) (queue.Job, error) {
+	job, err := queue.Enqueue(ctx, s.log, s.db, nj)
+	if err != nil {
+		return queue.Job{}, fmt.Errorf("enqueue: %w", err)
+	}
+
+	return job, nil
+}
+
+// WriteEvent adds a domain event to the outbox using the same connection, or
+// transaction, as the rest of the store.
+func (s *Store) WriteEvent(ctx context.Context, ne outbox.NewEvent) (outbox.Event, error) {
+	evt, err := outbox.Write(ctx, s.log, s.db, ne)
+	if err != nil {
+		return outbox.Event{}, fmt.Errorf("write: %w", err)
+	}
+
+	return evt, nil
+}
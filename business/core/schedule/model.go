@@ -0,0 +1,61 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schedule represents a one-off or recurring publish. A recurring schedule
+// has a cron spec that is evaluated in its time zone. A one-off schedule has
+// a run time instead and is disabled once it has run.
+type Schedule struct {
+	ID          uuid.UUID
+	Org         string
+	Name        string
+	TargetType  TargetType
+	TargetID    uuid.UUID
+	Spec        string
+	RunAt       time.Time
+	TimeZone    string
+	CatchUp     CatchUp
+	Enabled     bool
+	NextRunAt   time.Time
+	LastRunAt   time.Time
+	CreatedBy   string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// Recurring reports whether the schedule runs on a cron spec.
+func (s Schedule) Recurring() bool {
+	return s.Spec != ""
+}
+
+// NewSchedule is what we require from clients when adding a Schedule. Either
+// Spec or RunAt must be provided.
+type NewSchedule struct {
+	Org        string
+	Name       string
+	TargetType TargetType
+	TargetID   uuid.UUID
+	Spec       string
+	RunAt      time.Time
+	TimeZone   string
+	CatchUp    CatchUp
+	CreatedBy  string
+}
+
+// UpdateSchedule defines what information may be provided to modify an
+// existing Schedule. All fields are optional so clients can send just the
+// fields they want changed. It uses pointer fields so we can differentiate
+// between a field that was not provided and a field that was provided as
+// explicitly blank. The target of a schedule can't be changed.
+type UpdateSchedule struct {
+	Name     *string
+	Spec     *string
+	RunAt    *time.Time
+	TimeZone *string
+	CatchUp  *CatchUp
+	Enabled  *bool
+}
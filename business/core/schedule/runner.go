@@ -0,0 +1,226 @@
+package schedule
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+)
+
+// LockID is the key of the advisory lock that elects the replica running the
+// schedules. The value is arbitrary but must be the same for every replica.
+const LockID = 7480122050
+
+// This holds the metrics for the runner. The expvar package is based on a
+// singleton so these are registered once for the process.
+var sm = struct {
+	runs    *expvar.Int
+	missed  *expvar.Int
+	errors  *expvar.Int
+	lastRun *expvar.String
+}{
+	runs:    expvar.NewInt("schedule_runs"),
+	missed:  expvar.NewInt("schedule_missed_runs"),
+	errors:  expvar.NewInt("schedule_errors"),
+	lastRun: expvar.NewString("schedule_last_tick"),
+}
+
+// RunConfig represents the settings of the runner.
+type RunConfig struct {
+	Interval         time.Duration
+	CatchUp          CatchUp
+	MisfireThreshold time.Duration
+	MaxCatchUp       int
+	BatchSize        int
+}
+
+// Run fires the due schedules on the configured interval until the context
+// is cancelled. It is meant to run on the leader replica only.
+func (c *Core) Run(ctx context.Context, cfg RunConfig) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15 * time.Second
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.RunDue(ctx, time.Now(), cfg); err != nil {
+			c.log.Error(ctx, "scheduler", "status", "running due schedules", "msg", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RunDue fires the schedules that are due at the specified time and returns
+// the number of runs that were queued. Each schedule is fired in its own
+// transaction so the publish jobs and the next run are committed together.
+func (c *Core) RunDue(ctx context.Context, now time.Time, cfg RunConfig) (int, error) {
+	if cfg.CatchUp == (CatchUp{}) {
+		cfg.CatchUp = CatchUpLatest
+	}
+
+	if cfg.MaxCatchUp <= 0 {
+		cfg.MaxCatchUp = 100
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	sm.lastRun.Set(now.UTC().Format(time.RFC3339))
+
+	due, err := c.storer.QueryDue(ctx, now, cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("query due: %w", err)
+	}
+
+	var total int
+	for _, sch := range due {
+		n, err := c.fire(ctx, sch, now, cfg)
+		if err != nil {
+			sm.errors.Add(1)
+			c.log.Error(ctx, "scheduler", "status", "firing schedule", "scheduleID", sch.ID, "org", sch.Org, "msg", err)
+			continue
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// =============================================================================
+
+// fire queues the publish jobs for the due runs of the schedule and moves it
+// on to its next run.
+func (c *Core) fire(ctx context.Context, sch Schedule, now time.Time, cfg RunConfig) (int, error) {
+	runs, next, err := c.dueRuns(ctx, sch, now, cfg)
+	if err != nil {
+
+		// A schedule that can't be evaluated would fail on every tick, so it
+		// is disabled until it is fixed.
+		sch.Enabled = false
+		sch.DateUpdated = now
+		if err := c.storer.Update(ctx, sch); err != nil {
+			return 0, fmt.Errorf("disable: %w", err)
+		}
+		return 0, fmt.Errorf("evaluate: schedule disabled: %w", err)
+	}
+
+	f := func(tx transaction.Transaction) error {
+		storer, err := c.storer.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, run := range runs {
+			if _, err := storer.EnqueueJob(ctx, c.job(sch, run)); err != nil {
+				return fmt.Errorf("enqueue: run[%s]: %w", run.Format(time.RFC3339), err)
+			}
+		}
+
+		if len(runs) > 0 {
+			sch.LastRunAt = runs[len(runs)-1]
+		}
+
+		sch.NextRunAt = next
+		sch.DateUpdated = now
+		if next.IsZero() {
+			sch.Enabled = false
+		}
+
+		if err := storer.Update(ctx, sch); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := transaction.ExecuteUnderTransaction(ctx, c.log, c.bgn, f); err != nil {
+		return 0, err
+	}
+
+	sm.runs.Add(int64(len(runs)))
+
+	return len(runs), nil
+}
+
+// dueRuns works out which runs of the schedule to fire at the specified time
+// according to the catch up policy, and when the schedule runs next. A run is
+// missed when it is older than the misfire threshold. One-off schedules
+// always fire, however late. No policy fires more than the latest MaxCatchUp
+// runs, so only those are worked out however long the runner was down.
+func (c *Core) dueRuns(ctx context.Context, sch Schedule, now time.Time, cfg RunConfig) ([]time.Time, time.Time, error) {
+	if !sch.Recurring() {
+		return []time.Time{sch.NextRunAt}, time.Time{}, nil
+	}
+
+	due, occ, truncated, err := sch.runs(sch.NextRunAt, now, cfg.MaxCatchUp)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var missed int
+	for _, run := range due {
+		if now.Sub(run) > cfg.MisfireThreshold {
+			missed++
+		}
+	}
+	sm.missed.Add(int64(missed))
+
+	policy := sch.CatchUp
+	if policy == (CatchUp{}) {
+		policy = cfg.CatchUp
+	}
+
+	var runs []time.Time
+
+	switch policy {
+	case CatchUpAll:
+		runs = due
+
+	case CatchUpSkip:
+		runs = due[missed:]
+
+	default:
+		if len(due) > 0 {
+			runs = due[len(due)-1:]
+		}
+	}
+
+	if missed > 0 {
+		c.log.Info(ctx, "scheduler", "status", "catching up missed runs", "scheduleID", sch.ID, "missed", missed, "truncated", truncated, "policy", policy.Name(), "firing", len(runs))
+	}
+
+	return runs, occ, nil
+}
+
+// job constructs the publish job for a run of the schedule.
+func (c *Core) job(sch Schedule, run time.Time) queue.NewJob {
+	switch sch.TargetType {
+	case TargetPage:
+		return publication.PublishPageJob(sch.Org, sch.TargetID)
+
+	case TargetEdition:
+		return publication.PublishEditionJob(sch.Org, sch.TargetID, run)
+
+	default:
+
+		// The issue date is the local date of the run in the time zone of
+		// the schedule.
+		if loc, err := ParseTimeZone(sch.TimeZone); err == nil {
+			run = run.In(loc)
+		}
+		return publication.PublishEditionJob(sch.Org, uuid.Nil, run)
+	}
+}
@@ -0,0 +1,257 @@
+// Package schedule provides the core business API for one-off and recurring
+// publish schedules and the runner that fires them when they fall due.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/publication"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound        = errors.New("schedule not found")
+	ErrInvalidTiming   = errors.New("either a spec or a run time is required, not both")
+	ErrInvalidSpec     = errors.New("invalid spec")
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+	ErrInvalidTarget   = errors.New("invalid target")
+	ErrRunInPast       = errors.New("run time is in the past")
+	ErrTargetNotFound  = errors.New("target not found")
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	Create(ctx context.Context, sch Schedule) error
+	Update(ctx context.Context, sch Schedule) error
+	Delete(ctx context.Context, sch Schedule) error
+	Query(ctx context.Context, org string, pageNumber int, rowsPerPage int) ([]Schedule, error)
+	Count(ctx context.Context, org string) (int, error)
+	QueryByID(ctx context.Context, org string, scheduleID uuid.UUID) (Schedule, error)
+	QueryDue(ctx context.Context, now time.Time, limit int) ([]Schedule, error)
+	EnqueueJob(ctx context.Context, nj queue.NewJob) (queue.Job, error)
+}
+
+// Core manages the set of APIs for schedule access.
+type Core struct {
+	log     *logger.Logger
+	bgn     transaction.Beginner
+	pubCore *publication.Core
+	storer  Storer
+}
+
+// NewCore constructs a core for schedule api access.
+func NewCore(log *logger.Logger, bgn transaction.Beginner, pubCore *publication.Core, storer Storer) *Core {
+	return &Core{
+		log:     log,
+		bgn:     bgn,
+		pubCore: pubCore,
+		storer:  storer,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	storer, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	pubCore, err := c.pubCore.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCore(c.log, c.bgn, pubCore, storer), nil
+}
+
+// Create adds a new schedule to the system.
+func (c *Core) Create(ctx context.Context, ns NewSchedule) (Schedule, error) {
+	now := time.Now()
+
+	sch := Schedule{
+		ID:          uuid.New(),
+		Org:         ns.Org,
+		Name:        ns.Name,
+		TargetType:  ns.TargetType,
+		TargetID:    ns.TargetID,
+		Spec:        ns.Spec,
+		RunAt:       ns.RunAt,
+		TimeZone:    ns.TimeZone,
+		CatchUp:     ns.CatchUp,
+		Enabled:     true,
+		CreatedBy:   ns.CreatedBy,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.checkTarget(ctx, sch); err != nil {
+		return Schedule{}, err
+	}
+
+	sch, err := c.plan(sch, now)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if err := c.storer.Create(ctx, sch); err != nil {
+		return Schedule{}, fmt.Errorf("create: %w", err)
+	}
+
+	return sch, nil
+}
+
+// Update modifies information about a schedule. The next run is recalculated
+// from the current time.
+func (c *Core) Update(ctx context.Context, sch Schedule, us UpdateSchedule) (Schedule, error) {
+	if us.Name != nil {
+		sch.Name = *us.Name
+	}
+
+	if us.Spec != nil {
+		sch.Spec = *us.Spec
+		if sch.Spec != "" {
+			sch.RunAt = time.Time{}
+		}
+	}
+
+	if us.RunAt != nil {
+		sch.RunAt = *us.RunAt
+		if !sch.RunAt.IsZero() {
+			sch.Spec = ""
+		}
+	}
+
+	if us.TimeZone != nil {
+		sch.TimeZone = *us.TimeZone
+	}
+
+	if us.CatchUp != nil {
+		sch.CatchUp = *us.CatchUp
+	}
+
+	if us.Enabled != nil {
+		sch.Enabled = *us.Enabled
+	}
+
+	now := time.Now()
+	sch.DateUpdated = now
+
+	sch, err := c.plan(sch, now)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if err := c.storer.Update(ctx, sch); err != nil {
+		return Schedule{}, fmt.Errorf("update: %w", err)
+	}
+
+	return sch, nil
+}
+
+// Delete removes the specified schedule.
+func (c *Core) Delete(ctx context.Context, sch Schedule) error {
+	if err := c.storer.Delete(ctx, sch); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing schedules.
+func (c *Core) Query(ctx context.Context, org string, pageNumber int, rowsPerPage int) ([]Schedule, error) {
+	schedules, err := c.storer.Query(ctx, org, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// Count returns the total number of schedules.
+func (c *Core) Count(ctx context.Context, org string) (int, error) {
+	return c.storer.Count(ctx, org)
+}
+
+// QueryByID finds the schedule by the specified ID within the organisation.
+func (c *Core) QueryByID(ctx context.Context, org string, scheduleID uuid.UUID) (Schedule, error) {
+	sch, err := c.storer.QueryByID(ctx, org, scheduleID)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("query: scheduleID[%s]: %w", scheduleID, err)
+	}
+
+	return sch, nil
+}
+
+// =============================================================================
+
+// checkTarget validates the target of the schedule exists in the
+// organisation.
+func (c *Core) checkTarget(ctx context.Context, sch Schedule) error {
+	switch sch.TargetType {
+	case TargetPage:
+		if _, err := c.pubCore.QueryPageByID(ctx, sch.Org, sch.TargetID); err != nil {
+			if errors.Is(err, publication.ErrNotFound) {
+				return fmt.Errorf("page[%s]: %w", sch.TargetID, ErrTargetNotFound)
+			}
+			return fmt.Errorf("query page: %w", err)
+		}
+
+	case TargetEdition:
+		if _, err := c.pubCore.QueryEditionByID(ctx, sch.Org, sch.TargetID); err != nil {
+			if errors.Is(err, publication.ErrNotFound) {
+				return fmt.Errorf("edition[%s]: %w", sch.TargetID, ErrTargetNotFound)
+			}
+			return fmt.Errorf("query edition: %w", err)
+		}
+
+	case TargetIssue:
+		if sch.TargetID != uuid.Nil {
+			return fmt.Errorf("issue schedules don't take a target id: %w", ErrInvalidTarget)
+		}
+
+	default:
+		return fmt.Errorf("type %q: %w", sch.TargetType.Name(), ErrInvalidTarget)
+	}
+
+	return nil
+}
+
+// plan validates the timing of the schedule and calculates its next run.
+func (c *Core) plan(sch Schedule, now time.Time) (Schedule, error) {
+	if (sch.Spec == "") == sch.RunAt.IsZero() {
+		return Schedule{}, ErrInvalidTiming
+	}
+
+	if _, err := ParseTimeZone(sch.TimeZone); err != nil {
+		return Schedule{}, err
+	}
+
+	if sch.Recurring() {
+		if _, err := ParseSpec(sch.Spec); err != nil {
+			return Schedule{}, err
+		}
+	}
+
+	if !sch.Recurring() && sch.Enabled && !sch.RunAt.After(now) {
+		return Schedule{}, ErrRunInPast
+	}
+
+	next, err := sch.next(now)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	sch.NextRunAt = next
+
+	return sch, nil
+}
@@ -0,0 +1,137 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	// The time zone database is embedded so schedules evaluate correctly in
+	// images that don't ship one.
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+)
+
+// parser accepts the standard five field cron expressions and descriptors
+// like @daily.
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ParseSpec validates a cron expression. The time zone is a separate field of
+// the schedule so the TZ prefixes are not accepted.
+func ParseSpec(spec string) (cron.Schedule, error) {
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		return nil, fmt.Errorf("%w: time zone must be set on the schedule, not in the spec", ErrInvalidSpec)
+	}
+
+	sched, err := parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidSpec, spec, err)
+	}
+
+	return sched, nil
+}
+
+// ParseTimeZone validates the IANA name of a time zone.
+func ParseTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: time zone is required", ErrInvalidTimeZone)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidTimeZone, name, err)
+	}
+
+	return loc, nil
+}
+
+// next returns the first run of the schedule after the specified time, or
+// the zero time if the schedule has no more runs. Recurring schedules are
+// evaluated in their time zone, so a spec of "0 22 * * *" runs at 22:00
+// local time on both sides of a daylight saving change.
+func (s Schedule) next(after time.Time) (time.Time, error) {
+	if !s.Recurring() {
+		if s.RunAt.After(after) {
+			return s.RunAt, nil
+		}
+		return time.Time{}, nil
+	}
+
+	sched, err := s.cron()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return sched.Next(after), nil
+}
+
+// runs returns the runs of a recurring schedule from the first run up to and
+// including now, keeping only the latest limit of them, and the run after
+// now. It reports whether earlier runs were left out.
+func (s Schedule) runs(first time.Time, now time.Time, limit int) ([]time.Time, time.Time, bool, error) {
+	sched, err := s.cron()
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+
+	walk := func(occ time.Time, max int) ([]time.Time, time.Time) {
+		var runs []time.Time
+		for !occ.IsZero() && !occ.After(now) && len(runs) < max {
+			runs = append(runs, occ)
+			occ = sched.Next(occ)
+		}
+		return runs, occ
+	}
+
+	runs, next := walk(first, limit+1)
+	if len(runs) <= limit {
+		return runs, next, false, nil
+	}
+
+	// More runs are due than the limit, as after a long outage. Instead of
+	// walking every one of them, the walk starts at a point before now that
+	// moves back until the runs after it reach the limit.
+	window := runs[limit].Sub(runs[0])
+	for {
+		start := now.Add(-window)
+		if !start.After(first) {
+			runs, next = walk(first, math.MaxInt)
+			break
+		}
+
+		if runs, next = walk(sched.Next(start), math.MaxInt); len(runs) >= limit {
+			break
+		}
+
+		window *= 2
+	}
+
+	return runs[len(runs)-limit:], next, true, nil
+}
+
+// cron parses the spec of a recurring schedule with its time zone.
+func (s Schedule) cron() (cron.Schedule, error) {
+	sched, err := ParseSpec(s.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := ParseTimeZone(s.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	return inZone{sched: sched, loc: loc}, nil
+}
+
+// inZone evaluates a cron schedule in a time zone.
+type inZone struct {
+	sched cron.Schedule
+	loc   *time.Location
+}
+
+// Next implements the cron.Schedule interface.
+func (z inZone) Next(t time.Time) time.Time {
+	return z.sched.Next(t.In(z.loc))
+}
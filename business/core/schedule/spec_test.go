@@ -0,0 +1,256 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+func Test_ParseSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		err  error
+	}{
+		{name: "five fields", spec: "0 22 * * *"},
+		{name: "descriptor", spec: "@daily"},
+		{name: "seconds field", spec: "0 0 22 * * *", err: ErrInvalidSpec},
+		{name: "tz prefix", spec: "TZ=Europe/Stockholm 0 22 * * *", err: ErrInvalidSpec},
+		{name: "cron tz prefix", spec: "CRON_TZ=Europe/Stockholm 0 22 * * *", err: ErrInvalidSpec},
+		{name: "garbage", spec: "every day", err: ErrInvalidSpec},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpec(tt.spec)
+			if tt.err == nil && err != nil {
+				t.Fatalf("Should parse the spec: %v", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("Should reject the spec with %v: got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func Test_ParseTimeZone(t *testing.T) {
+	if _, err := ParseTimeZone("Europe/Stockholm"); err != nil {
+		t.Fatalf("Should parse an IANA time zone: %v", err)
+	}
+
+	for _, name := range []string{"", "Mars/Olympus"} {
+		if _, err := ParseTimeZone(name); !errors.Is(err, ErrInvalidTimeZone) {
+			t.Fatalf("Should reject time zone %q: got %v", name, err)
+		}
+	}
+}
+
+func Test_NextTimeZone(t *testing.T) {
+	sch := Schedule{Spec: "0 22 * * *", TimeZone: "Europe/Stockholm"}
+
+	loc, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Fatalf("Should load the time zone: %v", err)
+	}
+
+	// Summer time ends on 2024-10-27, so the same local time is two hours
+	// after UTC before the change and one hour after it.
+	tests := []struct {
+		name  string
+		after time.Time
+		exp   time.Time
+	}{
+		{name: "summer", after: time.Date(2024, 10, 25, 21, 0, 0, 0, time.UTC), exp: time.Date(2024, 10, 26, 20, 0, 0, 0, time.UTC)},
+		{name: "winter", after: time.Date(2024, 10, 26, 21, 0, 0, 0, time.UTC), exp: time.Date(2024, 10, 27, 21, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sch.next(tt.after)
+			if err != nil {
+				t.Fatalf("Should get the next run: %v", err)
+			}
+
+			if !got.Equal(tt.exp) {
+				t.Fatalf("Should run at %v: got %v", tt.exp, got)
+			}
+
+			if h := got.In(loc).Hour(); h != 22 {
+				t.Fatalf("Should run at 22:00 local time: got %d:00", h)
+			}
+		})
+	}
+}
+
+func Test_NextOneOff(t *testing.T) {
+	runAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sch := Schedule{RunAt: runAt}
+
+	if got, _ := sch.next(runAt.Add(-time.Minute)); !got.Equal(runAt) {
+		t.Fatalf("Should run at %v: got %v", runAt, got)
+	}
+
+	if got, _ := sch.next(runAt); !got.IsZero() {
+		t.Fatalf("Should not run again: got %v", got)
+	}
+}
+
+// =============================================================================
+
+func Test_DueRuns(t *testing.T) {
+	c := &Core{log: logger.New(io.Discard, logger.LevelInfo, "TEST", nil)}
+
+	hourly := Schedule{Spec: "0 * * * *", TimeZone: "UTC"}
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		catchUp CatchUp
+		now     time.Time
+		exp     []time.Time
+	}{
+		{
+			name:    "on time",
+			catchUp: CatchUpSkip,
+			now:     first.Add(30 * time.Second),
+			exp:     []time.Time{first},
+		},
+		{
+			name:    "all",
+			catchUp: CatchUpAll,
+			now:     first.Add(2*time.Hour + 30*time.Second),
+			exp:     []time.Time{first, first.Add(time.Hour), first.Add(2 * time.Hour)},
+		},
+		{
+			name:    "all limited to max",
+			catchUp: CatchUpAll,
+			now:     first.Add(10*time.Hour + 30*time.Second),
+			exp:     []time.Time{first.Add(8 * time.Hour), first.Add(9 * time.Hour), first.Add(10 * time.Hour)},
+		},
+		{
+			name:    "latest",
+			catchUp: CatchUpLatest,
+			now:     first.Add(2*time.Hour + 30*time.Second),
+			exp:     []time.Time{first.Add(2 * time.Hour)},
+		},
+		{
+			name:    "skip keeps the run within the threshold",
+			catchUp: CatchUpSkip,
+			now:     first.Add(2*time.Hour + 30*time.Second),
+			exp:     []time.Time{first.Add(2 * time.Hour)},
+		},
+		{
+			name:    "skip drops every missed run",
+			catchUp: CatchUpSkip,
+			now:     first.Add(2*time.Hour + 30*time.Minute),
+			exp:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sch := hourly
+			sch.NextRunAt = first
+			sch.CatchUp = tt.catchUp
+
+			cfg := RunConfig{MisfireThreshold: time.Minute, MaxCatchUp: 3}
+
+			runs, next, err := c.dueRuns(context.Background(), sch, tt.now, cfg)
+			if err != nil {
+				t.Fatalf("Should work out the due runs: %v", err)
+			}
+
+			if !equalTimes(runs, tt.exp) {
+				t.Fatalf("Should fire %v: got %v", tt.exp, runs)
+			}
+
+			if exp := tt.now.Truncate(time.Hour).Add(time.Hour); !next.Equal(exp) {
+				t.Fatalf("Should run next at %v: got %v", exp, next)
+			}
+		})
+	}
+}
+
+func Test_DueRunsLongOutage(t *testing.T) {
+	c := &Core{log: logger.New(io.Discard, logger.LevelInfo, "TEST", nil)}
+
+	first := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := first.AddDate(1, 0, 0).Add(30 * time.Second)
+
+	sch := Schedule{Spec: "* * * * *", TimeZone: "UTC", NextRunAt: first, CatchUp: CatchUpAll}
+	cfg := RunConfig{MisfireThreshold: time.Minute, MaxCatchUp: 5}
+
+	// A year of minutes is half a million runs. The look back keeps the work
+	// close to the limit, so this finishes well within the deadline.
+	start := time.Now()
+	runs, next, err := c.dueRuns(context.Background(), sch, now, cfg)
+	if err != nil {
+		t.Fatalf("Should work out the due runs: %v", err)
+	}
+
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Should not walk every missed run: took %v", d)
+	}
+
+	last := now.Truncate(time.Minute)
+	exp := []time.Time{last.Add(-4 * time.Minute), last.Add(-3 * time.Minute), last.Add(-2 * time.Minute), last.Add(-time.Minute), last}
+	if !equalTimes(runs, exp) {
+		t.Fatalf("Should fire the latest runs %v: got %v", exp, runs)
+	}
+
+	if !next.Equal(last.Add(time.Minute)) {
+		t.Fatalf("Should run next at %v: got %v", last.Add(time.Minute), next)
+	}
+}
+
+func Test_Runs(t *testing.T) {
+	sch := Schedule{Spec: "0 0 * * *", TimeZone: "UTC"}
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for limit := 1; limit <= 12; limit++ {
+		for days := 0; days < 20; days++ {
+			now := first.AddDate(0, 0, days).Add(time.Hour)
+
+			runs, _, truncated, err := sch.runs(first, now, limit)
+			if err != nil {
+				t.Fatalf("Should work out the runs: %v", err)
+			}
+
+			n := days + 1
+			if n > limit {
+				n = limit
+			}
+
+			var exp []time.Time
+			for i := days + 1 - n; i <= days; i++ {
+				exp = append(exp, first.AddDate(0, 0, i))
+			}
+
+			if !equalTimes(runs, exp) {
+				t.Fatalf("limit %d, days %d: Should get %v: got %v", limit, days, exp, runs)
+			}
+
+			if truncated != (days+1 > limit) {
+				t.Fatalf("limit %d, days %d: Should report truncated %t", limit, days, days+1 > limit)
+			}
+		}
+	}
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
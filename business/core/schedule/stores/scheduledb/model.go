@@ -0,0 +1,110 @@
+package scheduledb
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/schedule"
+)
+
+// dbSchedule represent the structure we need for moving data
+// between the app and the database.
+type dbSchedule struct {
+	ID          uuid.UUID     `db:"schedule_id"`
+	Org         string        `db:"org"`
+	Name        string        `db:"name"`
+	TargetType  string        `db:"target_type"`
+	TargetID    uuid.NullUUID `db:"target_id"`
+	Spec        string        `db:"spec"`
+	RunAt       sql.NullTime  `db:"run_at"`
+	TimeZone    string        `db:"time_zone"`
+	CatchUp     string        `db:"catch_up"`
+	Enabled     bool          `db:"enabled"`
+	NextRunAt   sql.NullTime  `db:"next_run_at"`
+	LastRunAt   sql.NullTime  `db:"last_run_at"`
+	CreatedBy   string        `db:"created_by"`
+	DateCreated time.Time     `db:"date_created"`
+	DateUpdated time.Time     `db:"date_updated"`
+}
+
+func toDBSchedule(sch schedule.Schedule) dbSchedule {
+	return dbSchedule{
+		ID:          sch.ID,
+		Org:         sch.Org,
+		Name:        sch.Name,
+		TargetType:  sch.TargetType.Name(),
+		TargetID:    uuid.NullUUID{UUID: sch.TargetID, Valid: sch.TargetID != uuid.Nil},
+		Spec:        sch.Spec,
+		RunAt:       toNullTime(sch.RunAt),
+		TimeZone:    sch.TimeZone,
+		CatchUp:     sch.CatchUp.Name(),
+		Enabled:     sch.Enabled,
+		NextRunAt:   toNullTime(sch.NextRunAt),
+		LastRunAt:   toNullTime(sch.LastRunAt),
+		CreatedBy:   sch.CreatedBy,
+		DateCreated: sch.DateCreated.UTC(),
+		DateUpdated: sch.DateUpdated.UTC(),
+	}
+}
+
+func toCoreSchedule(dbSch dbSchedule) (schedule.Schedule, error) {
+	targetType, err := schedule.ParseTargetType(dbSch.TargetType)
+	if err != nil {
+		return schedule.Schedule{}, fmt.Errorf("parse target type: %w", err)
+	}
+
+	catchUp, err := schedule.ParseCatchUp(dbSch.CatchUp)
+	if err != nil {
+		return schedule.Schedule{}, fmt.Errorf("parse catch up: %w", err)
+	}
+
+	sch := schedule.Schedule{
+		ID:          dbSch.ID,
+		Org:         dbSch.Org,
+		Name:        dbSch.Name,
+		TargetType:  targetType,
+		TargetID:    dbSch.TargetID.UUID,
+		Spec:        dbSch.Spec,
+		RunAt:       fromNullTime(dbSch.RunAt),
+		TimeZone:    dbSch.TimeZone,
+		CatchUp:     catchUp,
+		Enabled:     dbSch.Enabled,
+		NextRunAt:   fromNullTime(dbSch.NextRunAt),
+		LastRunAt:   fromNullTime(dbSch.LastRunAt),
+		CreatedBy:   dbSch.CreatedBy,
+		DateCreated: dbSch.DateCreated.In(time.Local),
+		DateUpdated: dbSch.DateUpdated.In(time.Local),
+	}
+
+	return sch, nil
+}
+
+func toCoreScheduleSlice(dbSchedules []dbSchedule) ([]schedule.Schedule, error) {
+	schedules := make([]schedule.Schedule, len(dbSchedules))
+	for i, dbSch := range dbSchedules {
+		sch, err := toCoreSchedule(dbSch)
+		if err != nil {
+			return nil, err
+		}
+		schedules[i] = sch
+	}
+	return schedules, nil
+}
+
+// =============================================================================
+
+func toNullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func fromNullTime(nt sql.NullTime) time.Time {
+	if !nt.Valid {
+		return time.Time{}
+	}
+	return nt.Time.In(time.Local)
+}
@@ -0,0 +1,233 @@
+// Package scheduledb contains schedule related CRUD functionality.
+package scheduledb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/core/schedule"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Store manages the set of APIs for schedule database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (schedule.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new schedule into the database.
+func (s *Store) Create(ctx context.Context, sch schedule.Schedule) error {
+	const q = `
+	INSERT INTO schedules
+		(schedule_id, org, name, target_type, target_id, spec, run_at, time_zone, catch_up, enabled, next_run_at, last_run_at, created_by, date_created, date_updated)
+	VALUES
+		(:schedule_id, :org, :name, :target_type, :target_id, :spec, :run_at, :time_zone, :catch_up, :enabled, :next_run_at, :last_run_at, :created_by, :date_created, :date_updated)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBSchedule(sch)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a schedule document in the database.
+func (s *Store) Update(ctx context.Context, sch schedule.Schedule) error {
+	const q = `
+	UPDATE
+		schedules
+	SET
+		"name" = :name,
+		"spec" = :spec,
+		"run_at" = :run_at,
+		"time_zone" = :time_zone,
+		"catch_up" = :catch_up,
+		"enabled" = :enabled,
+		"next_run_at" = :next_run_at,
+		"last_run_at" = :last_run_at,
+		"date_updated" = :date_updated
+	WHERE
+		schedule_id = :schedule_id AND org = :org`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBSchedule(sch)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a schedule from the database.
+func (s *Store) Delete(ctx context.Context, sch schedule.Schedule) error {
+	data := struct {
+		ID  string `db:"schedule_id"`
+		Org string `db:"org"`
+	}{
+		ID:  sch.ID.String(),
+		Org: sch.Org,
+	}
+
+	const q = `
+	DELETE FROM
+		schedules
+	WHERE
+		schedule_id = :schedule_id AND org = :org`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing schedules from the database.
+func (s *Store) Query(ctx context.Context, org string, pageNumber int, rowsPerPage int) ([]schedule.Schedule, error) {
+	data := map[string]any{
+		"org":           org,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		schedules
+	WHERE
+		org = :org
+	ORDER BY
+		date_created DESC, schedule_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var dbSchedules []dbSchedule
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbSchedules); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreScheduleSlice(dbSchedules)
+}
+
+// Count returns the total number of schedules in the DB.
+func (s *Store) Count(ctx context.Context, org string) (int, error) {
+	data := struct {
+		Org string `db:"org"`
+	}{
+		Org: org,
+	}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		schedules
+	WHERE
+		org = :org`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified schedule from the database.
+func (s *Store) QueryByID(ctx context.Context, org string, scheduleID uuid.UUID) (schedule.Schedule, error) {
+	data := struct {
+		ID  string `db:"schedule_id"`
+		Org string `db:"org"`
+	}{
+		ID:  scheduleID.String(),
+		Org: org,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		schedules
+	WHERE
+		schedule_id = :schedule_id AND org = :org`
+
+	var dbSch dbSchedule
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbSch); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return schedule.Schedule{}, fmt.Errorf("namedquerystruct: %w", schedule.ErrNotFound)
+		}
+		return schedule.Schedule{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreSchedule(dbSch)
+}
+
+// QueryDue retrieves the enabled schedules, across all organisations, whose
+// next run is at or before the specified time.
+func (s *Store) QueryDue(ctx context.Context, now time.Time, limit int) ([]schedule.Schedule, error) {
+	data := struct {
+		Now   time.Time `db:"now"`
+		Limit int       `db:"limit"`
+	}{
+		Now:   now.UTC(),
+		Limit: limit,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		schedules
+	WHERE
+		enabled AND next_run_at <= :now
+	ORDER BY
+		next_run_at
+	LIMIT :limit`
+
+	var dbSchedules []dbSchedule
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbSchedules); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreScheduleSlice(dbSchedules)
+}
+
+// EnqueueJob adds a job to the queue using the same connection, or
+// transaction, as the rest of the store.
+func (s *Store) EnqueueJob(ctx context.Context, nj queue.NewJob) (queue.Job, error) {
+	job, err := queue.Enqueue(ctx, s.log, s.db, nj)
+	if err != nil {
+		return queue.Job{}, fmt.Errorf("enqueue: %w", err)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,118 @@
+package schedule
+
+import "fmt"
+
+// Set of things a schedule can publish.
+var (
+	TargetPage    = TargetType{"page"}
+	TargetEdition = TargetType{"edition"}
+	TargetIssue   = TargetType{"issue"}
+)
+
+// Set of known target types.
+var targetTypes = map[string]TargetType{
+	TargetPage.name:    TargetPage,
+	TargetEdition.name: TargetEdition,
+	TargetIssue.name:   TargetIssue,
+}
+
+// TargetType represents what a schedule publishes when it runs. A page or
+// edition target publishes a specific page or edition. An issue target
+// publishes the editions issued on the local date of each run, which is how
+// recurring daily editions are set up.
+type TargetType struct {
+	name string
+}
+
+// ParseTargetType parses the string value and returns a target type if one
+// exists.
+func ParseTargetType(value string) (TargetType, error) {
+	targetType, exists := targetTypes[value]
+	if !exists {
+		return TargetType{}, fmt.Errorf("invalid target type %q", value)
+	}
+
+	return targetType, nil
+}
+
+// MustParseTargetType parses the string value and returns a target type if
+// one exists. If an error occurs the function panics.
+func MustParseTargetType(value string) TargetType {
+	targetType, err := ParseTargetType(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return targetType
+}
+
+// Name returns the name of the target type.
+func (tt TargetType) Name() string {
+	return tt.name
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (tt TargetType) Equal(tt2 TargetType) bool {
+	return tt.name == tt2.name
+}
+
+// =============================================================================
+
+// Set of policies for runs that were missed, for example while no replica
+// was running.
+var (
+	CatchUpAll    = CatchUp{"all"}
+	CatchUpLatest = CatchUp{"latest"}
+	CatchUpSkip   = CatchUp{"skip"}
+)
+
+// Set of known catch up policies.
+var catchUps = map[string]CatchUp{
+	CatchUpAll.name:    CatchUpAll,
+	CatchUpLatest.name: CatchUpLatest,
+	CatchUpSkip.name:   CatchUpSkip,
+}
+
+// CatchUp represents what happens to the runs of a recurring schedule that
+// were missed. All runs every missed run, latest runs only the most recent
+// one and skip drops them. The zero value uses the default policy of the
+// runner.
+type CatchUp struct {
+	name string
+}
+
+// ParseCatchUp parses the string value and returns a catch up policy if one
+// exists. An empty value returns the zero value.
+func ParseCatchUp(value string) (CatchUp, error) {
+	if value == "" {
+		return CatchUp{}, nil
+	}
+
+	catchUp, exists := catchUps[value]
+	if !exists {
+		return CatchUp{}, fmt.Errorf("invalid catch up policy %q", value)
+	}
+
+	return catchUp, nil
+}
+
+// MustParseCatchUp parses the string value and returns a catch up policy if
+// one exists. If an error occurs the function panics.
+func MustParseCatchUp(value string) CatchUp {
+	catchUp, err := ParseCatchUp(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return catchUp
+}
+
+// Name returns the name of the catch up policy.
+func (cu CatchUp) Name() string {
+	return cu.name
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (cu CatchUp) Equal(cu2 CatchUp) bool {
+	return cu.name == cu2.name
+}
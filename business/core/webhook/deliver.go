@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+)
+
+// Set of headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Publisher-Event"
+	HeaderDelivery  = "X-Publisher-Delivery"
+	HeaderSignature = "X-Publisher-Signature"
+)
+
+// maxErrorBody is the number of bytes of a failed response body that are
+// kept in the delivery log.
+const maxErrorBody = 512
+
+// Sign returns the value of the signature header for the payload. The
+// signature is the hex encoded HMAC-SHA256 of the timestamp and the payload
+// joined by a dot, keyed with the secret of the subscription. Receivers
+// should recompute it and reject timestamps that are too old.
+func Sign(secret string, ts time.Time, payload []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HandleDeliveryJob is the queue handler for JobDeliver. It posts the
+// payload of the delivery to the subscription and records the outcome. A
+// failed attempt returns an error so the queue retries it with backoff, and
+// the delivery is marked failed once the job runs out of attempts.
+func (c *Core) HandleDeliveryJob(ctx context.Context, job queue.Job) error {
+	var pl deliverPayload
+	if err := job.Decode(&pl); err != nil {
+		return queue.Permanent(err)
+	}
+
+	dlv, err := c.storer.QueryDeliveryByID(ctx, pl.Org, pl.DeliveryID)
+	if err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			return queue.Permanent(fmt.Errorf("query: deliveryID[%s]: %w", pl.DeliveryID, err))
+		}
+		return fmt.Errorf("query: deliveryID[%s]: %w", pl.DeliveryID, err)
+	}
+
+	if dlv.Status != DeliveryPending {
+		return nil
+	}
+
+	sub, err := c.storer.QuerySubscriptionByID(ctx, dlv.Org, dlv.SubscriptionID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return queue.Permanent(c.fail(ctx, dlv, 0, "subscription deleted"))
+
+	case err != nil:
+		return fmt.Errorf("query: subscriptionID[%s]: %w", dlv.SubscriptionID, err)
+
+	case !sub.Active:
+		return queue.Permanent(c.fail(ctx, dlv, 0, "subscription inactive"))
+	}
+
+	statusCode, sendErr := c.send(ctx, sub, dlv)
+
+	now := time.Now()
+	dlv.Attempts++
+	dlv.LastStatusCode = statusCode
+	dlv.DateUpdated = now
+
+	if sendErr == nil {
+		dlv.Status = DeliverySucceeded
+		dlv.LastError = ""
+		dlv.DateDelivered = now
+
+		if err := c.storer.UpdateDelivery(ctx, dlv); err != nil {
+			return fmt.Errorf("update: deliveryID[%s]: %w", dlv.ID, err)
+		}
+
+		return nil
+	}
+
+	dlv.LastError = sendErr.Error()
+	if job.Attempt >= job.MaxAttempts {
+		dlv.Status = DeliveryFailed
+	}
+
+	if err := c.storer.UpdateDelivery(ctx, dlv); err != nil {
+		return fmt.Errorf("update: deliveryID[%s]: %w", dlv.ID, err)
+	}
+
+	return fmt.Errorf("deliver: deliveryID[%s]: %w", dlv.ID, sendErr)
+}
+
+// =============================================================================
+
+// send posts the delivery to the subscription and returns the status code of
+// the response. Any status outside of 2xx is an error.
+func (c *Core) send(ctx context.Context, sub Subscription, dlv Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(dlv.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "publisher-webhooks/1.0")
+	req.Header.Set(HeaderEvent, dlv.EventType)
+	req.Header.Set(HeaderDelivery, dlv.ID.String())
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, time.Now(), dlv.Payload))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return resp.StatusCode, nil
+}
+
+// fail marks the delivery failed without sending it. The returned error
+// explains why.
+func (c *Core) fail(ctx context.Context, dlv Delivery, statusCode int, reason string) error {
+	dlv.Status = DeliveryFailed
+	dlv.LastStatusCode = statusCode
+	dlv.LastError = reason
+	dlv.DateUpdated = time.Now()
+
+	if err := c.storer.UpdateDelivery(ctx, dlv); err != nil {
+		return fmt.Errorf("update: deliveryID[%s]: %w", dlv.ID, err)
+	}
+
+	return fmt.Errorf("deliveryID[%s]: %s", dlv.ID, reason)
+}
@@ -0,0 +1,12 @@
+package webhook
+
+import "github.com/vikaskumar1187/publisher_saas/foundation/logger"
+
+// NewTestCore constructs a core whose client can reach the test servers on
+// the loopback address. Redirects are still not followed.
+func NewTestCore(log *logger.Logger, storer Storer) *Core {
+	core := NewCore(log, storer)
+	core.client = newClient(nil)
+
+	return core
+}
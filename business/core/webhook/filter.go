@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/foundation/validate"
+)
+
+// DeliveryFilter holds the available fields a delivery query can be
+// filtered on. The organisation and subscription are always required.
+type DeliveryFilter struct {
+	Org            string    `validate:"required"`
+	SubscriptionID uuid.UUID `validate:"required"`
+	Status         *string   `validate:"omitempty,oneof=pending succeeded failed"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (df *DeliveryFilter) Validate() error {
+	if err := validate.Check(df); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	return nil
+}
+
+// WithStatus sets the Status field of the DeliveryFilter value.
+func (df *DeliveryFilter) WithStatus(status string) {
+	df.Status = &status
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of event types a subscription can receive.
+const (
+	EventPagePublished   = "page.published"
+	EventPageUnpublished = "page.unpublished"
+)
+
+// eventTypes is the set of known event types.
+var eventTypes = map[string]bool{
+	EventPagePublished:   true,
+	EventPageUnpublished: true,
+}
+
+// Event represents something that happened in an organisation that
+// subscribers are told about. The data is marshaled to JSON.
+type Event struct {
+	Type string
+	Org  string
+	Data any
+}
+
+// Subscription represents an endpoint of an organisation that receives the
+// events it subscribes to. The secret is used to sign the deliveries.
+type Subscription struct {
+	ID          uuid.UUID
+	Org         string
+	URL         string
+	Events      []string
+	Secret      string
+	Active      bool
+	CreatedBy   string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewSubscription is what we require from clients when adding a
+// Subscription.
+type NewSubscription struct {
+	Org       string
+	URL       string
+	Events    []string
+	CreatedBy string
+}
+
+// UpdateSubscription defines what information may be provided to modify an
+// existing Subscription. All fields are optional so clients can send just
+// the fields they want changed.
+type UpdateSubscription struct {
+	URL    *string
+	Events []string
+	Active *bool
+}
+
+// =============================================================================
+
+// Delivery represents a single event sent, or to be sent, to a subscription.
+// The payload is the exact body that is posted.
+type Delivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	Org            string
+	EventID        uuid.UUID
+	EventType      string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int
+	LastStatusCode int
+	LastError      string
+	DateCreated    time.Time
+	DateUpdated    time.Time
+	DateDelivered  time.Time
+}
@@ -0,0 +1,22 @@
+package webhookdb
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+)
+
+func (s *Store) applyFilter(filter webhook.DeliveryFilter, data map[string]any, buf *bytes.Buffer) {
+	wc := []string{"org = :org", "subscription_id = :subscription_id"}
+	data["org"] = filter.Org
+	data["subscription_id"] = filter.SubscriptionID
+
+	if filter.Status != nil {
+		data["status"] = *filter.Status
+		wc = append(wc, "status = :status")
+	}
+
+	buf.WriteString(" WHERE ")
+	buf.WriteString(strings.Join(wc, " AND "))
+}
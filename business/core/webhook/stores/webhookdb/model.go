@@ -0,0 +1,134 @@
+package webhookdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx/dbarray"
+)
+
+// dbSubscription represent the structure we need for moving data
+// between the app and the database.
+type dbSubscription struct {
+	ID          uuid.UUID      `db:"subscription_id"`
+	Org         string         `db:"org"`
+	URL         string         `db:"url"`
+	Events      dbarray.String `db:"events"`
+	Secret      string         `db:"secret" log:"redact"`
+	Active      bool           `db:"active"`
+	CreatedBy   string         `db:"created_by"`
+	DateCreated time.Time      `db:"date_created"`
+	DateUpdated time.Time      `db:"date_updated"`
+}
+
+func toDBSubscription(sub webhook.Subscription) dbSubscription {
+	return dbSubscription{
+		ID:          sub.ID,
+		Org:         sub.Org,
+		URL:         sub.URL,
+		Events:      sub.Events,
+		Secret:      sub.Secret,
+		Active:      sub.Active,
+		CreatedBy:   sub.CreatedBy,
+		DateCreated: sub.DateCreated.UTC(),
+		DateUpdated: sub.DateUpdated.UTC(),
+	}
+}
+
+func toCoreSubscription(dbSub dbSubscription) webhook.Subscription {
+	return webhook.Subscription{
+		ID:          dbSub.ID,
+		Org:         dbSub.Org,
+		URL:         dbSub.URL,
+		Events:      dbSub.Events,
+		Secret:      dbSub.Secret,
+		Active:      dbSub.Active,
+		CreatedBy:   dbSub.CreatedBy,
+		DateCreated: dbSub.DateCreated.In(time.Local),
+		DateUpdated: dbSub.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreSubscriptionSlice(dbSubs []dbSubscription) []webhook.Subscription {
+	subs := make([]webhook.Subscription, len(dbSubs))
+	for i, dbSub := range dbSubs {
+		subs[i] = toCoreSubscription(dbSub)
+	}
+	return subs
+}
+
+// =============================================================================
+
+// dbDelivery represent the structure we need for moving data
+// between the app and the database.
+type dbDelivery struct {
+	ID             uuid.UUID    `db:"delivery_id"`
+	SubscriptionID uuid.UUID    `db:"subscription_id"`
+	Org            string       `db:"org"`
+	EventID        uuid.UUID    `db:"event_id"`
+	EventType      string       `db:"event_type"`
+	Payload        []byte       `db:"payload" log:"redact"`
+	Status         string       `db:"status"`
+	Attempts       int          `db:"attempts"`
+	LastStatusCode int          `db:"last_status_code"`
+	LastError      string       `db:"last_error"`
+	DateCreated    time.Time    `db:"date_created"`
+	DateUpdated    time.Time    `db:"date_updated"`
+	DateDelivered  sql.NullTime `db:"date_delivered"`
+}
+
+func toDBDelivery(dlv webhook.Delivery) dbDelivery {
+	dbDlv := dbDelivery{
+		ID:             dlv.ID,
+		SubscriptionID: dlv.SubscriptionID,
+		Org:            dlv.Org,
+		EventID:        dlv.EventID,
+		EventType:      dlv.EventType,
+		Payload:        dlv.Payload,
+		Status:         dlv.Status,
+		Attempts:       dlv.Attempts,
+		LastStatusCode: dlv.LastStatusCode,
+		LastError:      dlv.LastError,
+		DateCreated:    dlv.DateCreated.UTC(),
+		DateUpdated:    dlv.DateUpdated.UTC(),
+	}
+
+	if !dlv.DateDelivered.IsZero() {
+		dbDlv.DateDelivered = sql.NullTime{Time: dlv.DateDelivered.UTC(), Valid: true}
+	}
+
+	return dbDlv
+}
+
+func toCoreDelivery(dbDlv dbDelivery) webhook.Delivery {
+	dlv := webhook.Delivery{
+		ID:             dbDlv.ID,
+		SubscriptionID: dbDlv.SubscriptionID,
+		Org:            dbDlv.Org,
+		EventID:        dbDlv.EventID,
+		EventType:      dbDlv.EventType,
+		Payload:        dbDlv.Payload,
+		Status:         dbDlv.Status,
+		Attempts:       dbDlv.Attempts,
+		LastStatusCode: dbDlv.LastStatusCode,
+		LastError:      dbDlv.LastError,
+		DateCreated:    dbDlv.DateCreated.In(time.Local),
+		DateUpdated:    dbDlv.DateUpdated.In(time.Local),
+	}
+
+	if dbDlv.DateDelivered.Valid {
+		dlv.DateDelivered = dbDlv.DateDelivered.Time.In(time.Local)
+	}
+
+	return dlv
+}
+
+func toCoreDeliverySlice(dbDlvs []dbDelivery) []webhook.Delivery {
+	dlvs := make([]webhook.Delivery, len(dbDlvs))
+	for i, dbDlv := range dbDlvs {
+		dlvs[i] = toCoreDelivery(dbDlv)
+	}
+	return dlvs
+}
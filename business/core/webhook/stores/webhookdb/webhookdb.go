@@ -0,0 +1,350 @@
+// Package webhookdb contains webhook related CRUD functionality.
+package webhookdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Store manages the set of APIs for webhook database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (webhook.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// =============================================================================
+// Subscriptions
+
+// CreateSubscription inserts a new subscription into the database.
+func (s *Store) CreateSubscription(ctx context.Context, sub webhook.Subscription) error {
+	const q = `
+	INSERT INTO webhook_subscriptions
+		(subscription_id, org, url, events, secret, active, created_by, date_created, date_updated)
+	VALUES
+		(:subscription_id, :org, :url, :events, :secret, :active, :created_by, :date_created, :date_updated)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBSubscription(sub)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSubscription replaces a subscription document in the database.
+func (s *Store) UpdateSubscription(ctx context.Context, sub webhook.Subscription) error {
+	const q = `
+	UPDATE
+		webhook_subscriptions
+	SET
+		"url" = :url,
+		"events" = :events,
+		"secret" = :secret,
+		"active" = :active,
+		"date_updated" = :date_updated
+	WHERE
+		subscription_id = :subscription_id AND org = :org`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBSubscription(sub)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSubscription removes a subscription and its deliveries from the
+// database.
+func (s *Store) DeleteSubscription(ctx context.Context, sub webhook.Subscription) error {
+	data := struct {
+		ID  string `db:"subscription_id"`
+		Org string `db:"org"`
+	}{
+		ID:  sub.ID.String(),
+		Org: sub.Org,
+	}
+
+	const q = `
+	DELETE FROM
+		webhook_subscriptions
+	WHERE
+		subscription_id = :subscription_id AND org = :org`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QuerySubscriptions retrieves a list of existing subscriptions from the
+// database.
+func (s *Store) QuerySubscriptions(ctx context.Context, org string, pageNumber int, rowsPerPage int) ([]webhook.Subscription, error) {
+	data := map[string]any{
+		"org":           org,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhook_subscriptions
+	WHERE
+		org = :org
+	ORDER BY
+		date_created DESC, subscription_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var dbSubs []dbSubscription
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbSubs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreSubscriptionSlice(dbSubs), nil
+}
+
+// CountSubscriptions returns the total number of subscriptions in the DB.
+func (s *Store) CountSubscriptions(ctx context.Context, org string) (int, error) {
+	data := struct {
+		Org string `db:"org"`
+	}{
+		Org: org,
+	}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		webhook_subscriptions
+	WHERE
+		org = :org`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QuerySubscriptionByID gets the specified subscription from the database.
+func (s *Store) QuerySubscriptionByID(ctx context.Context, org string, subscriptionID uuid.UUID) (webhook.Subscription, error) {
+	data := struct {
+		ID  string `db:"subscription_id"`
+		Org string `db:"org"`
+	}{
+		ID:  subscriptionID.String(),
+		Org: org,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhook_subscriptions
+	WHERE
+		subscription_id = :subscription_id AND org = :org`
+
+	var dbSub dbSubscription
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbSub); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return webhook.Subscription{}, fmt.Errorf("namedquerystruct: %w", webhook.ErrNotFound)
+		}
+		return webhook.Subscription{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreSubscription(dbSub), nil
+}
+
+// QueryActiveSubscriptions retrieves the active subscriptions of the
+// organisation that receive the specified event type.
+func (s *Store) QueryActiveSubscriptions(ctx context.Context, org string, eventType string) ([]webhook.Subscription, error) {
+	data := struct {
+		Org       string `db:"org"`
+		EventType string `db:"event_type"`
+	}{
+		Org:       org,
+		EventType: eventType,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhook_subscriptions
+	WHERE
+		org = :org AND active AND :event_type = ANY(events)`
+
+	var dbSubs []dbSubscription
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbSubs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreSubscriptionSlice(dbSubs), nil
+}
+
+// =============================================================================
+// Deliveries
+
+// CreateDelivery inserts a new delivery into the database.
+func (s *Store) CreateDelivery(ctx context.Context, dlv webhook.Delivery) error {
+	const q = `
+	INSERT INTO webhook_deliveries
+		(delivery_id, subscription_id, org, event_id, event_type, payload, status, attempts, last_status_code, last_error, date_created, date_updated, date_delivered)
+	VALUES
+		(:delivery_id, :subscription_id, :org, :event_id, :event_type, CAST(:payload AS JSONB), :status, :attempts, :last_status_code, :last_error, :date_created, :date_updated, :date_delivered)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBDelivery(dlv)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateDelivery records the outcome of a delivery in the database.
+func (s *Store) UpdateDelivery(ctx context.Context, dlv webhook.Delivery) error {
+	const q = `
+	UPDATE
+		webhook_deliveries
+	SET
+		"status" = :status,
+		"attempts" = :attempts,
+		"last_status_code" = :last_status_code,
+		"last_error" = :last_error,
+		"date_updated" = :date_updated,
+		"date_delivered" = :date_delivered
+	WHERE
+		delivery_id = :delivery_id AND org = :org`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBDelivery(dlv)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryDeliveries retrieves a list of deliveries from the database.
+func (s *Store) QueryDeliveries(ctx context.Context, filter webhook.DeliveryFilter, pageNumber int, rowsPerPage int) ([]webhook.Delivery, error) {
+	data := map[string]any{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhook_deliveries`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	buf.WriteString(" ORDER BY date_created DESC, delivery_id")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbDlvs []dbDelivery
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbDlvs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreDeliverySlice(dbDlvs), nil
+}
+
+// CountDeliveries returns the total number of deliveries in the DB.
+func (s *Store) CountDeliveries(ctx context.Context, filter webhook.DeliveryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		webhook_deliveries`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryDeliveryByID gets the specified delivery from the database.
+func (s *Store) QueryDeliveryByID(ctx context.Context, org string, deliveryID uuid.UUID) (webhook.Delivery, error) {
+	data := struct {
+		ID  string `db:"delivery_id"`
+		Org string `db:"org"`
+	}{
+		ID:  deliveryID.String(),
+		Org: org,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		webhook_deliveries
+	WHERE
+		delivery_id = :delivery_id AND org = :org`
+
+	var dbDlv dbDelivery
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbDlv); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return webhook.Delivery{}, fmt.Errorf("namedquerystruct: %w", webhook.ErrDeliveryNotFound)
+		}
+		return webhook.Delivery{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreDelivery(dbDlv), nil
+}
+
+// EnqueueJob adds a job to the queue using the same connection, or
+// transaction, as the rest of the store.
+func (s *Store) EnqueueJob(ctx context.Context, nj queue.NewJob) (queue.Job, error) {
+	job, err := queue.Enqueue(ctx, s.log, s.db, nj)
+	if err != nil {
+		return queue.Job{}, fmt.Errorf("enqueue: %w", err)
+	}
+
+	return job, nil
+}
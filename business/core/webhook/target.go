@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrInvalidURL is returned when the URL of a subscription isn't an https
+// URL of a public host.
+var ErrInvalidURL = errors.New("url must be https to a public host")
+
+// blockedPrefixes are the address ranges a delivery must never reach: the
+// loopback, private, link-local, shared and reserved ranges, which includes
+// the cloud metadata endpoints and the addresses of the cluster.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// internalSuffixes are the host name suffixes that only resolve inside the
+// network of the service.
+var internalSuffixes = []string{".localhost", ".local", ".internal", ".cluster.local"}
+
+// publicAddr reports whether the address is outside of the blocked ranges.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkURL validates the URL of a subscription. It must be https, and its
+// host must not be an internal name or resolve to a blocked address. The
+// same addresses are blocked again when the delivery is sent, since what a
+// name resolves to can change after it was checked.
+func checkURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parse: %w", ErrInvalidURL)
+	}
+
+	if u.Scheme != "https" || u.User != nil {
+		return fmt.Errorf("scheme %q: %w", u.Scheme, ErrInvalidURL)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("no host: %w", ErrInvalidURL)
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(addr) {
+			return fmt.Errorf("host %q: %w", host, ErrInvalidURL)
+		}
+		return nil
+	}
+
+	// A name without a dot is resolved with the search domains of the
+	// cluster, so it names an internal service.
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return fmt.Errorf("host %q: %w", host, ErrInvalidURL)
+	}
+
+	for _, suffix := range internalSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return fmt.Errorf("host %q: %w", host, ErrInvalidURL)
+		}
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("lookup %q: %w", host, ErrInvalidURL)
+	}
+
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return fmt.Errorf("host %q resolves to %s: %w", host, addr, ErrInvalidURL)
+		}
+	}
+
+	return nil
+}
+
+// dialControl refuses the connections to a blocked address. It runs after
+// the name was resolved, so a host that resolved to a public address when
+// the subscription was checked can't be pointed at an internal one later.
+func dialControl(network string, address string, c syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", address, err)
+	}
+
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("dial %s: %w", address, ErrInvalidURL)
+	}
+
+	return nil
+}
+
+// newClient constructs the client that sends the deliveries. It dials with
+// the control, goes to the subscriber directly rather than through a proxy,
+// and doesn't follow redirects, so a subscriber can't send a delivery on to
+// an internal address.
+func newClient(control func(network string, address string, c syscall.RawConn) error) *http.Client {
+	dialer := net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: control,
+	}
+
+	transport := http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	client := http.Client{
+		Transport: &transport,
+		Timeout:   10 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &client
+}
@@ -0,0 +1,393 @@
+// Package webhook provides the core business API for outbound webhooks.
+// Organisations subscribe endpoints to events, and every event is captured as
+// a delivery in the same transaction as the change that caused it. The
+// deliveries are sent by the job queue, signed with the secret of the
+// subscription, and retried with backoff until they succeed or run out of
+// attempts.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// JobDeliver is the kind of the queued job that sends a delivery.
+const JobDeliver = "webhook.deliver"
+
+// deliverAttempts is the number of times a delivery is attempted. With the
+// default backoff of the queue this spans roughly half a day.
+const deliverAttempts = 10
+
+// Set of statuses a delivery moves through.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound         = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrUnknownEvent     = errors.New("unknown event type")
+	ErrReplayPending    = errors.New("delivery is still pending")
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+
+	CreateSubscription(ctx context.Context, sub Subscription) error
+	UpdateSubscription(ctx context.Context, sub Subscription) error
+	DeleteSubscription(ctx context.Context, sub Subscription) error
+	QuerySubscriptions(ctx context.Context, org string, pageNumber int, rowsPerPage int) ([]Subscription, error)
+	CountSubscriptions(ctx context.Context, org string) (int, error)
+	QuerySubscriptionByID(ctx context.Context, org string, subscriptionID uuid.UUID) (Subscription, error)
+	QueryActiveSubscriptions(ctx context.Context, org string, eventType string) ([]Subscription, error)
+
+	CreateDelivery(ctx context.Context, dlv Delivery) error
+	UpdateDelivery(ctx context.Context, dlv Delivery) error
+	QueryDeliveries(ctx context.Context, filter DeliveryFilter, pageNumber int, rowsPerPage int) ([]Delivery, error)
+	CountDeliveries(ctx context.Context, filter DeliveryFilter) (int, error)
+	QueryDeliveryByID(ctx context.Context, org string, deliveryID uuid.UUID) (Delivery, error)
+
+	EnqueueJob(ctx context.Context, nj queue.NewJob) (queue.Job, error)
+}
+
+// Core manages the set of APIs for webhook access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+	client *http.Client
+}
+
+// NewCore constructs a core for webhook api access.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+		client: newClient(dialControl),
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	storer, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Core{
+		log:    c.log,
+		storer: storer,
+		client: c.client,
+	}, nil
+}
+
+// =============================================================================
+// Subscriptions
+
+// CreateSubscription adds a new subscription to the system with a freshly
+// generated signing secret.
+func (c *Core) CreateSubscription(ctx context.Context, ns NewSubscription) (Subscription, error) {
+	if err := checkEvents(ns.Events); err != nil {
+		return Subscription{}, err
+	}
+
+	if err := checkURL(ctx, ns.URL); err != nil {
+		return Subscription{}, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return Subscription{}, fmt.Errorf("generate: %w", err)
+	}
+
+	now := time.Now()
+
+	sub := Subscription{
+		ID:          uuid.New(),
+		Org:         ns.Org,
+		URL:         ns.URL,
+		Events:      ns.Events,
+		Secret:      secret,
+		Active:      true,
+		CreatedBy:   ns.CreatedBy,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.storer.CreateSubscription(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("create: %w", err)
+	}
+
+	return sub, nil
+}
+
+// UpdateSubscription modifies information about a subscription.
+func (c *Core) UpdateSubscription(ctx context.Context, sub Subscription, us UpdateSubscription) (Subscription, error) {
+	if us.URL != nil {
+		if err := checkURL(ctx, *us.URL); err != nil {
+			return Subscription{}, err
+		}
+		sub.URL = *us.URL
+	}
+
+	if us.Events != nil {
+		if err := checkEvents(us.Events); err != nil {
+			return Subscription{}, err
+		}
+		sub.Events = us.Events
+	}
+
+	if us.Active != nil {
+		sub.Active = *us.Active
+	}
+
+	sub.DateUpdated = time.Now()
+
+	if err := c.storer.UpdateSubscription(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("update: %w", err)
+	}
+
+	return sub, nil
+}
+
+// RotateSecret replaces the signing secret of the subscription.
+func (c *Core) RotateSecret(ctx context.Context, sub Subscription) (Subscription, error) {
+	secret, err := generateSecret()
+	if err != nil {
+		return Subscription{}, fmt.Errorf("generate: %w", err)
+	}
+
+	sub.Secret = secret
+	sub.DateUpdated = time.Now()
+
+	if err := c.storer.UpdateSubscription(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("update: %w", err)
+	}
+
+	return sub, nil
+}
+
+// DeleteSubscription removes the subscription and its delivery log.
+func (c *Core) DeleteSubscription(ctx context.Context, sub Subscription) error {
+	if err := c.storer.DeleteSubscription(ctx, sub); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// QuerySubscriptions retrieves a list of existing subscriptions.
+func (c *Core) QuerySubscriptions(ctx context.Context, org string, pageNumber int, rowsPerPage int) ([]Subscription, error) {
+	subs, err := c.storer.QuerySubscriptions(ctx, org, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return subs, nil
+}
+
+// CountSubscriptions returns the total number of subscriptions.
+func (c *Core) CountSubscriptions(ctx context.Context, org string) (int, error) {
+	return c.storer.CountSubscriptions(ctx, org)
+}
+
+// QuerySubscriptionByID finds the subscription by the specified ID within
+// the organisation.
+func (c *Core) QuerySubscriptionByID(ctx context.Context, org string, subscriptionID uuid.UUID) (Subscription, error) {
+	sub, err := c.storer.QuerySubscriptionByID(ctx, org, subscriptionID)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("query: subscriptionID[%s]: %w", subscriptionID, err)
+	}
+
+	return sub, nil
+}
+
+// =============================================================================
+// Deliveries
+
+// Capture records a delivery of the event for every active subscription of
+// the organisation that subscribes to it, and queues the jobs that send them.
+// It must be called with a core bound to the transaction of the change that
+// caused the event, so events of rolled back changes are never sent.
+func (c *Core) Capture(ctx context.Context, evt Event) error {
+	if !eventTypes[evt.Type] {
+		return fmt.Errorf("type %q: %w", evt.Type, ErrUnknownEvent)
+	}
+
+	subs, err := c.storer.QueryActiveSubscriptions(ctx, evt.Org, evt.Type)
+	if err != nil {
+		return fmt.Errorf("query subscriptions: %w", err)
+	}
+
+	if len(subs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	eventID := uuid.New()
+
+	body := struct {
+		ID         uuid.UUID `json:"id"`
+		Type       string    `json:"type"`
+		Org        string    `json:"org"`
+		OccurredAt string    `json:"occurredAt"`
+		Data       any       `json:"data"`
+	}{
+		ID:         eventID,
+		Type:       evt.Type,
+		Org:        evt.Org,
+		OccurredAt: now.UTC().Format(time.RFC3339),
+		Data:       evt.Data,
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	for _, sub := range subs {
+		dlv := Delivery{
+			ID:             uuid.New(),
+			SubscriptionID: sub.ID,
+			Org:            sub.Org,
+			EventID:        eventID,
+			EventType:      evt.Type,
+			Payload:        payload,
+			Status:         DeliveryPending,
+			DateCreated:    now,
+			DateUpdated:    now,
+		}
+
+		if err := c.storer.CreateDelivery(ctx, dlv); err != nil {
+			return fmt.Errorf("create delivery: subscriptionID[%s]: %w", sub.ID, err)
+		}
+
+		if err := c.enqueue(ctx, dlv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// QueryDeliveries retrieves the delivery log of a subscription.
+func (c *Core) QueryDeliveries(ctx context.Context, filter DeliveryFilter, pageNumber int, rowsPerPage int) ([]Delivery, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	dlvs, err := c.storer.QueryDeliveries(ctx, filter, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return dlvs, nil
+}
+
+// CountDeliveries returns the total number of deliveries.
+func (c *Core) CountDeliveries(ctx context.Context, filter DeliveryFilter) (int, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, err
+	}
+
+	return c.storer.CountDeliveries(ctx, filter)
+}
+
+// QueryDeliveryByID finds the delivery by the specified ID within the
+// organisation.
+func (c *Core) QueryDeliveryByID(ctx context.Context, org string, deliveryID uuid.UUID) (Delivery, error) {
+	dlv, err := c.storer.QueryDeliveryByID(ctx, org, deliveryID)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("query: deliveryID[%s]: %w", deliveryID, err)
+	}
+
+	return dlv, nil
+}
+
+// Replay sends a delivery again with its original payload. Run this under a
+// transaction so the status change and the job are committed together.
+func (c *Core) Replay(ctx context.Context, dlv Delivery) (Delivery, error) {
+	if dlv.Status == DeliveryPending {
+		return Delivery{}, ErrReplayPending
+	}
+
+	dlv.Status = DeliveryPending
+	dlv.LastError = ""
+	dlv.DateUpdated = time.Now()
+
+	if err := c.storer.UpdateDelivery(ctx, dlv); err != nil {
+		return Delivery{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.enqueue(ctx, dlv); err != nil {
+		return Delivery{}, err
+	}
+
+	return dlv, nil
+}
+
+// =============================================================================
+
+// deliverPayload is the payload of a JobDeliver job.
+type deliverPayload struct {
+	Org        string    `json:"org"`
+	DeliveryID uuid.UUID `json:"deliveryId"`
+}
+
+func (c *Core) enqueue(ctx context.Context, dlv Delivery) error {
+	nj := queue.NewJob{
+		Kind: JobDeliver,
+		Payload: deliverPayload{
+			Org:        dlv.Org,
+			DeliveryID: dlv.ID,
+		},
+		MaxAttempts: deliverAttempts,
+	}
+
+	if _, err := c.storer.EnqueueJob(ctx, nj); err != nil {
+		return fmt.Errorf("enqueue: deliveryID[%s]: %w", dlv.ID, err)
+	}
+
+	return nil
+}
+
+// checkEvents validates the event types of a subscription.
+func checkEvents(events []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("no events: %w", ErrUnknownEvent)
+	}
+
+	for _, e := range events {
+		if !eventTypes[e] {
+			return fmt.Errorf("type %q: %w", e, ErrUnknownEvent)
+		}
+	}
+
+	return nil
+}
+
+// generateSecret creates a random signing secret.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,229 @@
+package webhook_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/webhook"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+func Test_Sign(t *testing.T) {
+	secret := "whsec_test"
+	ts := time.Unix(1700000000, 0)
+	payload := []byte(`{"type":"page.published"}`)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("1700000000." + string(payload)))
+	exp := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := webhook.Sign(secret, ts, payload); got != exp {
+		t.Fatalf("Should sign the timestamp and payload: got %q, exp %q", got, exp)
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		ts      time.Time
+		payload []byte
+	}{
+		{name: "other secret", secret: "whsec_other", ts: ts, payload: payload},
+		{name: "other timestamp", secret: secret, ts: ts.Add(time.Second), payload: payload},
+		{name: "other payload", secret: secret, ts: ts, payload: []byte(`{"type":"page.unpublished"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhook.Sign(tt.secret, tt.ts, tt.payload); got == exp {
+				t.Fatal("Should change the signature")
+			}
+		})
+	}
+}
+
+func Test_HandleDeliveryJob(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		attempt    int
+		active     bool
+		expErr     bool
+		expStatus  string
+		expPerm    bool
+		expRequest bool
+	}{
+		{name: "delivered", statusCode: http.StatusNoContent, attempt: 1, active: true, expStatus: webhook.DeliverySucceeded, expRequest: true},
+		{name: "retried", statusCode: http.StatusBadGateway, attempt: 1, active: true, expErr: true, expStatus: webhook.DeliveryPending, expRequest: true},
+		{name: "failed on last attempt", statusCode: http.StatusBadGateway, attempt: 10, active: true, expErr: true, expStatus: webhook.DeliveryFailed, expRequest: true},
+		{name: "inactive subscription", attempt: 1, active: false, expErr: true, expPerm: true, expStatus: webhook.DeliveryFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu  sync.Mutex
+				req *http.Request
+				got []byte
+			)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				req = r
+				got, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer srv.Close()
+
+			sub := webhook.Subscription{ID: uuid.New(), Org: "sample", URL: srv.URL, Secret: "whsec_test", Active: tt.active}
+			dlv := webhook.Delivery{
+				ID:             uuid.New(),
+				SubscriptionID: sub.ID,
+				Org:            "sample",
+				EventType:      webhook.EventPagePublished,
+				Payload:        json.RawMessage(`{"type":"page.published"}`),
+				Status:         webhook.DeliveryPending,
+			}
+
+			store := newStore(sub, dlv)
+			core := webhook.NewTestCore(logger.New(io.Discard, logger.LevelInfo, "TEST", nil), store)
+
+			payload, _ := json.Marshal(map[string]any{"org": dlv.Org, "deliveryId": dlv.ID})
+			job := queue.Job{Kind: webhook.JobDeliver, Payload: payload, Attempt: tt.attempt, MaxAttempts: 10}
+
+			err := core.HandleDeliveryJob(context.Background(), job)
+			if (err != nil) != tt.expErr {
+				t.Fatalf("Should return an error %t: got %v", tt.expErr, err)
+			}
+
+			if tt.expPerm && !queue.IsPermanent(err) {
+				t.Fatalf("Should not retry: got %v", err)
+			}
+
+			if s := store.delivery().Status; s != tt.expStatus {
+				t.Fatalf("Should leave the delivery %s: got %s", tt.expStatus, s)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !tt.expRequest {
+				if req != nil {
+					t.Fatal("Should not post the delivery")
+				}
+				return
+			}
+
+			if req == nil {
+				t.Fatal("Should post the delivery")
+			}
+
+			if string(got) != string(dlv.Payload) {
+				t.Fatalf("Should post the payload: got %s", got)
+			}
+
+			if h := req.Header.Get(webhook.HeaderDelivery); h != dlv.ID.String() {
+				t.Fatalf("Should send the delivery id: got %q", h)
+			}
+
+			if !verify(req.Header.Get(webhook.HeaderSignature), sub.Secret, got) {
+				t.Fatalf("Should sign the payload with the secret: got %q", req.Header.Get(webhook.HeaderSignature))
+			}
+		})
+	}
+}
+
+// verify checks a signature header the way a receiver would.
+func verify(header string, secret string, payload []byte) bool {
+	parts := strings.Split(header, ",")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "t=") || !strings.HasPrefix(parts[1], "v1=") {
+		return false
+	}
+
+	ts, err := strconv.ParseInt(strings.TrimPrefix(parts[0], "t="), 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + "."))
+	mac.Write(payload)
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(parts[1], "v1="))
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// =============================================================================
+
+// store is an in-memory store holding one subscription and one delivery.
+type store struct {
+	webhook.Storer
+
+	mu  sync.Mutex
+	sub webhook.Subscription
+	dlv webhook.Delivery
+}
+
+func newStore(sub webhook.Subscription, dlv webhook.Delivery) *store {
+	return &store{sub: sub, dlv: dlv}
+}
+
+func (s *store) delivery() webhook.Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dlv
+}
+
+func (s *store) QuerySubscriptionByID(ctx context.Context, org string, subscriptionID uuid.UUID) (webhook.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if subscriptionID != s.sub.ID {
+		return webhook.Subscription{}, webhook.ErrNotFound
+	}
+
+	return s.sub, nil
+}
+
+func (s *store) QueryDeliveryByID(ctx context.Context, org string, deliveryID uuid.UUID) (webhook.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if deliveryID != s.dlv.ID {
+		return webhook.Delivery{}, webhook.ErrDeliveryNotFound
+	}
+
+	return s.dlv, nil
+}
+
+func (s *store) UpdateDelivery(ctx context.Context, dlv webhook.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dlv.ID != s.dlv.ID {
+		return errors.New("unknown delivery")
+	}
+
+	s.dlv = dlv
+	return nil
+}
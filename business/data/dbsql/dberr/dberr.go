@@ -0,0 +1,162 @@
+// Package dberr provides the classification of PostgreSQL errors shared by
+// the database drivers. A driver error is turned into an Error that keeps
+// the details Postgres reports, such as the constraint or column involved,
+// and matches one of the kinds below with errors.Is.
+package dberr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Set of error kinds a database error is classified as.
+var (
+	ErrDuplicatedEntry      = errors.New("duplicated entry")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrCheckViolation       = errors.New("check violation")
+	ErrNotNullViolation     = errors.New("not null violation")
+	ErrSerializationFailure = errors.New("serialization failure")
+	ErrDeadlock             = errors.New("deadlock detected")
+	ErrLockTimeout          = errors.New("lock timeout")
+	ErrStatementTimeout     = errors.New("statement timeout")
+	ErrUnavailable          = errors.New("database unavailable")
+	ErrUndefinedTable       = errors.New("undefined table")
+)
+
+// codes maps the SQLSTATE codes to their kind. Codes that aren't listed are
+// classified by their class in kindOf.
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+var codes = map[string]error{
+	"23505": ErrDuplicatedEntry,
+	"23503": ErrForeignKeyViolation,
+	"23514": ErrCheckViolation,
+	"23502": ErrNotNullViolation,
+	"40001": ErrSerializationFailure,
+	"40P01": ErrDeadlock,
+	"55P03": ErrLockTimeout,
+	"57014": ErrStatementTimeout,
+	"42P01": ErrUndefinedTable,
+	"57P01": ErrUnavailable,
+	"57P02": ErrUnavailable,
+	"57P03": ErrUnavailable,
+	"53300": ErrUnavailable,
+}
+
+// Error represents a classified database error.
+type Error struct {
+	Kind       error
+	Code       string
+	Message    string
+	Detail     string
+	Table      string
+	Column     string
+	Constraint string
+	Err        error
+}
+
+// New classifies the driver error by its SQLSTATE code. It returns nil when
+// the code isn't one of the known kinds, in which case the caller should
+// return the driver error as is.
+func New(err error, code string, message string, detail string, table string, column string, constraint string) *Error {
+	kind := kindOf(code)
+	if kind == nil {
+		return nil
+	}
+
+	return &Error{
+		Kind:       kind,
+		Code:       code,
+		Message:    message,
+		Detail:     detail,
+		Table:      table,
+		Column:     column,
+		Constraint: constraint,
+		Err:        err,
+	}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Kind.Error())
+
+	if e.Constraint != "" {
+		fmt.Fprintf(&b, ": constraint[%s]", e.Constraint)
+	}
+
+	if e.Column != "" {
+		fmt.Fprintf(&b, ": column[%s]", e.Column)
+	}
+
+	fmt.Fprintf(&b, ": %s (SQLSTATE %s)", e.Message, e.Code)
+
+	return b.String()
+}
+
+// Unwrap returns the kind and the driver error so both can be matched with
+// errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// SQLState returns the SQLSTATE code of the error.
+func (e *Error) SQLState() string {
+	return e.Code
+}
+
+// Retryable reports whether running the transaction again may succeed.
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case ErrSerializationFailure, ErrDeadlock, ErrLockTimeout:
+		return true
+	}
+
+	return false
+}
+
+// =============================================================================
+
+// IsRetryable reports whether the error is a database error after which the
+// transaction can be run again.
+func IsRetryable(err error) bool {
+	var dbErr *Error
+	if !errors.As(err, &dbErr) {
+		return false
+	}
+
+	return dbErr.Retryable()
+}
+
+// IsConflict reports whether the error is a constraint violation caused by
+// the state of other rows.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrDuplicatedEntry) || errors.Is(err, ErrForeignKeyViolation)
+}
+
+// IsInvalid reports whether the error is a constraint violation caused by
+// the values being written.
+func IsInvalid(err error) bool {
+	return errors.Is(err, ErrCheckViolation) || errors.Is(err, ErrNotNullViolation)
+}
+
+// IsUnavailable reports whether the error is temporary, because of
+// contention, a timeout or the database not accepting work.
+func IsUnavailable(err error) bool {
+	return IsRetryable(err) || errors.Is(err, ErrStatementTimeout) || errors.Is(err, ErrUnavailable)
+}
+
+// =============================================================================
+
+func kindOf(code string) error {
+	if kind, ok := codes[code]; ok {
+		return kind
+	}
+
+	// Class 08 is connection exceptions.
+	if strings.HasPrefix(code, "08") {
+		return ErrUnavailable
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package dberr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/dberr"
+)
+
+func Test_Classify(t *testing.T) {
+	tests := []struct {
+		code        string
+		kind        error
+		conflict    bool
+		invalid     bool
+		unavailable bool
+		retryable   bool
+	}{
+		{code: "23505", kind: dberr.ErrDuplicatedEntry, conflict: true},
+		{code: "23503", kind: dberr.ErrForeignKeyViolation, conflict: true},
+		{code: "23514", kind: dberr.ErrCheckViolation, invalid: true},
+		{code: "23502", kind: dberr.ErrNotNullViolation, invalid: true},
+		{code: "40001", kind: dberr.ErrSerializationFailure, unavailable: true, retryable: true},
+		{code: "40P01", kind: dberr.ErrDeadlock, unavailable: true, retryable: true},
+		{code: "55P03", kind: dberr.ErrLockTimeout, unavailable: true, retryable: true},
+		{code: "57014", kind: dberr.ErrStatementTimeout, unavailable: true},
+		{code: "57P01", kind: dberr.ErrUnavailable, unavailable: true},
+		{code: "53300", kind: dberr.ErrUnavailable, unavailable: true},
+		{code: "08006", kind: dberr.ErrUnavailable, unavailable: true},
+		{code: "42P01", kind: dberr.ErrUndefinedTable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			pgErr := &pgconn.PgError{Code: tt.code, Message: "failed"}
+
+			dbErr := dberr.New(pgErr, pgErr.Code, pgErr.Message, "", "", "", "")
+			if dbErr == nil {
+				t.Fatal("Should classify the error")
+			}
+
+			// Stores wrap the errors they return.
+			err := fmt.Errorf("create: %w", dbErr)
+
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("Should match kind %v: got %v", tt.kind, err)
+			}
+
+			var target *pgconn.PgError
+			if !errors.As(err, &target) || target.Code != tt.code {
+				t.Fatal("Should keep the driver error")
+			}
+
+			if got := dberr.IsConflict(err); got != tt.conflict {
+				t.Fatalf("Should get conflict %t: got %t", tt.conflict, got)
+			}
+
+			if got := dberr.IsInvalid(err); got != tt.invalid {
+				t.Fatalf("Should get invalid %t: got %t", tt.invalid, got)
+			}
+
+			if got := dberr.IsUnavailable(err); got != tt.unavailable {
+				t.Fatalf("Should get unavailable %t: got %t", tt.unavailable, got)
+			}
+
+			if got := dberr.IsRetryable(err); got != tt.retryable {
+				t.Fatalf("Should get retryable %t: got %t", tt.retryable, got)
+			}
+		})
+	}
+}
+
+func Test_ClassifyUnknown(t *testing.T) {
+	for _, code := range []string{"42601", "22P02", ""} {
+		if dbErr := dberr.New(errors.New("failed"), code, "failed", "", "", "", ""); dbErr != nil {
+			t.Fatalf("code %q: Should leave the error unclassified: got %v", code, dbErr)
+		}
+	}
+
+	err := errors.New("not a database error")
+	if dberr.IsConflict(err) || dberr.IsInvalid(err) || dberr.IsUnavailable(err) || dberr.IsRetryable(err) {
+		t.Fatal("Should not classify other errors")
+	}
+}
+
+func Test_ErrorMessage(t *testing.T) {
+	pgErr := &pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	dbErr := dberr.New(pgErr, pgErr.Code, pgErr.Message, "Key (name)=(x) already exists.", "pages", "name", "pages_name_key")
+
+	exp := "duplicated entry: constraint[pages_name_key]: column[name]: duplicate key value violates unique constraint (SQLSTATE 23505)"
+	if got := dbErr.Error(); got != exp {
+		t.Fatalf("Should describe the error:\ngot %s\nexp %s", got, exp)
+	}
+
+	if dbErr.SQLState() != "23505" {
+		t.Fatalf("Should report the SQLSTATE: got %s", dbErr.SQLState())
+	}
+}
@@ -0,0 +1,74 @@
+package dbmetrics
+
+import (
+	"expvar"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector exposes the query metrics to prometheus. The values are read
+// from the expvar metrics on every scrape, so both report the same numbers.
+type Collector struct {
+	queries *prometheus.Desc
+	errors  *prometheus.Desc
+	slow    *prometheus.Desc
+	latency *prometheus.Desc
+	rows    *prometheus.Desc
+}
+
+// NewCollector constructs a collector for the query metrics.
+func NewCollector() *Collector {
+	labels := []string{"query"}
+
+	return &Collector{
+		queries: prometheus.NewDesc("db_queries_total", "Number of queries run.", labels, nil),
+		errors:  prometheus.NewDesc("db_query_errors_total", "Number of queries that failed.", labels, nil),
+		slow:    prometheus.NewDesc("db_slow_queries_total", "Number of queries over the slow query threshold.", labels, nil),
+		latency: prometheus.NewDesc("db_query_duration_seconds", "Latency of the queries.", labels, nil),
+		rows:    prometheus.NewDesc("db_query_rows", "Number of rows returned or affected by the queries.", labels, nil),
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.queries
+	ch <- c.errors
+	ch <- c.slow
+	ch <- c.latency
+	ch <- c.rows
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	counters(ch, c.queries, dm.queries)
+	counters(ch, c.errors, dm.errors)
+	counters(ch, c.slow, dm.slow)
+	histograms(ch, c.latency, dm.latency)
+	histograms(ch, c.rows, dm.rows)
+}
+
+func counters(ch chan<- prometheus.Metric, desc *prometheus.Desc, m *expvar.Map) {
+	m.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(v.Value()), kv.Key)
+		}
+	})
+}
+
+func histograms(ch chan<- prometheus.Metric, desc *prometheus.Desc, m *expvar.Map) {
+	m.Do(func(kv expvar.KeyValue) {
+		h, ok := kv.Value.(*Histogram)
+		if !ok {
+			return
+		}
+
+		bounds, cumulative, count, sum := h.Snapshot()
+
+		buckets := make(map[float64]uint64, len(bounds))
+		for i, bound := range bounds {
+			buckets[bound] = cumulative[i]
+		}
+
+		ch <- prometheus.MustNewConstHistogram(desc, count, sum, buckets, kv.Key)
+	})
+}
@@ -0,0 +1,195 @@
+// Package dbmetrics provides the query metrics shared by the database
+// drivers. The metrics are published through expvar and are keyed by the
+// name of the query, which is the function that ran it. They are also
+// recorded through open telemetry.
+package dbmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Set of bucket bounds for the histograms.
+var (
+	LatencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	RowsBuckets    = []float64{0, 1, 10, 100, 1000, 10000}
+)
+
+// This holds the metrics for the queries. The expvar package is based on a
+// singleton so these are registered once for the process.
+var dm = struct {
+	queries *expvar.Map
+	errors  *expvar.Map
+	slow    *expvar.Map
+	latency *expvar.Map
+	rows    *expvar.Map
+}{
+	queries: expvar.NewMap("db_queries"),
+	errors:  expvar.NewMap("db_query_errors"),
+	slow:    expvar.NewMap("db_slow_queries"),
+	latency: expvar.NewMap("db_query_seconds"),
+	rows:    expvar.NewMap("db_query_rows"),
+}
+
+// This holds the open telemetry instruments for the queries. They are created
+// from the global meter provider, which hands the values to the provider set
+// at startup.
+var om struct {
+	duration metric.Float64Histogram
+	rows     metric.Int64Histogram
+}
+
+func init() {
+	meter := otel.Meter("github.com/vikaskumar1187/publisher_saas/business/data/dbsql/dbmetrics")
+
+	var errs [2]error
+	om.duration, errs[0] = meter.Float64Histogram("db.client.operation.duration",
+		metric.WithDescription("Latency of the queries."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(LatencyBuckets...),
+	)
+	om.rows, errs[1] = meter.Int64Histogram("db.client.response.rows",
+		metric.WithDescription("Number of rows returned or affected by the queries."),
+		metric.WithUnit("{row}"),
+		metric.WithExplicitBucketBoundaries(RowsBuckets...),
+	)
+	if err := errors.Join(errs[:]...); err != nil {
+		otel.Handle(err)
+	}
+}
+
+// mu serializes the creation of the histograms of a new query name.
+var mu sync.Mutex
+
+// Observe records the outcome of a query. The context links the values to
+// the trace the query ran in.
+func Observe(ctx context.Context, name string, d time.Duration, rows int64, failed bool, slow bool) {
+	dm.queries.Add(name, 1)
+
+	if failed {
+		dm.errors.Add(name, 1)
+	}
+
+	if slow {
+		dm.slow.Add(name, 1)
+	}
+
+	histogram(dm.latency, name, LatencyBuckets).Observe(d.Seconds())
+	histogram(dm.rows, name, RowsBuckets).Observe(float64(rows))
+
+	attrs := metric.WithAttributes(
+		attribute.String("db.query.name", name),
+		attribute.Bool("error", failed),
+	)
+	om.duration.Record(ctx, d.Seconds(), attrs)
+	om.rows.Record(ctx, rows, attrs)
+}
+
+func histogram(m *expvar.Map, name string, bounds []float64) *Histogram {
+	if h, ok := m.Get(name).(*Histogram); ok {
+		return h
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if h, ok := m.Get(name).(*Histogram); ok {
+		return h
+	}
+
+	h := NewHistogram(bounds)
+	m.Set(name, h)
+
+	return h
+}
+
+// =============================================================================
+
+// Histogram counts the observed values into buckets. It implements the
+// expvar.Var interface.
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram constructs a histogram with the upper bounds of the buckets,
+// in increasing order.
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)),
+	}
+}
+
+// Observe adds the value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+
+	h.count++
+	h.sum += v
+}
+
+// Snapshot returns the upper bounds with the cumulative count of every
+// bucket, the number of values and their sum.
+func (h *Histogram) Snapshot() (bounds []float64, cumulative []uint64, count uint64, sum float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cumulative = make([]uint64, len(h.counts))
+
+	var total uint64
+	for i, c := range h.counts {
+		total += c
+		cumulative[i] = total
+	}
+
+	return h.bounds, cumulative, h.count, h.sum
+}
+
+// String implements the expvar.Var interface.
+func (h *Histogram) String() string {
+	bounds, cumulative, count, sum := h.Snapshot()
+
+	buckets := make(map[string]uint64, len(bounds)+1)
+	for i, bound := range bounds {
+		buckets[strconv.FormatFloat(bound, 'g', -1, 64)] = cumulative[i]
+	}
+	buckets["+Inf"] = count
+
+	doc := struct {
+		Count   uint64            `json:"count"`
+		Sum     float64           `json:"sum"`
+		Buckets map[string]uint64 `json:"buckets"`
+	}{
+		Count:   count,
+		Sum:     sum,
+		Buckets: buckets,
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "{}"
+	}
+
+	return string(data)
+}
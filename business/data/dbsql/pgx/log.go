@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// redacted replaces the value of a sensitive parameter in a logged query.
+const redacted = "'[REDACTED]'"
+
+// LogMode determines how the parameters of a query are logged.
+type LogMode int
+
+// Set of modes the queries can be logged in.
+const (
+	// LogValues inlines the parameters into the query, except the sensitive
+	// ones which are redacted.
+	LogValues LogMode = iota
+
+	// LogPlaceholders logs the query with its named placeholders only.
+	LogPlaceholders
+)
+
+// ParseLogMode parses the string value and returns a log mode if one exists.
+func ParseLogMode(value string) (LogMode, error) {
+	switch strings.ToLower(value) {
+	case "values":
+		return LogValues, nil
+	case "placeholders":
+		return LogPlaceholders, nil
+	}
+
+	return LogValues, fmt.Errorf("invalid log mode %q", value)
+}
+
+// LogPolicy controls what is logged and added to the spans for a query. A
+// parameter is sensitive when its struct field is tagged `log:"redact"` or
+// its name is one of the sensitive columns. Queries that take longer than the
+// slow threshold are logged at Warn, a zero threshold turns this off.
+type LogPolicy struct {
+	Mode             LogMode
+	SensitiveColumns []string
+	MaxLength        int
+	Level            logger.Level
+	SlowThreshold    time.Duration
+}
+
+// DefaultSensitiveColumns is the list of columns redacted when no other list
+// is configured.
+var DefaultSensitiveColumns = []string{"email", "password", "password_hash", "secret", "token", "key_hash", "content"}
+
+type logPolicy struct {
+	mode          LogMode
+	sensitive     map[string]bool
+	maxLength     int
+	level         logger.Level
+	slowThreshold time.Duration
+}
+
+var policy atomic.Pointer[logPolicy]
+
+func init() {
+	SetLogPolicy(LogPolicy{
+		Mode:             LogValues,
+		SensitiveColumns: DefaultSensitiveColumns,
+		Level:            logger.LevelInfo,
+		SlowThreshold:    500 * time.Millisecond,
+	})
+}
+
+// SetLogPolicy replaces the policy the queries are logged with. It is meant
+// to be called once at startup.
+func SetLogPolicy(lp LogPolicy) {
+	sensitive := make(map[string]bool, len(lp.SensitiveColumns))
+	for _, column := range lp.SensitiveColumns {
+		sensitive[strings.ToLower(strings.TrimSpace(column))] = true
+	}
+
+	policy.Store(&logPolicy{
+		mode:          lp.Mode,
+		sensitive:     sensitive,
+		maxLength:     lp.MaxLength,
+		level:         lp.Level,
+		slowThreshold: lp.SlowThreshold,
+	})
+}
+
+// =============================================================================
+
+type ctxKey int
+
+const levelKey ctxKey = 1
+
+// WithLogLevel returns a context that logs the queries run with it at the
+// level instead of the level of the policy. Use it for chatty queries, such
+// as the ones run by the background workers, to log them at Debug.
+func WithLogLevel(ctx context.Context, level logger.Level) context.Context {
+	return context.WithValue(ctx, levelKey, level)
+}
+
+// logQuery logs the query at the level of the call or the policy. The caller
+// is the number of frames above logQuery to report the source of.
+func logQuery(ctx context.Context, log *logger.Logger, caller int, msg string, q string) {
+	level := policy.Load().level
+	if v, ok := ctx.Value(levelKey).(logger.Level); ok {
+		level = v
+	}
+
+	switch level {
+	case logger.LevelDebug:
+		log.Debugc(ctx, caller+1, msg, "query", q)
+	default:
+		log.Infoc(ctx, caller+1, msg, "query", q)
+	}
+}
+
+// =============================================================================
+
+// mapper matches the fields of a struct to the named parameters the same way
+// sqlx binds them.
+var mapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+// queryString provides a pretty print version of the query and parameters,
+// with the sensitive parameters redacted, following the log policy.
+func queryString(query string, args any) string {
+	lp := policy.Load()
+
+	if lp.mode == LogPlaceholders {
+		return truncate(compact(query), lp.maxLength)
+	}
+
+	names := paramNames(query)
+
+	query, params, err := sqlx.Named(query, args)
+	if err != nil {
+		return err.Error()
+	}
+
+	tagged := redactedFields(args)
+
+	for i, param := range params {
+		var value string
+		switch {
+		case i < len(names) && (lp.sensitive[strings.ToLower(names[i])] || tagged[names[i]]):
+			value = redacted
+		default:
+			switch v := param.(type) {
+			case string:
+				value = fmt.Sprintf("'%s'", v)
+			case []byte:
+				value = fmt.Sprintf("'%s'", string(v))
+			default:
+				value = fmt.Sprintf("%v", v)
+			}
+		}
+		query = strings.Replace(query, "?", value, 1)
+	}
+
+	return truncate(compact(query), lp.maxLength)
+}
+
+// paramPattern matches the named parameters of a query, leaving out the
+// :: casts.
+var paramPattern = regexp.MustCompile(`(?:^|[^:]):([A-Za-z_][A-Za-z0-9_.]*)`)
+
+// paramNames returns the names of the parameters of the query in the order
+// sqlx binds them.
+func paramNames(query string) []string {
+	matches := paramPattern.FindAllStringSubmatch(query, -1)
+
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m[1]
+	}
+
+	return names
+}
+
+// redactedFields returns the names of the parameters whose struct fields are
+// tagged `log:"redact"`.
+func redactedFields(args any) map[string]bool {
+	t := reflect.TypeOf(args)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var tagged map[string]bool
+	for name, fi := range mapper.TypeMap(t).Names {
+		if fi.Field.Tag.Get("log") != "redact" {
+			continue
+		}
+
+		if tagged == nil {
+			tagged = make(map[string]bool)
+		}
+		tagged[name] = true
+	}
+
+	return tagged
+}
+
+// compact puts the query on a single line.
+func compact(query string) string {
+	query = strings.ReplaceAll(query, "\t", "")
+	query = strings.ReplaceAll(query, "\n", " ")
+
+	return strings.Trim(query, " ")
+}
+
+// truncate cuts the query down to the maximum length. A length of zero or
+// less keeps the query whole.
+func truncate(query string, maxLength int) string {
+	if maxLength <= 0 || len(query) <= maxLength {
+		return query
+	}
+
+	// Don't cut a multi-byte character in half.
+	for maxLength > 0 && !utf8.RuneStart(query[maxLength]) {
+		maxLength--
+	}
+
+	return fmt.Sprintf("%s...[%d bytes truncated]", query[:maxLength], len(query)-maxLength)
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+func Test_QueryStringRedaction(t *testing.T) {
+	defer SetLogPolicy(LogPolicy{Mode: LogValues, SensitiveColumns: DefaultSensitiveColumns, Level: logger.LevelInfo, SlowThreshold: 500 * time.Millisecond})
+
+	type user struct {
+		Name    string `db:"name"`
+		Email   string `db:"email"`
+		Payload []byte `db:"payload" log:"redact"`
+		Count   int    `db:"count"`
+	}
+
+	args := user{Name: "bill", Email: "bill@example.com", Payload: []byte(`{"body":"private"}`), Count: 3}
+
+	tests := []struct {
+		name    string
+		policy  LogPolicy
+		query   string
+		args    any
+		exp     string
+		missing []string
+	}{
+		{
+			name:    "sensitive column",
+			policy:  LogPolicy{Mode: LogValues, SensitiveColumns: DefaultSensitiveColumns},
+			query:   "SELECT * FROM users WHERE name = :name AND email = :email",
+			args:    args,
+			exp:     "SELECT * FROM users WHERE name = 'bill' AND email = '[REDACTED]'",
+			missing: []string{"bill@example.com"},
+		},
+		{
+			name:    "tagged field",
+			policy:  LogPolicy{Mode: LogValues},
+			query:   "INSERT INTO events (payload, count) VALUES (CAST(:payload AS JSONB), :count)",
+			args:    args,
+			exp:     "INSERT INTO events (payload, count) VALUES (CAST('[REDACTED]' AS JSONB), 3)",
+			missing: []string{"private"},
+		},
+		{
+			name:    "configured columns",
+			policy:  LogPolicy{Mode: LogValues, SensitiveColumns: []string{" NAME "}},
+			query:   "SELECT * FROM users WHERE name = :name AND email = :email",
+			args:    args,
+			exp:     "SELECT * FROM users WHERE name = '[REDACTED]' AND email = 'bill@example.com'",
+			missing: []string{"bill'"},
+		},
+		{
+			name:    "map arguments",
+			policy:  LogPolicy{Mode: LogValues, SensitiveColumns: DefaultSensitiveColumns},
+			query:   "UPDATE users SET password_hash = :password_hash WHERE name = :name",
+			args:    map[string]any{"password_hash": "$2a$10$abc", "name": "bill"},
+			exp:     "UPDATE users SET password_hash = '[REDACTED]' WHERE name = 'bill'",
+			missing: []string{"$2a$10$abc"},
+		},
+		{
+			name:    "placeholders",
+			policy:  LogPolicy{Mode: LogPlaceholders},
+			query:   "SELECT *\n\tFROM users\n\tWHERE name = :name",
+			args:    args,
+			exp:     "SELECT * FROM users WHERE name = :name",
+			missing: []string{"bill"},
+		},
+		{
+			name:   "truncated",
+			policy: LogPolicy{Mode: LogPlaceholders, MaxLength: 13},
+			query:  "SELECT * FROM users WHERE name = :name",
+			args:   args,
+			exp:    "SELECT * FROM...[25 bytes truncated]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLogPolicy(tt.policy)
+
+			got := queryString(tt.query, tt.args)
+			if got != tt.exp {
+				t.Fatalf("Should log the query:\ngot %s\nexp %s", got, tt.exp)
+			}
+
+			for _, s := range tt.missing {
+				if strings.Contains(got, s) {
+					t.Fatalf("Should not log %q: got %s", s, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_ParamNames(t *testing.T) {
+	got := paramNames("SELECT 'x'::text, :name, now()::date, :user.email")
+
+	if len(got) != 2 || got[0] != "name" || got[1] != "user.email" {
+		t.Fatalf("Should find the named parameters without the casts: got %v", got)
+	}
+}
+
+func Test_Truncate(t *testing.T) {
+	if got := truncate("short", 0); got != "short" {
+		t.Fatalf("Should keep the query whole: got %s", got)
+	}
+
+	// The cut falls in the middle of the two byte "é".
+	got := truncate("café au lait", 4)
+	if exp := "caf...[10 bytes truncated]"; got != exp {
+		t.Fatalf("Should not cut a character in half: got %s, exp %s", got, exp)
+	}
+}
+
+func Test_ParseLogMode(t *testing.T) {
+	if m, err := ParseLogMode("Placeholders"); err != nil || m != LogPlaceholders {
+		t.Fatalf("Should parse the mode: got %v, %v", m, err)
+	}
+
+	if _, err := ParseLogMode("everything"); err == nil {
+		t.Fatal("Should reject an unknown mode")
+	}
+}
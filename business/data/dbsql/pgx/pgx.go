@@ -7,46 +7,101 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/dberr"
 	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
 	"github.com/vikaskumar1187/publisher_saas/foundation/web"
 	"go.opentelemetry.io/otel/attribute"
 )
 
-const (
-	uniqueViolation = "23505"
-	undefinedTable  = "42P01"
-)
-
-// Set of error variables for CRUD operations.
+// Set of error variables for CRUD operations. Errors reported by Postgres are
+// returned as a *dberr.Error that matches one of the dberr kinds, and the
+// aliases below are kept for the stores.
 var (
 	ErrDBNotFound        = sql.ErrNoRows
-	ErrDBDuplicatedEntry = errors.New("duplicated entry")
-	ErrUndefinedTable    = errors.New("undefined table")
+	ErrDBDuplicatedEntry = dberr.ErrDuplicatedEntry
+	ErrUndefinedTable    = dberr.ErrUndefinedTable
 )
 
 // Config is the required properties to use the database.
 type Config struct {
-	User         string
-	Password     string
-	Host         string
-	Name         string
-	Schema       string
-	MaxIdleConns int
-	MaxOpenConns int
-	DisableTLS   bool
+	User     string
+	Password string
+
+	// Host is one or more host:port pairs separated by commas. The hosts are
+	// tried in order until one accepts the connection, and with the target
+	// session attrs set to read-write a standby is skipped, so the service
+	// fails over to the new primary.
+	Host   string
+	Name   string
+	Schema string
+
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// SSLMode is require, verify-ca or verify-full and defaults to require,
+	// which encrypts the connection without checking the server certificate.
+	// DisableTLS turns TLS off whatever the mode.
+	DisableTLS  bool
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	TargetSessionAttrs string
+
+	// StatementTimeout and IdleInTxTimeout set the statement_timeout and the
+	// idle_in_transaction_session_timeout of every session. Zero leaves the
+	// server default in place.
+	StatementTimeout time.Duration
+	IdleInTxTimeout  time.Duration
+	ApplicationName  string
+
+	// HealthCheckPeriod is how often OpenPool checks the idle connections of
+	// the pool. It is not used by Open.
+	HealthCheckPeriod time.Duration
 }
 
 // Open knows how to open a database connection based on the configuration.
 func Open(cfg Config) (*sqlx.DB, error) {
+	dsn, err := connString(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sqlx.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	return db, nil
+}
+
+// connString builds the connection URL for the configuration.
+func connString(cfg Config) (string, error) {
 	sslMode := "require"
-	if cfg.DisableTLS {
+	switch {
+	case cfg.DisableTLS:
 		sslMode = "disable"
+
+	case cfg.SSLMode != "":
+		switch cfg.SSLMode {
+		case "require", "verify-ca", "verify-full":
+			sslMode = cfg.SSLMode
+		default:
+			return "", fmt.Errorf("invalid ssl mode %q", cfg.SSLMode)
+		}
 	}
 
 	q := make(url.Values)
@@ -56,6 +111,33 @@ func Open(cfg Config) (*sqlx.DB, error) {
 		q.Set("search_path", cfg.Schema)
 	}
 
+	if !cfg.DisableTLS {
+		if cfg.SSLRootCert != "" {
+			q.Set("sslrootcert", cfg.SSLRootCert)
+		}
+		if cfg.SSLCert != "" {
+			q.Set("sslcert", cfg.SSLCert)
+		}
+		if cfg.SSLKey != "" {
+			q.Set("sslkey", cfg.SSLKey)
+		}
+	}
+
+	if cfg.TargetSessionAttrs != "" {
+		q.Set("target_session_attrs", cfg.TargetSessionAttrs)
+	}
+	if cfg.StatementTimeout > 0 {
+		q.Set("statement_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
+
+	if cfg.IdleInTxTimeout > 0 {
+		q.Set("idle_in_transaction_session_timeout", strconv.FormatInt(cfg.IdleInTxTimeout.Milliseconds(), 10))
+	}
+
+	if cfg.ApplicationName != "" {
+		q.Set("application_name", cfg.ApplicationName)
+	}
+
 	u := url.URL{
 		Scheme:   "postgres",
 		User:     url.UserPassword(cfg.User, cfg.Password),
@@ -64,14 +146,7 @@ func Open(cfg Config) (*sqlx.DB, error) {
 		RawQuery: q.Encode(),
 	}
 
-	db, err := sqlx.Open("pgx", u.String())
-	if err != nil {
-		return nil, err
-	}
-	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetMaxOpenConns(cfg.MaxOpenConns)
-
-	return db, nil
+	return u.String(), nil
 }
 
 // StatusCheck returns nil if it can successfully talk to the database. It
@@ -117,27 +192,30 @@ func ExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, qu
 func NamedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any) error {
 	q := queryString(query, data)
 
+	var name string
 	if _, ok := data.(struct{}); ok {
-		log.Infoc(ctx, 5, "database.NamedExecContext", "query", q)
+		logQuery(ctx, log, 5, "database.NamedExecContext", q)
+		name = queryName(2)
 	} else {
-		log.Infoc(ctx, 4, "database.NamedExecContext", "query", q)
+		logQuery(ctx, log, 4, "database.NamedExecContext", q)
+		name = queryName(1)
 	}
 
 	ctx, span := web.AddSpan(ctx, "business.sys.database.exec", attribute.String("query", q))
 	defer span.End()
 
-	if _, err := sqlx.NamedExecContext(ctx, db, query, data); err != nil {
-		if pqerr, ok := err.(*pgconn.PgError); ok {
-			switch pqerr.Code {
-			case undefinedTable:
-				return ErrUndefinedTable
-			case uniqueViolation:
-				return ErrDBDuplicatedEntry
-			}
-		}
+	start := time.Now()
+
+	result, err := sqlx.NamedExecContext(ctx, db, query, data)
+	if err != nil {
+		err = classify(err)
+		observe(ctx, log, span, name, q, start, 0, err)
 		return err
 	}
 
+	rows, _ := result.RowsAffected()
+	observe(ctx, log, span, name, q, start, rows, nil)
+
 	return nil
 }
 
@@ -161,16 +239,26 @@ func NamedQuerySliceUsingIn[T any](ctx context.Context, log *logger.Logger, db s
 	return namedQuerySlice(ctx, log, db, query, data, dest, true)
 }
 
-func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, dest *[]T, withIn bool) error {
+func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, dest *[]T, withIn bool) (err error) {
 	q := queryString(query, data)
 
-	log.Infoc(ctx, 5, "database.NamedQuerySlice", "query", q)
+	logQuery(ctx, log, 5, "database.NamedQuerySlice", q)
+	name := queryName(2)
 
 	ctx, span := web.AddSpan(ctx, "business.sys.database.queryslice", attribute.String("query", q))
 	defer span.End()
 
+	db, onReplica := route(ctx, db, query)
+	span.SetAttributes(attribute.Bool("db.replica", onReplica))
+
+	start := time.Now()
+
+	var n int64
+	defer func() {
+		observe(ctx, log, span, name, q, start, n, err)
+	}()
+
 	var rows *sqlx.Rows
-	var err error
 
 	switch withIn {
 	case true:
@@ -194,10 +282,7 @@ func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.Ext
 	}
 
 	if err != nil {
-		if pqerr, ok := err.(*pgconn.PgError); ok && pqerr.Code == undefinedTable {
-			return ErrUndefinedTable
-		}
-		return err
+		return classify(err)
 	}
 	defer rows.Close()
 
@@ -209,7 +294,77 @@ func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.Ext
 		}
 		slice = append(slice, *v)
 	}
+
+	if err := rows.Err(); err != nil {
+		return classify(err)
+	}
+
 	*dest = slice
+	n = int64(len(slice))
+
+	return nil
+}
+
+// QueryEach is a helper function for executing queries that return a
+// collection of data, calling the function with every row as it is read
+// instead of collecting them into a slice.
+func QueryEach[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, fn func(T) error) error {
+	return namedQueryEach(ctx, log, db, query, struct{}{}, fn)
+}
+
+// NamedQueryEach is a helper function for executing queries that return a
+// collection of data, calling the function with every row as it is read
+// where field replacement is necessary. Use it for result sets too large to
+// hold in memory. Reading stops when the function returns an error or the
+// context is cancelled.
+func NamedQueryEach[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, fn func(T) error) error {
+	return namedQueryEach(ctx, log, db, query, data, fn)
+}
+
+func namedQueryEach[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, fn func(T) error) (err error) {
+	q := queryString(query, data)
+
+	logQuery(ctx, log, 5, "database.NamedQueryEach", q)
+	name := queryName(2)
+
+	ctx, span := web.AddSpan(ctx, "business.sys.database.queryeach", attribute.String("query", q))
+	defer span.End()
+
+	db, onReplica := route(ctx, db, query)
+	span.SetAttributes(attribute.Bool("db.replica", onReplica))
+
+	start := time.Now()
+
+	var n int64
+	defer func() {
+		observe(ctx, log, span, name, q, start, n, err)
+	}()
+
+	rows, err := sqlx.NamedQueryContext(ctx, db, query, data)
+	if err != nil {
+		return classify(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var v T
+		if err := rows.StructScan(&v); err != nil {
+			return err
+		}
+
+		if err := fn(v); err != nil {
+			return err
+		}
+		n++
+	}
+
+	if err := rows.Err(); err != nil {
+		return classify(err)
+	}
 
 	return nil
 }
@@ -233,16 +388,26 @@ func NamedQueryStructUsingIn(ctx context.Context, log *logger.Logger, db sqlx.Ex
 	return namedQueryStruct(ctx, log, db, query, data, dest, true)
 }
 
-func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, dest any, withIn bool) error {
+func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, dest any, withIn bool) (err error) {
 	q := queryString(query, data)
 
-	log.Infoc(ctx, 5, "database.NamedQueryStruct", "query", q)
+	logQuery(ctx, log, 5, "database.NamedQueryStruct", q)
+	name := queryName(2)
 
 	ctx, span := web.AddSpan(ctx, "business.sys.database.query", attribute.String("query", q))
 	defer span.End()
 
+	db, onReplica := route(ctx, db, query)
+	span.SetAttributes(attribute.Bool("db.replica", onReplica))
+
+	start := time.Now()
+
+	var n int64
+	defer func() {
+		observe(ctx, log, span, name, q, start, n, err)
+	}()
+
 	var rows *sqlx.Rows
-	var err error
 
 	switch withIn {
 	case true:
@@ -266,46 +431,36 @@ func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 	}
 
 	if err != nil {
-		if pqerr, ok := err.(*pgconn.PgError); ok && pqerr.Code == undefinedTable {
-			return ErrUndefinedTable
-		}
-		return err
+		return classify(err)
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return classify(err)
+		}
 		return ErrDBNotFound
 	}
 
 	if err := rows.StructScan(dest); err != nil {
 		return err
 	}
+	n = 1
 
 	return nil
 }
 
-// queryString provides a pretty print version of the query and parameters.
-func queryString(query string, args any) string {
-	query, params, err := sqlx.Named(query, args)
-	if err != nil {
-		return err.Error()
+// classify turns an error reported by Postgres into a *dberr.Error. Any other
+// error is returned as is.
+func classify(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
 	}
 
-	for _, param := range params {
-		var value string
-		switch v := param.(type) {
-		case string:
-			value = fmt.Sprintf("'%s'", v)
-		case []byte:
-			value = fmt.Sprintf("'%s'", string(v))
-		default:
-			value = fmt.Sprintf("%v", v)
-		}
-		query = strings.Replace(query, "?", value, 1)
+	if dbErr := dberr.New(err, pgErr.Code, pgErr.Message, pgErr.Detail, pgErr.TableName, pgErr.ColumnName, pgErr.ConstraintName); dbErr != nil {
+		return dbErr
 	}
 
-	query = strings.ReplaceAll(query, "\t", "")
-	query = strings.ReplaceAll(query, "\n", " ")
-
-	return strings.Trim(query, " ")
+	return err
 }
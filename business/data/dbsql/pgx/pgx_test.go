@@ -0,0 +1,61 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbtest"
+)
+
+func Test_NamedQueryEach(t *testing.T) {
+	dbT := dbtest.NewDatabase(t, c)
+
+	if _, err := dbT.Exec("CREATE TABLE items AS SELECT n FROM generate_series(1, 1000) AS n"); err != nil {
+		t.Fatalf("Should be able to create the table: %v", err)
+	}
+
+	type item struct {
+		N int `db:"n"`
+	}
+
+	data := struct {
+		Max int `db:"max"`
+	}{
+		Max: 500,
+	}
+
+	const q = `SELECT n FROM items WHERE n <= :max ORDER BY n`
+
+	var sum, next int
+	err := db.NamedQueryEach(context.Background(), dbtest.Log(), dbT, q, data, func(it item) error {
+		next++
+		if it.N != next {
+			return errors.New("out of order")
+		}
+		sum += it.N
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Should be able to read every row: %v", err)
+	}
+
+	if next != 500 || sum != 500*501/2 {
+		t.Fatalf("Should read 500 rows: got %d", next)
+	}
+
+	errStop := errors.New("stop")
+
+	var read int
+	err = db.NamedQueryEach(context.Background(), dbtest.Log(), dbT, q, data, func(it item) error {
+		read++
+		if read == 10 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || read != 10 {
+		t.Fatalf("Should stop reading on the error of the function: got %v after %d rows", err, read)
+	}
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Pool is the native pgx backend. The pool gives access to the pgx features
+// database/sql can't provide, such as COPY and batches, and DB runs the sqlx
+// helpers of this package over the same connections.
+type Pool struct {
+	*pgxpool.Pool
+	DB *sqlx.DB
+}
+
+// OpenPool knows how to open a pgx pool based on the configuration. The idle
+// connections are checked on the health check period, and pgxpool pings a
+// connection that has been idle for over a second before handing it out.
+func OpenPool(ctx context.Context, cfg Config) (*Pool, error) {
+	dsn, err := connString(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+
+	if cfg.MaxOpenConns > 0 {
+		pc.MaxConns = int32(cfg.MaxOpenConns)
+	}
+
+	if cfg.ConnMaxLifetime > 0 {
+		pc.MaxConnLifetime = cfg.ConnMaxLifetime
+	}
+
+	if cfg.ConnMaxIdleTime > 0 {
+		pc.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	}
+
+	if cfg.HealthCheckPeriod > 0 {
+		pc.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, pc)
+	if err != nil {
+		return nil, fmt.Errorf("creating pool: %w", err)
+	}
+
+	p := Pool{
+		Pool: pool,
+		DB:   sqlx.NewDb(stdlib.OpenDBFromPool(pool), "pgx"),
+	}
+
+	return &p, nil
+}
+
+// Close closes the DB and then the pool, waiting for the connections in use
+// to be released.
+func (p *Pool) Close() error {
+	err := p.DB.Close()
+	p.Pool.Close()
+
+	return err
+}
+
+// StatusCheck returns nil if it can successfully talk to the database
+// through the pool. It returns a non-nil error otherwise.
+func (p *Pool) StatusCheck(ctx context.Context) error {
+	return StatusCheck(ctx, p.DB)
+}
+
+// =============================================================================
+
+// Querier represents the pgx native behavior provided by a pool, a connection
+// and a pgx transaction.
+type Querier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// CopyFrom is a helper function to bulk load rows into a table with the COPY
+// protocol, with logging and tracing. It returns the number of rows copied.
+// Use pgx.CopyFromRows or pgx.CopyFromSlice to build the source.
+func CopyFrom(ctx context.Context, log *logger.Logger, q Querier, table string, columns []string, src pgx.CopyFromSource) (int64, error) {
+	stmt := fmt.Sprintf("COPY %s (%s) FROM STDIN", table, strings.Join(columns, ", "))
+
+	logQuery(ctx, log, 4, "database.CopyFrom", stmt)
+	name := queryName(1)
+
+	ctx, span := web.AddSpan(ctx, "business.sys.database.copyfrom", attribute.String("query", stmt))
+	defer span.End()
+
+	start := time.Now()
+
+	n, err := q.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, src)
+	if err != nil {
+		err = classify(err)
+		observe(ctx, log, span, name, stmt, start, n, err)
+		return n, err
+	}
+
+	observe(ctx, log, span, name, stmt, start, n, nil)
+
+	return n, nil
+}
+
+// =============================================================================
+
+// Batch queues statements with named parameters, like the ones given to
+// NamedExecContext, to be sent to the database in a single round trip.
+type Batch struct {
+	batch   pgx.Batch
+	queries []string
+}
+
+// Queue adds the statement to the batch.
+func (b *Batch) Queue(query string, data any) error {
+	named, args, err := sqlx.Named(query, data)
+	if err != nil {
+		return err
+	}
+
+	b.batch.Queue(sqlx.Rebind(sqlx.DOLLAR, named), args...)
+	b.queries = append(b.queries, queryString(query, data))
+
+	return nil
+}
+
+// Len returns the number of statements in the batch.
+func (b *Batch) Len() int {
+	return b.batch.Len()
+}
+
+// SendBatch is a helper function to run the statements of the batch in a
+// single round trip, with logging and tracing. The statements are run in
+// order in an implicit transaction, so the first error rolls back the batch
+// unless it is sent inside a transaction.
+func SendBatch(ctx context.Context, log *logger.Logger, q Querier, b *Batch) error {
+	stmt := strings.Join(b.queries, "; ")
+
+	logQuery(ctx, log, 4, "database.SendBatch", stmt)
+	name := queryName(1)
+
+	ctx, span := web.AddSpan(ctx, "business.sys.database.batch", attribute.String("query", stmt), attribute.Int("db.statements", b.Len()))
+	defer span.End()
+
+	start := time.Now()
+
+	br := q.SendBatch(ctx, &b.batch)
+
+	var rows int64
+	for i := range b.queries {
+		tag, err := br.Exec()
+		if err != nil {
+			br.Close()
+			err = fmt.Errorf("statement[%d]: %w", i, classify(err))
+			observe(ctx, log, span, name, stmt, start, rows, err)
+			return err
+		}
+		rows += tag.RowsAffected()
+	}
+
+	err := br.Close()
+	if err != nil {
+		err = classify(err)
+	}
+	observe(ctx, log, span, name, stmt, start, rows, err)
+
+	return err
+}
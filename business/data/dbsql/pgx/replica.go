@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Set of errors the replica checks report.
+var (
+	ErrReplicaLagging      = errors.New("replica lagging")
+	ErrReplicaNotStreaming = errors.New("replica not streaming")
+)
+
+// ReplicaConfig represents the settings of a read replica.
+type ReplicaConfig struct {
+	Log           *logger.Logger
+	Primary       *sqlx.DB
+	Replica       *sqlx.DB
+	MaxLag        time.Duration
+	CheckInterval time.Duration
+}
+
+// Replica is a read replica of the primary database. Once it is in use, the
+// read only queries run on the primary outside of a transaction are routed
+// to it while it is healthy and its lag is under the maximum. Otherwise they
+// fall back to the primary.
+type Replica struct {
+	log           *logger.Logger
+	primary       *sqlx.DB
+	db            *sqlx.DB
+	maxLag        time.Duration
+	checkInterval time.Duration
+	healthy       atomic.Bool
+	lag           atomic.Int64
+}
+
+// NewReplica constructs a Replica for the primary. The replica is treated as
+// unhealthy until its first check.
+func NewReplica(cfg ReplicaConfig) *Replica {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 5 * time.Second
+	}
+
+	return &Replica{
+		log:           cfg.Log,
+		primary:       cfg.Primary,
+		db:            cfg.Replica,
+		maxLag:        cfg.MaxLag,
+		checkInterval: cfg.CheckInterval,
+	}
+}
+
+// Run checks the health and the lag of the replica on the check interval
+// until the context is cancelled.
+func (r *Replica) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Check(ctx); err != nil {
+			r.log.Warn(ctx, "replica", "status", "check failed", "msg", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check talks to the replica and records its health and its lag behind the
+// primary. The lag is zero when the replica has replayed everything it has
+// received, so an idle primary doesn't make it look behind. That only holds
+// while the replica is receiving, so a replica whose WAL receiver isn't
+// streaming from the primary is unhealthy however little it has to replay.
+func (r *Replica) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	if err := StatusCheck(ctx, r.db); err != nil {
+		r.healthy.Store(false)
+		return fmt.Errorf("status check: %w", err)
+	}
+
+	// The status of the WAL receiver is only visible to roles with
+	// pg_read_all_stats, other roles only see that it is running.
+	const q = `
+	SELECT
+		pg_is_in_recovery() AS in_recovery,
+		EXISTS (SELECT 1 FROM pg_stat_wal_receiver) AS receiving,
+		COALESCE((SELECT status FROM pg_stat_wal_receiver), 'streaming') AS receiver_status,
+		CASE
+			WHEN NOT pg_is_in_recovery() THEN 0
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END AS lag`
+
+	var row replicaStatus
+	if err := QueryStruct(WithLogLevel(ctx, logger.LevelDebug), r.log, r.db, q, &row); err != nil {
+		r.healthy.Store(false)
+		return fmt.Errorf("querystruct: %w", err)
+	}
+
+	r.lag.Store(int64(row.Lag * float64(time.Second)))
+
+	if err := row.streaming(); err != nil {
+		r.healthy.Store(false)
+		return err
+	}
+
+	r.healthy.Store(true)
+
+	return nil
+}
+
+// replicaStatus is the state of the replica read by Check.
+type replicaStatus struct {
+	InRecovery     bool    `db:"in_recovery"`
+	Receiving      bool    `db:"receiving"`
+	ReceiverStatus string  `db:"receiver_status"`
+	Lag            float64 `db:"lag"`
+}
+
+// streaming returns an error when the replica is in recovery but isn't
+// streaming the WAL from the primary, such as when the connection to the
+// primary was lost.
+func (rs replicaStatus) streaming() error {
+	if !rs.InRecovery {
+		return nil
+	}
+
+	if !rs.Receiving {
+		return fmt.Errorf("%w: no wal receiver", ErrReplicaNotStreaming)
+	}
+
+	if rs.ReceiverStatus != "streaming" {
+		return fmt.Errorf("%w: wal receiver %s", ErrReplicaNotStreaming, rs.ReceiverStatus)
+	}
+
+	return nil
+}
+
+// Lag returns the lag of the replica recorded by the last check.
+func (r *Replica) Lag() time.Duration {
+	return time.Duration(r.lag.Load())
+}
+
+// StatusCheck returns nil if the replica passed its last check and is within
+// the maximum lag. It returns a non-nil error otherwise.
+func (r *Replica) StatusCheck() error {
+	if !r.healthy.Load() {
+		return errors.New("replica unhealthy")
+	}
+
+	if r.maxLag > 0 && r.Lag() > r.maxLag {
+		return fmt.Errorf("%w: lag[%s] max[%s]", ErrReplicaLagging, r.Lag(), r.maxLag)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+var replica atomic.Pointer[Replica]
+
+// UseReplica routes the read only queries run on the primary of the replica
+// to it. Passing nil stops the routing.
+func UseReplica(r *Replica) {
+	replica.Store(r)
+}
+
+const primaryKey ctxKey = 2
+
+// WithPrimary returns a context whose queries always run on the primary. Use
+// it to read your own writes, and for work that can't act on stale data, such
+// as the background workers.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey, true)
+}
+
+// writePattern matches the queries that lock rows or have side effects even
+// though they start with SELECT.
+var writePattern = regexp.MustCompile(`(?i)\bFOR\s+(NO\s+KEY\s+)?(UPDATE|SHARE|KEY\s+SHARE)\b|\bnextval\s*\(|\bpg_(try_)?advisory`)
+
+// readPattern matches the queries that start with SELECT.
+var readPattern = regexp.MustCompile(`(?i)^\s*SELECT\b`)
+
+// route returns the replica when the query is read only, it is run on the
+// primary of the replica in use and the replica is usable. It returns the
+// database as is otherwise.
+func route(ctx context.Context, db sqlx.ExtContext, query string) (sqlx.ExtContext, bool) {
+	r := replica.Load()
+	if r == nil {
+		return db, false
+	}
+
+	if primary, ok := db.(*sqlx.DB); !ok || primary != r.primary {
+		return db, false
+	}
+
+	if force, _ := ctx.Value(primaryKey).(bool); force {
+		return db, false
+	}
+
+	if !readPattern.MatchString(query) || writePattern.MatchString(query) {
+		return db, false
+	}
+
+	if r.StatusCheck() != nil {
+		return db, false
+	}
+
+	return r.db, true
+}
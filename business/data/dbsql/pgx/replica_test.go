@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func Test_ReplicaStreaming(t *testing.T) {
+	tests := []struct {
+		name   string
+		status replicaStatus
+		err    error
+	}{
+		{name: "streaming", status: replicaStatus{InRecovery: true, Receiving: true, ReceiverStatus: "streaming"}},
+		{name: "promoted", status: replicaStatus{InRecovery: false}},
+		{name: "no receiver", status: replicaStatus{InRecovery: true, Receiving: false, ReceiverStatus: "streaming"}, err: ErrReplicaNotStreaming},
+		{name: "receiver stopping", status: replicaStatus{InRecovery: true, Receiving: true, ReceiverStatus: "stopping"}, err: ErrReplicaNotStreaming},
+		{name: "receiver waiting", status: replicaStatus{InRecovery: true, Receiving: true, ReceiverStatus: "waiting"}, err: ErrReplicaNotStreaming},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.status.streaming()
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("Should get %v: got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func Test_ReplicaRoute(t *testing.T) {
+	primary := &sqlx.DB{}
+	other := &sqlx.DB{}
+
+	r := NewReplica(ReplicaConfig{Primary: primary, Replica: &sqlx.DB{}, MaxLag: time.Second})
+
+	UseReplica(r)
+	defer UseReplica(nil)
+
+	const read = "SELECT * FROM pages"
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		db        sqlx.ExtContext
+		query     string
+		healthy   bool
+		lag       time.Duration
+		onReplica bool
+	}{
+		{name: "read", ctx: context.Background(), db: primary, query: read, healthy: true, onReplica: true},
+		{name: "unhealthy", ctx: context.Background(), db: primary, query: read, healthy: false},
+		{name: "lagging", ctx: context.Background(), db: primary, query: read, healthy: true, lag: 2 * time.Second},
+		{name: "with primary", ctx: WithPrimary(context.Background()), db: primary, query: read, healthy: true},
+		{name: "other database", ctx: context.Background(), db: other, query: read, healthy: true},
+		{name: "write", ctx: context.Background(), db: primary, query: "UPDATE pages SET name = 'x'", healthy: true},
+		{name: "locking read", ctx: context.Background(), db: primary, query: "SELECT * FROM pages FOR UPDATE", healthy: true},
+		{name: "advisory lock", ctx: context.Background(), db: primary, query: "SELECT pg_try_advisory_lock(1)", healthy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.healthy.Store(tt.healthy)
+			r.lag.Store(int64(tt.lag))
+
+			got, onReplica := route(tt.ctx, tt.db, tt.query)
+			if onReplica != tt.onReplica {
+				t.Fatalf("Should run on the replica %t: got %t", tt.onReplica, onReplica)
+			}
+
+			if !onReplica && got != tt.db {
+				t.Fatal("Should run on the database it was given")
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package db
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
@@ -26,6 +30,17 @@ func (db *dbBeginner) Begin() (transaction.Transaction, error) {
 	return db.sqlxDB.Beginx()
 }
 
+// BeginTx starts a transaction bound to the context with the specified
+// options. When the context already holds a transaction, a savepoint is
+// created in it instead and the options are ignored.
+func (db *dbBeginner) BeginTx(ctx context.Context, opts *sql.TxOptions) (transaction.Transaction, error) {
+	if parent, ok := transaction.Get(ctx); ok {
+		return beginSavepoint(ctx, parent)
+	}
+
+	return db.sqlxDB.BeginTxx(ctx, opts)
+}
+
 // GetExtContext is a helper function that extracts the sqlx value
 // from the core transactor interface for transactional use.
 func GetExtContext(tx transaction.Transaction) (sqlx.ExtContext, error) {
@@ -36,3 +51,81 @@ func GetExtContext(tx transaction.Transaction) (sqlx.ExtContext, error) {
 
 	return ec, nil
 }
+
+// ExtContext returns the sqlx value store code should run its statements on.
+// This is the transaction held by the context, such as the one started by
+// the transaction middleware, or the database when there is none.
+func ExtContext(ctx context.Context, db *sqlx.DB) (sqlx.ExtContext, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		return GetExtContext(tx)
+	}
+
+	return db, nil
+}
+
+// =============================================================================
+
+// savepointID makes the savepoint names unique within a transaction.
+var savepointID atomic.Uint64
+
+// savepoint implements the transaction interface for a nested transaction.
+// It runs its statements on the parent transaction.
+type savepoint struct {
+	sqlx.ExtContext
+	ctx  context.Context
+	name string
+	done bool
+}
+
+func beginSavepoint(ctx context.Context, parent transaction.Transaction) (*savepoint, error) {
+	ec, err := GetExtContext(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := savepoint{
+		ExtContext: ec,
+		ctx:        context.WithoutCancel(ctx),
+		name:       "sp_" + strconv.FormatUint(savepointID.Add(1), 10),
+	}
+
+	if _, err := ec.ExecContext(ctx, "SAVEPOINT "+sp.name); err != nil {
+		return nil, fmt.Errorf("savepoint: %w", err)
+	}
+
+	return &sp, nil
+}
+
+// Commit releases the savepoint, keeping its changes in the parent
+// transaction.
+func (sp *savepoint) Commit() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+
+	if _, err := sp.ExecContext(sp.ctx, "RELEASE SAVEPOINT "+sp.name); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+
+	return nil
+}
+
+// Rollback undoes the changes made since the savepoint. The parent
+// transaction carries on.
+func (sp *savepoint) Rollback() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+
+	if _, err := sp.ExecContext(sp.ctx, "ROLLBACK TO SAVEPOINT "+sp.name); err != nil {
+		return fmt.Errorf("rollback to savepoint: %w", err)
+	}
+
+	if _, err := sp.ExecContext(sp.ctx, "RELEASE SAVEPOINT "+sp.name); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbtest"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	code := m.Run()
+	dbtest.StopDB(c)
+
+	os.Exit(code)
+}
+
+func Test_ExtContext(t *testing.T) {
+	dbT := sqlx.NewDb(&sql.DB{}, "pgx")
+	tx := &sqlx.Tx{}
+
+	ec, err := db.ExtContext(context.Background(), dbT)
+	if err != nil {
+		t.Fatalf("Should get the database without a transaction: %v", err)
+	}
+
+	if got, ok := ec.(*sqlx.DB); !ok || got != dbT {
+		t.Fatalf("Should return the database without a transaction: got %T", ec)
+	}
+
+	// The transaction middleware stores the transaction it started in the
+	// context of the request.
+	ctx := transaction.Set(context.Background(), tx)
+
+	ec, err = db.ExtContext(ctx, dbT)
+	if err != nil {
+		t.Fatalf("Should get the transaction: %v", err)
+	}
+
+	if got, ok := ec.(*sqlx.Tx); !ok || got != tx {
+		t.Fatalf("Should return the transaction held by the context: got %T", ec)
+	}
+}
+
+func Test_Savepoint(t *testing.T) {
+	dbT := dbtest.NewDatabase(t, c)
+
+	if _, err := dbT.Exec("CREATE TABLE items (name TEXT NOT NULL)"); err != nil {
+		t.Fatalf("Should be able to create the table: %v", err)
+	}
+
+	errInner := errors.New("inner failed")
+	ctx := context.Background()
+	log := dbtest.Log()
+	bgn := db.NewBeginner(dbT)
+
+	err := transaction.ExecuteUnderTransaction(ctx, log, bgn, func(tx transaction.Transaction) error {
+		ctx := transaction.Set(ctx, tx)
+
+		insert(t, ctx, "outer")
+
+		err := transaction.ExecuteUnderTransaction(ctx, log, bgn, func(tx transaction.Transaction) error {
+			insert(t, ctx, "released")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = transaction.ExecuteUnderTransaction(ctx, log, bgn, func(tx transaction.Transaction) error {
+			insert(t, ctx, "rolled back")
+			return errInner
+		})
+		if !errors.Is(err, errInner) {
+			t.Fatalf("Should get the error of the savepoint: got %v", err)
+		}
+
+		// The failed savepoint leaves the transaction usable.
+		insert(t, ctx, "after")
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Should commit the transaction: %v", err)
+	}
+
+	var names []string
+	if err := dbT.Select(&names, "SELECT name FROM items ORDER BY name"); err != nil {
+		t.Fatalf("Should be able to query the items: %v", err)
+	}
+
+	if exp := "[after outer released]"; fmt.Sprint(names) != exp {
+		t.Fatalf("Should keep the released savepoint only: got %v, exp %s", names, exp)
+	}
+}
+
+func Test_SavepointOuterRollback(t *testing.T) {
+	dbT := dbtest.NewDatabase(t, c)
+
+	if _, err := dbT.Exec("CREATE TABLE items (name TEXT NOT NULL)"); err != nil {
+		t.Fatalf("Should be able to create the table: %v", err)
+	}
+
+	errOuter := errors.New("outer failed")
+	ctx := context.Background()
+	log := dbtest.Log()
+	bgn := db.NewBeginner(dbT)
+
+	err := transaction.ExecuteUnderTransaction(ctx, log, bgn, func(tx transaction.Transaction) error {
+		ctx := transaction.Set(ctx, tx)
+
+		err := transaction.ExecuteUnderTransaction(ctx, log, bgn, func(tx transaction.Transaction) error {
+			insert(t, ctx, "released")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return errOuter
+	})
+	if !errors.Is(err, errOuter) {
+		t.Fatalf("Should get the error of the transaction: got %v", err)
+	}
+
+	var n int
+	if err := dbT.Get(&n, "SELECT count(*) FROM items"); err != nil {
+		t.Fatalf("Should be able to count the items: %v", err)
+	}
+
+	if n != 0 {
+		t.Fatalf("Should roll back the released savepoint with the transaction: got %d rows", n)
+	}
+}
+
+// insert adds an item on the transaction held by the context.
+func insert(t *testing.T, ctx context.Context, name string) {
+	t.Helper()
+
+	if _, ok := transaction.Get(ctx); !ok {
+		t.Fatal("Should get the transaction from the context")
+	}
+
+	ec, err := db.ExtContext(ctx, nil)
+	if err != nil {
+		t.Fatalf("Should get the transaction: %v", err)
+	}
+
+	if _, err := ec.ExecContext(ctx, "INSERT INTO items (name) VALUES ($1)", name); err != nil {
+		t.Fatalf("Should be able to insert %s: %v", name, err)
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/dbmetrics"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryName returns the name of the function the query is run for, such as
+// publicationdb.(*Store).QueryPageByID. The skip is the number of frames
+// that function is above the caller of queryName.
+func queryName(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return name
+}
+
+// observe records the outcome of the query on the span and in the metrics,
+// and logs the query when it took longer than the slow query threshold. Not
+// finding a row isn't counted as an error.
+func observe(ctx context.Context, log *logger.Logger, span trace.Span, name string, q string, start time.Time, rows int64, err error) {
+	d := time.Since(start)
+
+	failed := err != nil && !errors.Is(err, ErrDBNotFound)
+
+	threshold := policy.Load().slowThreshold
+	slow := threshold > 0 && d >= threshold
+
+	dbmetrics.Observe(ctx, name, d, rows, failed, slow)
+
+	span.SetAttributes(attribute.Int64("db.rows", rows))
+	if failed {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	if slow {
+		log.Warn(ctx, "slow query", "name", name, "duration", d, "threshold", threshold, "rows", rows, "query", q)
+	}
+}
@@ -0,0 +1,171 @@
+// Package dbtest contains supporting code for running tests that hit the DB.
+// A Postgres container is started once per test binary and every test gets
+// its own database in it, so the tests of a package can run in parallel.
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/migrate"
+	"github.com/vikaskumar1187/publisher_saas/foundation/docker"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// image is the Postgres image the tests run against, the same as the one
+// used by the cluster.
+const image = "postgres:16.1"
+
+// ErrNoDocker is returned by StartDB when docker isn't installed, in which
+// case the database tests are skipped.
+var ErrNoDocker = errors.New("docker is not installed")
+
+// StartDB starts a database instance.
+func StartDB() (*docker.Container, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, ErrNoDocker
+	}
+
+	dockerArgs := []string{"-e", "POSTGRES_PASSWORD=postgres"}
+	appArgs := []string{"-c", "log_statement=all"}
+
+	c, err := docker.StartContainer(image, "5432", dockerArgs, appArgs)
+	if err != nil {
+		return nil, fmt.Errorf("starting container: %w", err)
+	}
+
+	return c, nil
+}
+
+// StopDB stops a running database instance.
+func StopDB(c *docker.Container) {
+	if c == nil {
+		return
+	}
+
+	docker.StopContainer(c.ID)
+}
+
+// =============================================================================
+
+// NewDatabase creates an empty database for the test and returns a
+// connection to it. The test is skipped when no container is running.
+func NewDatabase(t *testing.T, c *docker.Container) *sqlx.DB {
+	t.Helper()
+
+	name := create(t, c)
+
+	dbT, err := db.Open(config(c, name))
+	if err != nil {
+		t.Fatalf("Opening database connection: %v", err)
+	}
+
+	t.Cleanup(func() {
+		dbT.Close()
+	})
+
+	return dbT
+}
+
+// NewMigrated creates a database for the test with every migration applied.
+func NewMigrated(t *testing.T, c *docker.Container) *sqlx.DB {
+	t.Helper()
+
+	dbT := NewDatabase(t, c)
+	migrated(t, dbT)
+
+	return dbT
+}
+
+// NewMigratedPool creates a database for the test with every migration
+// applied and returns a pgx pool connected to it.
+func NewMigratedPool(t *testing.T, c *docker.Container) *db.Pool {
+	t.Helper()
+
+	name := create(t, c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pool, err := db.OpenPool(ctx, config(c, name))
+	if err != nil {
+		t.Fatalf("Opening database pool: %v", err)
+	}
+
+	t.Cleanup(func() {
+		pool.Close()
+	})
+
+	migrated(t, pool.DB)
+
+	return pool
+}
+
+// Log returns a logger that discards its output.
+func Log() *logger.Logger {
+	return logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+}
+
+// =============================================================================
+
+// create creates an empty database for the test and returns its name. The
+// test is skipped when no container is running.
+func create(t *testing.T, c *docker.Container) string {
+	t.Helper()
+
+	if c == nil {
+		t.Skip("database tests need docker")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dbM, err := db.Open(config(c, "postgres"))
+	if err != nil {
+		t.Fatalf("Opening database connection: %v", err)
+	}
+	defer dbM.Close()
+
+	if err := db.StatusCheck(ctx, dbM); err != nil {
+		t.Fatalf("Status check database: %v\n%s", err, docker.DumpContainerLogs(c.ID))
+	}
+
+	name := fmt.Sprintf("test_%d", rand.Int63())
+	if _, err := dbM.ExecContext(ctx, "CREATE DATABASE "+name); err != nil {
+		t.Fatalf("Creating database %s: %v", name, err)
+	}
+
+	return name
+}
+
+// migrated applies every migration to the database.
+func migrated(t *testing.T, dbT *sqlx.DB) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := migrate.Migrate(ctx, Log(), dbT); err != nil {
+		t.Fatalf("Migrating database: %v", err)
+	}
+}
+
+func config(c *docker.Container, name string) db.Config {
+	return db.Config{
+		User:         "postgres",
+		Password:     "postgres",
+		Host:         c.Host,
+		Name:         name,
+		MaxIdleConns: 2,
+		MaxOpenConns: 10,
+		DisableTLS:   true,
+	}
+}
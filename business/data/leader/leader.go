@@ -0,0 +1,170 @@
+// Package leader provides leader election between the replicas of a service
+// using a Postgres session level advisory lock. The replica holding the lock
+// is the leader until its connection is lost or it shuts down, at which point
+// another replica acquires the lock.
+package leader
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// isLeader reports whether this process holds any leader lock.
+var isLeader = expvar.NewInt("leader")
+
+// Config represents information required to initialize a Leader.
+type Config struct {
+	Log           *logger.Logger
+	DB            *sqlx.DB
+	Name          string
+	LockID        int64
+	CheckInterval time.Duration
+}
+
+// Leader campaigns for the advisory lock and runs work while it holds it.
+type Leader struct {
+	log           *logger.Logger
+	db            *sqlx.DB
+	name          string
+	lockID        int64
+	checkInterval time.Duration
+
+	leading  atomic.Bool
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New constructs a Leader for the specified lock.
+func New(cfg Config) *Leader {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 5 * time.Second
+	}
+
+	return &Leader{
+		log:           cfg.Log,
+		db:            cfg.DB,
+		name:          cfg.Name,
+		lockID:        cfg.LockID,
+		checkInterval: cfg.CheckInterval,
+		shutdown:      make(chan struct{}),
+	}
+}
+
+// Start campaigns for leadership in the background. Each time leadership is
+// acquired fn is called with a context that is cancelled when leadership is
+// lost or the leader is shut down. fn is expected to block until then.
+func (l *Leader) Start(fn func(ctx context.Context)) {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		l.run(fn)
+	}()
+}
+
+// Shutdown stops campaigning, cancels the work and releases the lock.
+func (l *Leader) Shutdown() {
+	close(l.shutdown)
+	l.wg.Wait()
+}
+
+// IsLeader reports whether this replica currently holds the lock.
+func (l *Leader) IsLeader() bool {
+	return l.leading.Load()
+}
+
+// =============================================================================
+
+func (l *Leader) run(fn func(ctx context.Context)) {
+	ctx := context.Background()
+
+	for {
+		if err := l.lead(ctx, fn); err != nil {
+			l.log.Error(ctx, "leader", "status", "campaign", "name", l.name, "msg", err)
+		}
+
+		select {
+		case <-time.After(l.checkInterval):
+		case <-l.shutdown:
+			return
+		}
+	}
+}
+
+// lead tries to take the lock once. If it is taken, fn runs until the lock
+// connection fails or shutdown is requested.
+func (l *Leader) lead(ctx context.Context, fn func(ctx context.Context)) error {
+
+	// Session level advisory locks belong to a connection, so a dedicated
+	// connection is held for as long as this replica is the leader.
+	conn, err := l.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.lockID).Scan(&acquired); err != nil {
+		return fmt.Errorf("trying lock: %w", err)
+	}
+
+	if !acquired {
+		return nil
+	}
+
+	l.log.Info(ctx, "leader", "status", "acquired leadership", "name", l.name)
+
+	l.leading.Store(true)
+	isLeader.Add(1)
+
+	defer func() {
+		l.leading.Store(false)
+		isLeader.Add(-1)
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.lockID); err != nil {
+			l.log.Error(ctx, "leader", "status", "releasing lock", "name", l.name, "msg", err)
+		}
+
+		l.log.Info(ctx, "leader", "status", "released leadership", "name", l.name)
+	}()
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(workCtx)
+	}()
+
+	ticker := time.NewTicker(l.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := conn.PingContext(ctx); err != nil {
+				cancel()
+				<-done
+				return fmt.Errorf("lost lock connection: %w", err)
+			}
+
+		case <-done:
+			return nil
+
+		case <-l.shutdown:
+			cancel()
+			<-done
+			return nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Gate tracks the progress of migrations applied at startup so readiness
+// checks can report the service as not ready until the schema is current,
+// and background work can wait for the tables it uses.
+type Gate struct {
+	mu      sync.RWMutex
+	ready   bool
+	version int
+	err     error
+	done    chan struct{}
+}
+
+// NewGate constructs a gate in the not ready state.
+func NewGate() *Gate {
+	return &Gate{
+		done: make(chan struct{}),
+	}
+}
+
+// Ready reports whether the migrations have completed successfully.
+func (g *Gate) Ready() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.ready
+}
+
+// Version returns the schema version recorded when the migrations completed.
+func (g *Gate) Version() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.version
+}
+
+// Err returns the error the migrations failed with, if any.
+func (g *Gate) Err() error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.err
+}
+
+// Wait blocks until the migrations have completed. It returns the error the
+// migrations failed with, or the context error if the context is done first.
+func (g *Gate) Wait(ctx context.Context) error {
+	select {
+	case <-g.done:
+		return g.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run applies the pending migrations and opens the gate once they complete.
+// The provided context should carry the timeout for the migrations. Run must
+// only be called once.
+func (g *Gate) Run(ctx context.Context, log *logger.Logger, db *sqlx.DB) error {
+	defer close(g.done)
+
+	applied, err := Migrate(ctx, log, db)
+	if err != nil {
+		g.fail(err)
+		return err
+	}
+
+	version, err := Version(ctx, db)
+	if err != nil {
+		err = fmt.Errorf("querying version: %w", err)
+		g.fail(err)
+		return err
+	}
+
+	log.Info(ctx, "migrate", "status", "complete", "applied", len(applied), "version", version)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.ready = true
+	g.version = version
+
+	return nil
+}
+
+func (g *Gate) fail(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.err = err
+}
@@ -0,0 +1,81 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbtest"
+	"github.com/vikaskumar1187/publisher_saas/business/data/migrate"
+)
+
+func Test_GateFailed(t *testing.T) {
+	gate := migrate.NewGate()
+
+	if gate.Ready() || gate.Version() != 0 || gate.Err() != nil {
+		t.Fatal("Should start closed without a version or an error")
+	}
+
+	unreachable, err := db.Open(db.Config{Host: "127.0.0.1:1", Name: "postgres", DisableTLS: true})
+	if err != nil {
+		t.Fatalf("Should be able to open the database handle: %v", err)
+	}
+	defer unreachable.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if err := gate.Run(ctx, dbtest.Log(), unreachable); err == nil {
+		t.Fatal("Should fail to migrate an unreachable database")
+	}
+
+	if gate.Ready() || gate.Err() == nil {
+		t.Fatalf("Should stay closed and hold the error: ready %t: %v", gate.Ready(), gate.Err())
+	}
+
+	if err := gate.Wait(context.Background()); err == nil || err != gate.Err() {
+		t.Fatalf("Should stop waiting with the error of the migrations: %v", err)
+	}
+}
+
+func Test_GateWaitPending(t *testing.T) {
+	gate := migrate.NewGate()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := gate.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Should wait until the context is done: %v", err)
+	}
+}
+
+func Test_GateReady(t *testing.T) {
+	t.Parallel()
+
+	dbT := dbtest.NewDatabase(t, c)
+
+	latest, err := migrate.Latest()
+	if err != nil {
+		t.Fatalf("Should be able to get the latest version: %v", err)
+	}
+
+	gate := migrate.NewGate()
+
+	if err := gate.Run(context.Background(), dbtest.Log(), dbT); err != nil {
+		t.Fatalf("Should be able to migrate: %v", err)
+	}
+
+	if !gate.Ready() || gate.Err() != nil {
+		t.Fatalf("Should open the gate: %v", gate.Err())
+	}
+
+	if gate.Version() != latest {
+		t.Fatalf("Should record version %d: got %d", latest, gate.Version())
+	}
+
+	if err := gate.Wait(context.Background()); err != nil {
+		t.Fatalf("Should not wait on an open gate: %v", err)
+	}
+}
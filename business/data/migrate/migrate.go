@@ -0,0 +1,415 @@
+// Package migrate contains the database schema, migrations and seeding data.
+// Migrations are versioned sql files embedded in the binary and applied in
+// order under a Postgres advisory lock so replicas don't race each other.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// lockID is the key of the advisory lock held while migrations run. The
+// value is arbitrary but must be the same for every replica.
+const lockID = 7480122049
+
+var (
+	//go:embed sql
+	migrationFS embed.FS
+
+	//go:embed seed/seed.sql
+	seedDoc string
+)
+
+// ErrChecksumMismatch is returned when an applied migration has been changed
+// after it was applied.
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// Migration represents a single versioned schema change.
+type Migration struct {
+	Version  int
+	Name     string
+	Checksum string
+	Query    string
+}
+
+// Status represents the state of a single migration in the database.
+type Status struct {
+	Version     int
+	Name        string
+	Applied     bool
+	DateApplied time.Time
+	Modified    bool
+}
+
+// =============================================================================
+
+// Migrations returns the set of embedded migrations ordered by version.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFS, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		version, name, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		if other, exists := seen[version]; exists {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		data, err := fs.ReadFile(migrationFS, path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading migration[%s]: %w", entry.Name(), err)
+		}
+
+		sum := sha256.Sum256(data)
+
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     name,
+			Checksum: hex.EncodeToString(sum[:]),
+			Query:    string(data),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrate attempts to bring the database up to date with the migrations
+// defined in this package. The advisory lock is held for the duration so
+// only one replica applies migrations at a time. It returns the migrations
+// that were applied.
+func Migrate(ctx context.Context, log *logger.Logger, sqlxDB *sqlx.DB) ([]Migration, error) {
+	if err := db.StatusCheck(ctx, sqlxDB); err != nil {
+		return nil, fmt.Errorf("status check database: %w", err)
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sqlxDB.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	// Waiting on the lock and applying a migration can take longer than the
+	// statement timeout of the service, the context bounds them instead.
+	if _, err := conn.ExecContext(ctx, `SET statement_timeout = 0`); err != nil {
+		return nil, fmt.Errorf("clearing statement timeout: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `RESET statement_timeout`); err != nil {
+			log.Error(ctx, "migrate", "status", "resetting statement timeout", "msg", err)
+		}
+	}()
+
+	log.Info(ctx, "migrate", "status", "acquiring lock", "lockid", lockID)
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return nil, fmt.Errorf("acquiring lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID); err != nil {
+			log.Error(ctx, "migrate", "status", "releasing lock", "msg", err)
+		}
+	}()
+
+	if err := createVersionTable(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	applied, err := queryApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := plan(migrations, applied)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range pending {
+		log.Info(ctx, "migrate", "status", "applying", "version", m.Version, "name", m.Name)
+
+		if err := apply(ctx, conn, m); err != nil {
+			return done, fmt.Errorf("version[%d] name[%s]: %w", m.Version, m.Name, err)
+		}
+
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Statuses returns the state of every known migration in the database.
+func Statuses(ctx context.Context, sqlxDB *sqlx.DB) ([]Status, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sqlxDB.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	applied := make(map[int]appliedMigration)
+
+	exists, err := versionTableExists(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		if applied, err = queryApplied(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, m := range migrations {
+		a, ok := applied[m.Version]
+
+		statuses[i] = Status{
+			Version:     m.Version,
+			Name:        m.Name,
+			Applied:     ok,
+			DateApplied: a.dateApplied,
+			Modified:    ok && a.checksum != m.Checksum,
+		}
+	}
+
+	return statuses, nil
+}
+
+// Version returns the highest migration version applied to the database, or
+// zero when no migrations have been applied.
+func Version(ctx context.Context, sqlxDB *sqlx.DB) (int, error) {
+	conn, err := sqlxDB.Connx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+
+	exists, err := versionTableExists(ctx, conn)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("querying version: %w", err)
+	}
+
+	return int(version.Int64), nil
+}
+
+// Latest returns the version of the newest embedded migration.
+func Latest() (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// Seed runs the seed document defined in this package against db. The
+// queries are run in a transaction and rolled back if any fail.
+func Seed(ctx context.Context, sqlxDB *sqlx.DB) (err error) {
+	if err := db.StatusCheck(ctx, sqlxDB); err != nil {
+		return fmt.Errorf("status check database: %w", err)
+	}
+
+	tx, err := sqlxDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if errTx := tx.Rollback(); errTx != nil {
+			if errors.Is(errTx, sql.ErrTxDone) {
+				return
+			}
+
+			err = fmt.Errorf("rollback: %w", errTx)
+			return
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, seedDoc); err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// appliedMigration represents a row in the schema_migrations table.
+type appliedMigration struct {
+	checksum    string
+	dateApplied time.Time
+}
+
+// parseFileName extracts the version and name from a file named like
+// 0001_create_api_keys.sql.
+func parseFileName(fileName string) (int, string, error) {
+	base := strings.TrimSuffix(fileName, ".sql")
+
+	v, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, "", fmt.Errorf("migration[%s]: expected <version>_<name>.sql", fileName)
+	}
+
+	version, err := strconv.Atoi(v)
+	if err != nil || version <= 0 {
+		return 0, "", fmt.Errorf("migration[%s]: invalid version %q", fileName, v)
+	}
+
+	return version, name, nil
+}
+
+// plan returns the migrations that still need to be applied. Nothing is
+// applied when a migration that was already applied has been changed since.
+func plan(migrations []Migration, applied map[int]appliedMigration) ([]Migration, error) {
+	var pending []Migration
+	for _, m := range migrations {
+		a, exists := applied[m.Version]
+		if !exists {
+			pending = append(pending, m)
+			continue
+		}
+
+		if a.checksum != m.Checksum {
+			return nil, fmt.Errorf("version[%d] name[%s]: %w", m.Version, m.Name, ErrChecksumMismatch)
+		}
+	}
+
+	return pending, nil
+}
+
+func createVersionTable(ctx context.Context, conn *sqlx.Conn) error {
+	const q = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version      INT       NOT NULL,
+		name         TEXT      NOT NULL,
+		checksum     TEXT      NOT NULL,
+		date_applied TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+
+		PRIMARY KEY (version)
+	)`
+
+	if _, err := conn.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("creating version table: %w", err)
+	}
+
+	return nil
+}
+
+func versionTableExists(ctx context.Context, conn *sqlx.Conn) (bool, error) {
+	const q = `SELECT to_regclass('schema_migrations') IS NOT NULL`
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, q).Scan(&exists); err != nil {
+		return false, fmt.Errorf("checking version table: %w", err)
+	}
+
+	return exists, nil
+}
+
+func queryApplied(ctx context.Context, conn *sqlx.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, checksum, date_applied FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("querying applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.checksum, &a.dateApplied); err != nil {
+			return nil, fmt.Errorf("scanning applied migration: %w", err)
+		}
+		applied[version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// apply runs a single migration and records it in the same transaction.
+func apply(ctx context.Context, conn *sqlx.Conn, m Migration) (err error) {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	defer func() {
+		if errTx := tx.Rollback(); errTx != nil {
+			if errors.Is(errTx, sql.ErrTxDone) {
+				return
+			}
+
+			err = fmt.Errorf("rollback: %w", errTx)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, m.Query); err != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	const q = `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, q, m.Version, m.Name, m.Checksum); err != nil {
+		return fmt.Errorf("recording version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
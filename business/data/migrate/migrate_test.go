@@ -0,0 +1,139 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbtest"
+	"github.com/vikaskumar1187/publisher_saas/business/data/migrate"
+	"github.com/vikaskumar1187/publisher_saas/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	code := m.Run()
+	dbtest.StopDB(c)
+
+	os.Exit(code)
+}
+
+func Test_MigrateChecksum(t *testing.T) {
+	t.Parallel()
+
+	db := dbtest.NewDatabase(t, c)
+	ctx := context.Background()
+
+	latest, err := migrate.Latest()
+	if err != nil {
+		t.Fatalf("Should be able to get the latest version: %v", err)
+	}
+
+	done, err := migrate.Migrate(ctx, dbtest.Log(), db)
+	if err != nil {
+		t.Fatalf("Should be able to migrate: %v", err)
+	}
+
+	if len(done) == 0 || done[len(done)-1].Version != latest {
+		t.Fatalf("Should apply every migration up to %d: got %d", latest, len(done))
+	}
+
+	done, err = migrate.Migrate(ctx, dbtest.Log(), db)
+	if err != nil || len(done) != 0 {
+		t.Fatalf("Should apply nothing on the second run: got %d: %v", len(done), err)
+	}
+
+	if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET checksum = 'changed' WHERE version = 1`); err != nil {
+		t.Fatalf("Should be able to change the checksum: %v", err)
+	}
+
+	if _, err := migrate.Migrate(ctx, dbtest.Log(), db); !errors.Is(err, migrate.ErrChecksumMismatch) {
+		t.Fatalf("Should refuse to migrate after a migration changed: %v", err)
+	}
+
+	statuses, err := migrate.Statuses(ctx, db)
+	if err != nil {
+		t.Fatalf("Should be able to query the statuses: %v", err)
+	}
+
+	for _, s := range statuses {
+		if !s.Applied || s.Modified != (s.Version == 1) {
+			t.Fatalf("Should report version %d applied and modified %t: got %+v", s.Version, s.Version == 1, s)
+		}
+	}
+}
+
+func Test_MigrateLock(t *testing.T) {
+	t.Parallel()
+
+	db := dbtest.NewDatabase(t, c)
+	ctx := context.Background()
+
+	// While another session holds the lock, a migration has to wait.
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		t.Fatalf("Should be able to acquire a connection: %v", err)
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(7480122049)`); err != nil {
+		t.Fatalf("Should be able to take the lock: %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	if _, err := migrate.Migrate(waitCtx, dbtest.Log(), db); err == nil {
+		t.Fatal("Should not migrate while the lock is held")
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(7480122049)`); err != nil {
+		t.Fatalf("Should be able to release the lock: %v", err)
+	}
+	conn.Close()
+
+	// Replicas starting together apply every migration exactly once.
+	const replicas = 5
+
+	var wg sync.WaitGroup
+	applied := make([]int, replicas)
+	errs := make([]error, replicas)
+
+	for i := 0; i < replicas; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			done, err := migrate.Migrate(ctx, dbtest.Log(), db)
+			applied[i], errs[i] = len(done), err
+		}(i)
+	}
+	wg.Wait()
+
+	migrations, err := migrate.Migrations()
+	if err != nil {
+		t.Fatalf("Should be able to read the migrations: %v", err)
+	}
+
+	var total int
+	for i := range applied {
+		if errs[i] != nil {
+			t.Fatalf("Should migrate from every replica: %v", errs[i])
+		}
+		total += applied[i]
+	}
+
+	if total != len(migrations) {
+		t.Fatalf("Should apply each migration once: got %d applies for %d migrations", total, len(migrations))
+	}
+}
@@ -0,0 +1,116 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"path"
+	"testing"
+)
+
+func Test_Migrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Should be able to read the migrations: %v", err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("Should embed the migrations")
+	}
+
+	entries, err := fs.ReadDir(migrationFS, "sql")
+	if err != nil {
+		t.Fatalf("Should be able to read the migration files: %v", err)
+	}
+
+	for i, m := range migrations {
+		if i > 0 && migrations[i-1].Version >= m.Version {
+			t.Fatalf("Should order the migrations by version: %d after %d", m.Version, migrations[i-1].Version)
+		}
+
+		data, err := fs.ReadFile(migrationFS, path.Join("sql", entries[i].Name()))
+		if err != nil {
+			t.Fatalf("Should be able to read migration %s: %v", entries[i].Name(), err)
+		}
+
+		sum := sha256.Sum256(data)
+		if m.Checksum != hex.EncodeToString(sum[:]) {
+			t.Fatalf("Should checksum migration %d with sha256 of the file", m.Version)
+		}
+	}
+}
+
+func Test_ParseFileName(t *testing.T) {
+	tests := []struct {
+		file    string
+		version int
+		name    string
+		valid   bool
+	}{
+		{"0001_create_api_keys.sql", 1, "create_api_keys", true},
+		{"0042_x.sql", 42, "x", true},
+		{"create.sql", 0, "", false},
+		{"abc_create.sql", 0, "", false},
+		{"0000_create.sql", 0, "", false},
+		{"-1_create.sql", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		version, name, err := parseFileName(tt.file)
+
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("%s: Should get valid %t: %v", tt.file, tt.valid, err)
+			continue
+		}
+
+		if version != tt.version || name != tt.name {
+			t.Errorf("%s: Should get version %d name %q: got %d %q", tt.file, tt.version, tt.name, version, name)
+		}
+	}
+}
+
+func Test_Plan(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "one", Checksum: "a"},
+		{Version: 2, Name: "two", Checksum: "b"},
+		{Version: 3, Name: "three", Checksum: "c"},
+	}
+
+	tests := []struct {
+		name    string
+		applied map[int]appliedMigration
+		pending []int
+		err     error
+	}{
+		{"fresh", nil, []int{1, 2, 3}, nil},
+		{"partly applied", map[int]appliedMigration{1: {checksum: "a"}}, []int{2, 3}, nil},
+		{"up to date", map[int]appliedMigration{1: {checksum: "a"}, 2: {checksum: "b"}, 3: {checksum: "c"}}, nil, nil},
+		{"gap", map[int]appliedMigration{1: {checksum: "a"}, 3: {checksum: "c"}}, []int{2}, nil},
+		{"modified", map[int]appliedMigration{1: {checksum: "x"}}, nil, ErrChecksumMismatch},
+		{"modified after pending", map[int]appliedMigration{3: {checksum: "x"}}, nil, ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, err := plan(migrations, tt.applied)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Should get error %v: got %v", tt.err, err)
+			}
+
+			var versions []int
+			for _, m := range pending {
+				versions = append(versions, m.Version)
+			}
+
+			if len(versions) != len(tt.pending) {
+				t.Fatalf("Should get pending %v: got %v", tt.pending, versions)
+			}
+			for i := range versions {
+				if versions[i] != tt.pending[i] {
+					t.Fatalf("Should get pending %v: got %v", tt.pending, versions)
+				}
+			}
+		})
+	}
+}
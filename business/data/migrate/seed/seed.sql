@@ -0,0 +1,5 @@
+-- Development api key. The secret for this key is:
+-- pub_000000000000.ZGV2LW9ubHktc2VjcmV0LWRvLW5vdC11c2UtaW4tcHJvZA
+INSERT INTO api_keys (key_id, org, name, prefix, key_hash, permissions, created_by, date_created, date_updated) VALUES
+	('5cf37266-3473-4006-984f-9325122678b7', 'dev', 'Development CI', '000000000000', 'b31f3e3a93e070e894bb69b4a642bc2f40b51570d5aeacc2d73f9d499fdfb01b', '{pagehub:read,pagehub:publish}', 'seed', '2024-01-01 00:00:00', '2024-01-01 00:00:00')
+	ON CONFLICT DO NOTHING;
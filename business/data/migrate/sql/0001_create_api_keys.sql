@@ -0,0 +1,18 @@
+-- Description: Create table api_keys
+CREATE TABLE api_keys (
+	key_id       UUID        NOT NULL,
+	org          TEXT        NOT NULL,
+	name         TEXT        NOT NULL,
+	prefix       TEXT        NOT NULL,
+	key_hash     TEXT        NOT NULL,
+	permissions  TEXT[]      NOT NULL,
+	created_by   TEXT        NOT NULL,
+	date_created TIMESTAMP   NOT NULL,
+	date_updated TIMESTAMP   NOT NULL,
+	date_revoked TIMESTAMP   NULL,
+
+	PRIMARY KEY (key_id),
+	UNIQUE (prefix)
+);
+
+CREATE INDEX api_keys_org_idx ON api_keys (org);
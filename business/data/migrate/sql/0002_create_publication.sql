@@ -0,0 +1,37 @@
+-- Description: Create tables editions and pages
+CREATE TABLE editions (
+	edition_id   UUID      NOT NULL,
+	org          TEXT      NOT NULL,
+	name         TEXT      NOT NULL,
+	issue_date   DATE      NOT NULL,
+	created_by   TEXT      NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_updated TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (edition_id)
+);
+
+CREATE INDEX editions_org_issue_date_idx ON editions (org, issue_date DESC);
+
+CREATE TABLE pages (
+	page_id      UUID      NOT NULL,
+	org          TEXT      NOT NULL,
+	edition_id   UUID      NULL,
+	title        TEXT      NOT NULL,
+	slug         TEXT      NOT NULL,
+	content      TEXT      NOT NULL,
+	status       TEXT      NOT NULL,
+	scheduled_at TIMESTAMP NULL,
+	published_at TIMESTAMP NULL,
+	created_by   TEXT      NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_updated TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (page_id),
+	UNIQUE (org, slug),
+	FOREIGN KEY (edition_id) REFERENCES editions(edition_id) ON DELETE SET NULL,
+	CHECK (status IN ('draft', 'scheduled', 'published', 'unpublished'))
+);
+
+CREATE INDEX pages_org_status_idx ON pages (org, status);
+CREATE INDEX pages_edition_idx ON pages (edition_id);
@@ -0,0 +1,23 @@
+-- Description: Create table jobs
+CREATE TABLE jobs (
+	job_id       UUID      NOT NULL,
+	queue        TEXT      NOT NULL,
+	kind         TEXT      NOT NULL,
+	payload      JSONB     NOT NULL,
+	status       TEXT      NOT NULL,
+	attempts     INT       NOT NULL,
+	max_attempts INT       NOT NULL,
+	run_at       TIMESTAMP NOT NULL,
+	lock_token   UUID      NULL,
+	locked_until TIMESTAMP NULL,
+	last_error   TEXT      NOT NULL DEFAULT '',
+	date_created TIMESTAMP NOT NULL,
+	date_updated TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (job_id),
+	CHECK (status IN ('pending', 'running', 'succeeded', 'dead'))
+);
+
+CREATE INDEX jobs_pending_idx ON jobs (queue, run_at) WHERE status = 'pending';
+CREATE INDEX jobs_running_idx ON jobs (queue, locked_until) WHERE status = 'running';
+CREATE INDEX jobs_dead_idx ON jobs (queue, date_updated DESC) WHERE status = 'dead';
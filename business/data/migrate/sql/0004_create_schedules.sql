@@ -0,0 +1,25 @@
+-- Description: Create table schedules
+CREATE TABLE schedules (
+	schedule_id  UUID      NOT NULL,
+	org          TEXT      NOT NULL,
+	name         TEXT      NOT NULL,
+	target_type  TEXT      NOT NULL,
+	target_id    UUID      NULL,
+	spec         TEXT      NOT NULL DEFAULT '',
+	run_at       TIMESTAMP NULL,
+	time_zone    TEXT      NOT NULL,
+	catch_up     TEXT      NOT NULL DEFAULT '',
+	enabled      BOOLEAN   NOT NULL,
+	next_run_at  TIMESTAMP NULL,
+	last_run_at  TIMESTAMP NULL,
+	created_by   TEXT      NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_updated TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (schedule_id),
+	CHECK (target_type IN ('page', 'edition', 'issue')),
+	CHECK (catch_up IN ('', 'all', 'latest', 'skip'))
+);
+
+CREATE INDEX schedules_org_idx ON schedules (org, date_created DESC);
+CREATE INDEX schedules_due_idx ON schedules (next_run_at) WHERE enabled;
@@ -0,0 +1,38 @@
+-- Description: Create tables webhook_subscriptions and webhook_deliveries
+CREATE TABLE webhook_subscriptions (
+	subscription_id UUID      NOT NULL,
+	org             TEXT      NOT NULL,
+	url             TEXT      NOT NULL,
+	events          TEXT[]    NOT NULL,
+	secret          TEXT      NOT NULL,
+	active          BOOLEAN   NOT NULL,
+	created_by      TEXT      NOT NULL,
+	date_created    TIMESTAMP NOT NULL,
+	date_updated    TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (subscription_id)
+);
+
+CREATE INDEX webhook_subscriptions_org_idx ON webhook_subscriptions (org, date_created DESC);
+
+CREATE TABLE webhook_deliveries (
+	delivery_id      UUID      NOT NULL,
+	subscription_id  UUID      NOT NULL,
+	org              TEXT      NOT NULL,
+	event_id         UUID      NOT NULL,
+	event_type       TEXT      NOT NULL,
+	payload          JSONB     NOT NULL,
+	status           TEXT      NOT NULL,
+	attempts         INT       NOT NULL DEFAULT 0,
+	last_status_code INT       NOT NULL DEFAULT 0,
+	last_error       TEXT      NOT NULL DEFAULT '',
+	date_created     TIMESTAMP NOT NULL,
+	date_updated     TIMESTAMP NOT NULL,
+	date_delivered   TIMESTAMP NULL,
+
+	PRIMARY KEY (delivery_id),
+	FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(subscription_id) ON DELETE CASCADE,
+	CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, date_created DESC);
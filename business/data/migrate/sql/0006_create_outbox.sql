@@ -0,0 +1,18 @@
+-- Description: Create table outbox
+CREATE TABLE outbox (
+	seq             BIGSERIAL NOT NULL,
+	event_id        UUID      NOT NULL,
+	org             TEXT      NOT NULL,
+	aggregate_type  TEXT      NOT NULL,
+	aggregate_id    TEXT      NOT NULL,
+	event_type      TEXT      NOT NULL,
+	payload         JSONB     NOT NULL,
+	date_created    TIMESTAMP NOT NULL,
+	date_dispatched TIMESTAMP NULL,
+
+	PRIMARY KEY (seq),
+	UNIQUE (event_id)
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (seq) WHERE date_dispatched IS NULL;
+CREATE INDEX outbox_dispatched_idx ON outbox (date_dispatched) WHERE date_dispatched IS NOT NULL;
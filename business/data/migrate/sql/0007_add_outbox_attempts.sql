@@ -0,0 +1,9 @@
+-- Description: Add attempts and dead lettering to the outbox
+ALTER TABLE outbox
+	ADD COLUMN attempts        INT       NOT NULL DEFAULT 0,
+	ADD COLUMN next_attempt_at TIMESTAMP NULL,
+	ADD COLUMN last_error      TEXT      NOT NULL DEFAULT '',
+	ADD COLUMN date_dead       TIMESTAMP NULL;
+
+CREATE INDEX outbox_aggregate_pending_idx ON outbox (aggregate_type, aggregate_id, seq) WHERE date_dispatched IS NULL AND date_dead IS NULL;
+CREATE INDEX outbox_dead_idx ON outbox (date_dead DESC) WHERE date_dead IS NOT NULL;
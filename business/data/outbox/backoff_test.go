@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Backoff(t *testing.T) {
+	r := NewRelay(RelayConfig{
+		BackoffBase: time.Second,
+		BackoffMax:  time.Minute,
+	})
+
+	if r.maxAttempts != 10 {
+		t.Fatalf("Should default to 10 attempts: got %d", r.maxAttempts)
+	}
+
+	tests := []struct {
+		attempt int
+		delay   time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{50, time.Minute},
+	}
+
+	for _, tt := range tests {
+		low := tt.delay - tt.delay/10
+		high := tt.delay + tt.delay/10
+
+		for i := 0; i < 100; i++ {
+			if d := r.backoff(tt.attempt); d < low || d > high {
+				t.Fatalf("attempt %d: Should back off %v with 10%% jitter: got %v", tt.attempt, tt.delay, d)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dbEvent represent the structure we need for moving data
+// between the app and the database.
+type dbEvent struct {
+	Seq            int64        `db:"seq"`
+	ID             uuid.UUID    `db:"event_id"`
+	Org            string       `db:"org"`
+	AggregateType  string       `db:"aggregate_type"`
+	AggregateID    string       `db:"aggregate_id"`
+	Type           string       `db:"event_type"`
+	Payload        []byte       `db:"payload" log:"redact"`
+	DateCreated    time.Time    `db:"date_created"`
+	DateDispatched sql.NullTime `db:"date_dispatched"`
+	Attempts       int          `db:"attempts"`
+	NextAttemptAt  sql.NullTime `db:"next_attempt_at"`
+	LastError      string       `db:"last_error"`
+	DateDead       sql.NullTime `db:"date_dead"`
+}
+
+func toDBEvent(evt Event) dbEvent {
+	return dbEvent{
+		Seq:           evt.Seq,
+		ID:            evt.ID,
+		Org:           evt.Org,
+		AggregateType: evt.AggregateType,
+		AggregateID:   evt.AggregateID,
+		Type:          evt.Type,
+		Payload:       evt.Payload,
+		DateCreated:   evt.DateCreated.UTC(),
+	}
+}
+
+func toEvent(dbEvt dbEvent) Event {
+	return Event{
+		Seq:           dbEvt.Seq,
+		ID:            dbEvt.ID,
+		Org:           dbEvt.Org,
+		AggregateType: dbEvt.AggregateType,
+		AggregateID:   dbEvt.AggregateID,
+		Type:          dbEvt.Type,
+		Payload:       dbEvt.Payload,
+		DateCreated:   dbEvt.DateCreated.In(time.Local),
+	}
+}
+
+func toEventSlice(dbEvts []dbEvent) []Event {
+	evts := make([]Event, len(dbEvts))
+	for i, dbEvt := range dbEvts {
+		evts[i] = toEvent(dbEvt)
+	}
+	return evts
+}
@@ -0,0 +1,101 @@
+// Package outbox provides a transactional outbox for domain events. Events
+// are written to the outbox table in the same transaction as the change that
+// caused them, so an event exists if and only if the change was committed. A
+// relay reads the committed events in order and hands them to the sinks.
+//
+// Delivery is at-least-once: an event is marked dispatched only after every
+// sink accepted it, so sinks must tolerate seeing an event more than once and
+// can use its ID to drop duplicates. Events of the same aggregate are
+// delivered in the order they were written. This relies on the writer
+// holding a lock on the aggregate, such as the row lock taken by updating
+// it, before the event is written. An event the sinks keep failing is
+// retried with backoff and then dead lettered, which is the one case where
+// a later event of the aggregate is delivered without it.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Event represents a domain event written to the outbox. Seq is the position
+// of the event in the outbox and the payload is the JSON encoded data.
+type Event struct {
+	Seq           int64           `json:"seq"`
+	ID            uuid.UUID       `json:"id"`
+	Org           string          `json:"org"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateID   string          `json:"aggregateId"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	DateCreated   time.Time       `json:"dateCreated"`
+}
+
+// Decode unmarshals the payload of the event into the specified value.
+func (e Event) Decode(v any) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("decoding payload: type[%s]: %w", e.Type, err)
+	}
+
+	return nil
+}
+
+// NewEvent is what we require when writing an Event. The data is marshaled to
+// JSON.
+type NewEvent struct {
+	Org           string
+	AggregateType string
+	AggregateID   string
+	Type          string
+	Data          any
+}
+
+// Write adds the event to the outbox. Pass the transaction of the change the
+// event describes so both are committed together.
+func Write(ctx context.Context, log *logger.Logger, ext sqlx.ExtContext, ne NewEvent) (Event, error) {
+	if ne.Type == "" || ne.AggregateType == "" || ne.AggregateID == "" {
+		return Event{}, errors.New("event type and aggregate are required")
+	}
+
+	payload, err := json.Marshal(ne.Data)
+	if err != nil {
+		return Event{}, fmt.Errorf("encoding payload: type[%s]: %w", ne.Type, err)
+	}
+
+	evt := Event{
+		ID:            uuid.New(),
+		Org:           ne.Org,
+		AggregateType: ne.AggregateType,
+		AggregateID:   ne.AggregateID,
+		Type:          ne.Type,
+		Payload:       payload,
+		DateCreated:   time.Now().UTC(),
+	}
+
+	const q = `
+	INSERT INTO outbox
+		(event_id, org, aggregate_type, aggregate_id, event_type, payload, date_created)
+	VALUES
+		(:event_id, :org, :aggregate_type, :aggregate_id, :event_type, CAST(:payload AS JSONB), :date_created)
+	RETURNING
+		seq`
+
+	var row struct {
+		Seq int64 `db:"seq"`
+	}
+	if err := db.NamedQueryStruct(ctx, log, ext, q, toDBEvent(evt), &row); err != nil {
+		return Event{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	evt.Seq = row.Seq
+
+	return evt, nil
+}
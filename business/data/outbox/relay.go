@@ -0,0 +1,430 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx/dbarray"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// LockID is the key of the advisory lock that elects the replica running the
+// relay. Only one relay may run at a time to keep the events of an aggregate
+// in order.
+const LockID = 7480122051
+
+// This holds the metrics for the relay. The expvar package is based on a
+// singleton so these are registered once for the process.
+var om = struct {
+	dispatched  *expvar.Int
+	errors      *expvar.Int
+	dead        *expvar.Int
+	pending     *expvar.Int
+	lag         *expvar.Float
+	dispatchLag *expvar.Float
+	lastSeq     *expvar.Int
+}{
+	dispatched:  expvar.NewInt("outbox_dispatched"),
+	errors:      expvar.NewInt("outbox_errors"),
+	dead:        expvar.NewInt("outbox_dead"),
+	pending:     expvar.NewInt("outbox_pending"),
+	lag:         expvar.NewFloat("outbox_lag_seconds"),
+	dispatchLag: expvar.NewFloat("outbox_dispatch_lag_seconds"),
+	lastSeq:     expvar.NewInt("outbox_last_seq"),
+}
+
+// RelayConfig represents the settings of the relay.
+type RelayConfig struct {
+	Log          *logger.Logger
+	DB           *sqlx.DB
+	Pool         *db.Pool
+	Sinks        []Sink
+	PollInterval time.Duration
+	BatchSize    int
+	Retention    time.Duration
+	MaxAttempts  int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+}
+
+// Relay reads the committed events from the outbox and hands them to the
+// sinks.
+type Relay struct {
+	log          *logger.Logger
+	db           *sqlx.DB
+	pool         *db.Pool
+	sinks        []Sink
+	pollInterval time.Duration
+	batchSize    int
+	retention    time.Duration
+	maxAttempts  int
+	backoffBase  time.Duration
+	backoffMax   time.Duration
+	lastPrune    time.Time
+}
+
+// NewRelay constructs a Relay for the sinks.
+func NewRelay(cfg RelayConfig) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = time.Second
+	}
+
+	if cfg.BackoffMax < cfg.BackoffBase {
+		cfg.BackoffMax = cfg.BackoffBase
+	}
+
+	return &Relay{
+		log:          cfg.Log,
+		db:           cfg.DB,
+		pool:         cfg.Pool,
+		sinks:        cfg.Sinks,
+		pollInterval: cfg.PollInterval,
+		batchSize:    cfg.BatchSize,
+		retention:    cfg.Retention,
+		maxAttempts:  cfg.MaxAttempts,
+		backoffBase:  cfg.BackoffBase,
+		backoffMax:   cfg.BackoffMax,
+	}
+}
+
+// Run relays the events on the poll interval until the context is cancelled.
+// A full batch is followed by the next one straight away. It is meant to run
+// on the leader replica only.
+func (r *Relay) Run(ctx context.Context) {
+	// The relay polls the outbox, so its queries are only logged at Debug.
+	// It must never read a stale outbox from a replica.
+	ctx = db.WithPrimary(db.WithLogLevel(ctx, logger.LevelDebug))
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.RelayBatch(ctx)
+		if err != nil {
+			r.log.Error(ctx, "outbox relay", "status", "relaying events", "msg", err)
+		}
+
+		if err := r.measure(ctx); err != nil {
+			r.log.Error(ctx, "outbox relay", "status", "measuring lag", "msg", err)
+		}
+
+		if err := r.prune(ctx); err != nil {
+			r.log.Error(ctx, "outbox relay", "status", "pruning events", "msg", err)
+		}
+
+		if err == nil && n == r.batchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RelayBatch hands the next batch of events to the sinks in the order they
+// were written and returns the number that were dispatched. Only aggregates
+// whose oldest pending event is due are read, so an aggregate held back by a
+// failing event never takes up the batch of the others. A failed event is
+// retried with backoff, and the later events of its aggregate wait for it.
+// Once an event runs out of attempts it is dead lettered and the aggregate
+// carries on with the next one. The outcomes of the batch are recorded
+// together, in a single round trip when the relay has a pool.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	data := struct {
+		Limit int       `db:"limit"`
+		Now   time.Time `db:"now"`
+	}{
+		Limit: r.batchSize,
+		Now:   time.Now().UTC(),
+	}
+
+	const q = `
+	WITH heads AS (
+		SELECT DISTINCT ON (aggregate_type, aggregate_id)
+			aggregate_type, aggregate_id, next_attempt_at
+		FROM
+			outbox
+		WHERE
+			date_dispatched IS NULL AND date_dead IS NULL
+		ORDER BY
+			aggregate_type, aggregate_id, seq
+	)
+	SELECT
+		o.*
+	FROM
+		outbox o
+	JOIN
+		heads h ON h.aggregate_type = o.aggregate_type AND h.aggregate_id = o.aggregate_id
+	WHERE
+		o.date_dispatched IS NULL AND o.date_dead IS NULL AND
+		(h.next_attempt_at IS NULL OR h.next_attempt_at <= :now)
+	ORDER BY
+		o.seq
+	LIMIT :limit`
+
+	var dbEvts []dbEvent
+	if err := db.NamedQuerySlice(ctx, r.log, r.db, q, data, &dbEvts); err != nil {
+		return 0, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	blocked := make(map[string]bool)
+	sent := make(dbarray.Int64, 0, len(dbEvts))
+
+	var stmts []statement
+	var dead int64
+
+	for _, dbEvt := range dbEvts {
+		evt := toEvent(dbEvt)
+
+		aggregate := evt.AggregateType + "/" + evt.AggregateID
+		if blocked[aggregate] {
+			continue
+		}
+
+		if err := r.send(ctx, evt); err != nil {
+			om.errors.Add(1)
+			blocked[aggregate] = true
+			r.log.Error(ctx, "outbox relay", "status", "sending event", "seq", evt.Seq, "eventID", evt.ID, "type", evt.Type, "aggregate", aggregate, "attempt", dbEvt.Attempts+1, "msg", err)
+
+			stmt, isDead := r.failed(dbEvt, err)
+			if isDead {
+				dead++
+				r.log.Error(ctx, "outbox relay", "status", "event dead lettered", "seq", evt.Seq, "eventID", evt.ID, "type", evt.Type, "attempts", dbEvt.Attempts+1)
+			}
+
+			stmts = append(stmts, stmt)
+			continue
+		}
+
+		sent = append(sent, evt.Seq)
+		om.lastSeq.Set(evt.Seq)
+		om.dispatchLag.Set(time.Since(evt.DateCreated).Seconds())
+	}
+
+	if len(sent) > 0 {
+		stmts = append(stmts, dispatched(sent))
+	}
+
+	if err := r.exec(ctx, stmts); err != nil {
+		return 0, err
+	}
+
+	om.dead.Add(dead)
+	om.dispatched.Add(int64(len(sent)))
+
+	return len(sent), nil
+}
+
+// =============================================================================
+
+// send hands the event to every sink.
+func (r *Relay) send(ctx context.Context, evt Event) error {
+	for _, sink := range r.sinks {
+		if err := sink.Send(ctx, evt); err != nil {
+			return fmt.Errorf("sink[%s]: %w", sink.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// statement is a query with its named parameters, for the updates the relay
+// records the outcome of a batch with.
+type statement struct {
+	query string
+	data  any
+}
+
+// exec runs the statements. With a pool they are sent as one batch,
+// otherwise one at a time.
+func (r *Relay) exec(ctx context.Context, stmts []statement) error {
+	if len(stmts) == 0 {
+		return nil
+	}
+
+	if r.pool != nil {
+		var b db.Batch
+		for _, stmt := range stmts {
+			if err := b.Queue(stmt.query, stmt.data); err != nil {
+				return fmt.Errorf("queue: %w", err)
+			}
+		}
+
+		if err := db.SendBatch(ctx, r.log, r.pool, &b); err != nil {
+			return fmt.Errorf("sendbatch: %w", err)
+		}
+
+		return nil
+	}
+
+	for _, stmt := range stmts {
+		if err := db.NamedExecContext(ctx, r.log, r.db, stmt.query, stmt.data); err != nil {
+			return fmt.Errorf("namedexeccontext: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// dispatched returns the statement that records the events were accepted by
+// the sinks. If it fails the events are sent again with the next batch.
+func dispatched(seqs dbarray.Int64) statement {
+	data := struct {
+		Seqs dbarray.Int64 `db:"seqs"`
+		Now  time.Time     `db:"now"`
+	}{
+		Seqs: seqs,
+		Now:  time.Now().UTC(),
+	}
+
+	const q = `
+	UPDATE
+		outbox
+	SET
+		date_dispatched = :now
+	WHERE
+		seq = ANY(:seqs)`
+
+	return statement{query: q, data: data}
+}
+
+// failed returns the statement that records the failed attempt of the event
+// and when to try it again. On the last attempt the event is dead lettered
+// instead, which is reported back.
+func (r *Relay) failed(dbEvt dbEvent, sendErr error) (statement, bool) {
+	now := time.Now().UTC()
+
+	data := struct {
+		Seq           int64        `db:"seq"`
+		Attempts      int          `db:"attempts"`
+		NextAttemptAt sql.NullTime `db:"next_attempt_at"`
+		LastError     string       `db:"last_error"`
+		DateDead      sql.NullTime `db:"date_dead"`
+	}{
+		Seq:       dbEvt.Seq,
+		Attempts:  dbEvt.Attempts + 1,
+		LastError: sendErr.Error(),
+	}
+
+	isDead := data.Attempts >= r.maxAttempts
+
+	switch {
+	case isDead:
+		data.DateDead = sql.NullTime{Time: now, Valid: true}
+
+	default:
+		data.NextAttemptAt = sql.NullTime{Time: now.Add(r.backoff(data.Attempts)), Valid: true}
+	}
+
+	const q = `
+	UPDATE
+		outbox
+	SET
+		attempts = :attempts,
+		next_attempt_at = :next_attempt_at,
+		last_error = :last_error,
+		date_dead = :date_dead
+	WHERE
+		seq = :seq`
+
+	return statement{query: q, data: data}, isDead
+}
+
+// backoff calculates the delay before the next attempt of an event. The
+// delay doubles on each attempt up to the maximum, with jitter.
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.backoffBase
+	for i := 1; i < attempt && delay < r.backoffMax; i++ {
+		delay *= 2
+	}
+
+	if delay > r.backoffMax {
+		delay = r.backoffMax
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+
+	return delay - delay/10 + jitter
+}
+
+// measure updates the lag metrics: the number of events waiting and the age
+// of the oldest one.
+func (r *Relay) measure(ctx context.Context) error {
+	const q = `
+	SELECT
+		count(1) AS pending,
+		COALESCE(EXTRACT(EPOCH FROM (now() AT TIME ZONE 'UTC') - min(date_created)), 0) AS lag
+	FROM
+		outbox
+	WHERE
+		date_dispatched IS NULL AND date_dead IS NULL`
+
+	var row struct {
+		Pending int64   `db:"pending"`
+		Lag     float64 `db:"lag"`
+	}
+	if err := db.QueryStruct(ctx, r.log, r.db, q, &row); err != nil {
+		return fmt.Errorf("querystruct: %w", err)
+	}
+
+	om.pending.Set(row.Pending)
+	om.lag.Set(row.Lag)
+
+	return nil
+}
+
+// prune deletes the dispatched events older than the retention, at most once
+// a minute. A zero retention keeps the events forever.
+func (r *Relay) prune(ctx context.Context) error {
+	if r.retention <= 0 || time.Since(r.lastPrune) < time.Minute {
+		return nil
+	}
+
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: time.Now().Add(-r.retention).UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		outbox
+	WHERE
+		seq IN (
+			SELECT seq FROM outbox
+			WHERE date_dispatched < :before
+			LIMIT 1000
+		)`
+
+	if err := db.NamedExecContext(ctx, r.log, r.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	r.lastPrune = time.Now()
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbtest"
+	"github.com/vikaskumar1187/publisher_saas/business/data/outbox"
+	"github.com/vikaskumar1187/publisher_saas/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	code := m.Run()
+	dbtest.StopDB(c)
+
+	os.Exit(code)
+}
+
+func Test_RelayOrder(t *testing.T) {
+	dbT := dbtest.NewMigrated(t, c)
+
+	write(t, dbT, "a", "a1", "b", "b1", "a", "a2", "b", "b2", "a", "a3")
+
+	sink := newSink("a1")
+	relay := newRelay(dbT, sink, 100, 5)
+
+	relayBatch(t, relay, 2)
+	sink.check(t, "b1", "b2")
+
+	// The failed event is due again after its backoff and the rest of the
+	// aggregate follows it in order.
+	sink.recover()
+	time.Sleep(50 * time.Millisecond)
+
+	relayBatch(t, relay, 3)
+	sink.check(t, "b1", "b2", "a1", "a2", "a3")
+}
+
+func Test_RelayPool(t *testing.T) {
+	pool := dbtest.NewMigratedPool(t, c)
+
+	write(t, pool.DB, "a", "a1", "b", "b1", "a", "a2")
+
+	sink := newSink("a1")
+	relay := outbox.NewRelay(outbox.RelayConfig{
+		Log:         dbtest.Log(),
+		DB:          pool.DB,
+		Pool:        pool,
+		Sinks:       []outbox.Sink{sink},
+		MaxAttempts: 1,
+	})
+
+	// The failed attempt and the dispatched event are recorded in one batch.
+	relayBatch(t, relay, 1)
+	sink.check(t, "b1")
+
+	relayBatch(t, relay, 1)
+	sink.check(t, "b1", "a2")
+
+	var pending int
+	if err := pool.DB.Get(&pending, "SELECT count(*) FROM outbox WHERE date_dispatched IS NULL AND date_dead IS NULL"); err != nil {
+		t.Fatalf("Should be able to count the pending events: %v", err)
+	}
+
+	if pending != 0 {
+		t.Fatalf("Should leave no pending events: got %d", pending)
+	}
+}
+
+func Test_RelayBlockedAggregate(t *testing.T) {
+	dbT := dbtest.NewMigrated(t, c)
+
+	write(t, dbT, "a", "a1", "a", "a2", "a", "a3", "a", "a4", "b", "b1")
+
+	sink := newSink("a1")
+	relay := outbox.NewRelay(outbox.RelayConfig{
+		Log:         dbtest.Log(),
+		DB:          dbT,
+		Sinks:       []outbox.Sink{sink},
+		BatchSize:   2,
+		BackoffBase: time.Hour,
+	})
+
+	relayBatch(t, relay, 0)
+	sink.check(t)
+
+	// While the first event of the aggregate waits out its backoff, the
+	// aggregate is left out of the batch so the other one gets through.
+	relayBatch(t, relay, 1)
+	sink.check(t, "b1")
+}
+
+func Test_RelayDeadLetter(t *testing.T) {
+	dbT := dbtest.NewMigrated(t, c)
+
+	write(t, dbT, "a", "a1", "a", "a2")
+
+	sink := newSink("a1")
+	relay := newRelay(dbT, sink, 100, 2)
+
+	relayBatch(t, relay, 0)
+	time.Sleep(50 * time.Millisecond)
+
+	relayBatch(t, relay, 0)
+	sink.check(t)
+
+	// The event ran out of attempts, so it stays in the outbox as dead and
+	// the aggregate carries on without it.
+	relayBatch(t, relay, 1)
+	sink.check(t, "a2")
+
+	const q = `SELECT attempts, last_error, date_dead IS NOT NULL AS dead FROM outbox WHERE event_type = 'a1'`
+
+	var row struct {
+		Attempts  int    `db:"attempts"`
+		LastError string `db:"last_error"`
+		Dead      bool   `db:"dead"`
+	}
+	if err := dbT.Get(&row, q); err != nil {
+		t.Fatalf("Should be able to query the event: %v", err)
+	}
+
+	if row.Attempts != 2 || !row.Dead || row.LastError == "" {
+		t.Fatalf("Should dead letter the event after 2 attempts: got %+v", row)
+	}
+}
+
+// =============================================================================
+
+// write adds events to the outbox given as pairs of aggregate id and event
+// type.
+func write(t *testing.T, dbT *sqlx.DB, pairs ...string) {
+	t.Helper()
+
+	for i := 0; i < len(pairs); i += 2 {
+		ne := outbox.NewEvent{
+			Org:           "sample",
+			AggregateType: "test",
+			AggregateID:   pairs[i],
+			Type:          pairs[i+1],
+			Data:          map[string]string{},
+		}
+
+		if _, err := outbox.Write(context.Background(), dbtest.Log(), dbT, ne); err != nil {
+			t.Fatalf("Should be able to write event %s: %v", pairs[i+1], err)
+		}
+	}
+}
+
+func newRelay(dbT *sqlx.DB, sink outbox.Sink, batchSize int, maxAttempts int) *outbox.Relay {
+	return outbox.NewRelay(outbox.RelayConfig{
+		Log:         dbtest.Log(),
+		DB:          dbT,
+		Sinks:       []outbox.Sink{sink},
+		BatchSize:   batchSize,
+		MaxAttempts: maxAttempts,
+		BackoffBase: time.Millisecond,
+		BackoffMax:  time.Millisecond,
+	})
+}
+
+func relayBatch(t *testing.T, relay *outbox.Relay, exp int) {
+	t.Helper()
+
+	n, err := relay.RelayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("Should be able to relay a batch: %v", err)
+	}
+
+	if n != exp {
+		t.Fatalf("Should dispatch %d events: got %d", exp, n)
+	}
+}
+
+// sink records the types of the events it accepts and fails the ones it is
+// told to.
+type sink struct {
+	mu   sync.Mutex
+	fail map[string]bool
+	got  []string
+}
+
+func newSink(fail ...string) *sink {
+	s := sink{fail: make(map[string]bool)}
+	for _, typ := range fail {
+		s.fail[typ] = true
+	}
+
+	return &s
+}
+
+func (s *sink) Name() string {
+	return "test"
+}
+
+func (s *sink) Send(ctx context.Context, evt outbox.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fail[evt.Type] {
+		return errors.New("sink down")
+	}
+
+	s.got = append(s.got, evt.Type)
+	return nil
+}
+
+func (s *sink) recover() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fail = make(map[string]bool)
+}
+
+func (s *sink) check(t *testing.T, exp ...string) {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fmt.Sprint(s.got) != fmt.Sprint(exp) {
+		t.Fatalf("Should send %v: got %v", exp, s.got)
+	}
+}
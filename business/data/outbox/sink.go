@@ -0,0 +1,128 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Sink represents a destination the relay hands events to. An event is sent
+// again when Send returns an error, or when any other sink failed, so Send
+// must be idempotent.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, evt Event) error
+}
+
+// =============================================================================
+
+// HandlerFunc represents a function that handles an event in process.
+type HandlerFunc func(ctx context.Context, evt Event) error
+
+// Bus is a sink that dispatches events to the handlers subscribed in the
+// same process.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]HandlerFunc
+}
+
+// NewBus constructs an in-process Bus.
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[string][]HandlerFunc),
+	}
+}
+
+// Subscribe registers the handler for the event type. Subscribing to the
+// empty type receives every event.
+func (b *Bus) Subscribe(eventType string, fn HandlerFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], fn)
+}
+
+// Name implements the Sink interface.
+func (b *Bus) Name() string {
+	return "bus"
+}
+
+// Send implements the Sink interface. Every handler of the event is called,
+// and the errors of the ones that failed are returned together.
+func (b *Bus) Send(ctx context.Context, evt Event) error {
+	b.mu.RLock()
+	handlers := make([]HandlerFunc, 0, len(b.handlers[evt.Type])+len(b.handlers[""]))
+	handlers = append(handlers, b.handlers[evt.Type]...)
+	handlers = append(handlers, b.handlers[""]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, fn := range handlers {
+		if err := fn(ctx, evt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// =============================================================================
+
+// FileSink is a sink that writes every event as a line of JSON. It is meant
+// for tests and local development, where the file can be tailed.
+type FileSink struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewFileSink constructs a FileSink that writes to the writer.
+func NewFileSink(w io.Writer) *FileSink {
+	return &FileSink{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// OpenFileSink constructs a FileSink that appends to the file at the path,
+// creating it when it doesn't exist.
+func OpenFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening outbox file: %w", err)
+	}
+
+	fs := NewFileSink(f)
+	fs.closer = f
+
+	return fs, nil
+}
+
+// Name implements the Sink interface.
+func (fs *FileSink) Name() string {
+	return "file"
+}
+
+// Send implements the Sink interface.
+func (fs *FileSink) Send(ctx context.Context, evt Event) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := fs.enc.Encode(evt); err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file the sink writes to, if it opened one.
+func (fs *FileSink) Close() error {
+	if fs.closer == nil {
+		return nil
+	}
+
+	return fs.closer.Close()
+}
@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_Backoff(t *testing.T) {
+	w := NewWorkers(WorkersConfig{
+		BackoffBase: time.Second,
+		BackoffMax:  10 * time.Second,
+	})
+
+	tests := []struct {
+		attempt int
+		delay   time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		low := tt.delay - tt.delay/10
+		high := tt.delay + tt.delay/10
+
+		for i := 0; i < 100; i++ {
+			if d := w.backoff(tt.attempt); d < low || d > high {
+				t.Fatalf("attempt %d: Should back off %v with 10%% jitter: got %v", tt.attempt, tt.delay, d)
+			}
+		}
+	}
+}
+
+func Test_Permanent(t *testing.T) {
+	err := errors.New("bad payload")
+
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"plain", err, false},
+		{"permanent", Permanent(err), true},
+		{"wrapped permanent", fmt.Errorf("handle: %w", Permanent(err)), true},
+	}
+
+	for _, tt := range tests {
+		if got := IsPermanent(tt.err); got != tt.permanent {
+			t.Errorf("%s: Should get permanent %t: got %t", tt.name, tt.permanent, got)
+		}
+
+		if !errors.Is(tt.err, err) {
+			t.Errorf("%s: Should unwrap to the original error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dbJob represent the structure we need for moving data
+// between the app and the database.
+type dbJob struct {
+	ID          uuid.UUID `db:"job_id"`
+	Queue       string    `db:"queue"`
+	Kind        string    `db:"kind"`
+	Payload     []byte    `db:"payload" log:"redact"`
+	Status      string    `db:"status"`
+	Attempts    int       `db:"attempts"`
+	MaxAttempts int       `db:"max_attempts"`
+	RunAt       time.Time `db:"run_at"`
+	LastError   string    `db:"last_error"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+func toDBJob(job Job) dbJob {
+	return dbJob{
+		ID:          job.ID,
+		Queue:       job.Queue,
+		Kind:        job.Kind,
+		Payload:     job.Payload,
+		Status:      job.Status,
+		Attempts:    job.Attempt,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       job.RunAt.UTC(),
+		LastError:   job.LastError,
+		DateCreated: job.DateCreated.UTC(),
+		DateUpdated: job.DateUpdated.UTC(),
+	}
+}
+
+func toJob(dbJob dbJob) Job {
+	return Job{
+		ID:          dbJob.ID,
+		Queue:       dbJob.Queue,
+		Kind:        dbJob.Kind,
+		Payload:     dbJob.Payload,
+		Status:      dbJob.Status,
+		Attempt:     dbJob.Attempts,
+		MaxAttempts: dbJob.MaxAttempts,
+		RunAt:       dbJob.RunAt.In(time.Local),
+		LastError:   dbJob.LastError,
+		DateCreated: dbJob.DateCreated.In(time.Local),
+		DateUpdated: dbJob.DateUpdated.In(time.Local),
+	}
+}
+
+func toJobs(dbJobs []dbJob) []Job {
+	jobs := make([]Job, len(dbJobs))
+	for i, dbJob := range dbJobs {
+		jobs[i] = toJob(dbJob)
+	}
+
+	return jobs
+}
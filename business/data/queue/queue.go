@@ -0,0 +1,225 @@
+// Package queue provides a durable job queue backed by Postgres. Jobs are
+// claimed with SELECT ... FOR UPDATE SKIP LOCKED so any number of workers,
+// across any number of replicas, can pull from the same table. A claimed job
+// is leased for a visibility timeout; if the worker disappears the lease
+// expires and the job becomes visible again. Failed jobs are retried with
+// exponential backoff and are dead-lettered once they run out of attempts.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// Set of defaults applied to new jobs.
+const (
+	DefaultQueue       = "default"
+	DefaultMaxAttempts = 5
+)
+
+// ErrNotFound is returned when a job can't be found.
+var ErrNotFound = errors.New("job not found")
+
+// Set of statuses a job moves through.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+)
+
+// Job represents a unit of work in the queue.
+type Job struct {
+	ID          uuid.UUID
+	Queue       string
+	Kind        string
+	Payload     json.RawMessage
+	Status      string
+	Attempt     int
+	MaxAttempts int
+	RunAt       time.Time
+	LastError   string
+	DateCreated time.Time
+	DateUpdated time.Time
+
+	lockToken uuid.UUID
+}
+
+// Decode unmarshals the payload of the job into the specified value.
+func (j Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("decoding payload: kind[%s]: %w", j.Kind, err)
+	}
+
+	return nil
+}
+
+// NewJob is what we require when adding a Job. The payload is marshaled to
+// JSON. A zero RunAt runs the job as soon as possible.
+type NewJob struct {
+	Queue       string
+	Kind        string
+	Payload     any
+	RunAt       time.Time
+	MaxAttempts int
+}
+
+// =============================================================================
+
+// Enqueue adds a new job to the queue. The db can be a transaction so the job
+// is only visible to workers once the surrounding changes are committed.
+func Enqueue(ctx context.Context, log *logger.Logger, ext sqlx.ExtContext, nj NewJob) (Job, error) {
+	if nj.Kind == "" {
+		return Job{}, errors.New("job kind is required")
+	}
+
+	if nj.Queue == "" {
+		nj.Queue = DefaultQueue
+	}
+
+	if nj.MaxAttempts <= 0 {
+		nj.MaxAttempts = DefaultMaxAttempts
+	}
+
+	payload, err := json.Marshal(nj.Payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("encoding payload: kind[%s]: %w", nj.Kind, err)
+	}
+
+	now := time.Now().UTC()
+
+	runAt := now
+	if !nj.RunAt.IsZero() {
+		runAt = nj.RunAt.UTC()
+	}
+
+	job := Job{
+		ID:          uuid.New(),
+		Queue:       nj.Queue,
+		Kind:        nj.Kind,
+		Payload:     payload,
+		Status:      StatusPending,
+		MaxAttempts: nj.MaxAttempts,
+		RunAt:       runAt,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	const q = `
+	INSERT INTO jobs
+		(job_id, queue, kind, payload, status, attempts, max_attempts, run_at, date_created, date_updated)
+	VALUES
+		(:job_id, :queue, :kind, CAST(:payload AS JSONB), :status, :attempts, :max_attempts, :run_at, :date_created, :date_updated)`
+
+	if err := db.NamedExecContext(ctx, log, ext, q, toDBJob(job)); err != nil {
+		return Job{}, fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return job, nil
+}
+
+// QueryByID finds the job by the specified ID.
+func QueryByID(ctx context.Context, log *logger.Logger, ext sqlx.ExtContext, jobID uuid.UUID) (Job, error) {
+	data := struct {
+		ID string `db:"job_id"`
+	}{
+		ID: jobID.String(),
+	}
+
+	const q = `
+	SELECT
+		job_id, queue, kind, payload, status, attempts, max_attempts, run_at, last_error, date_created, date_updated
+	FROM
+		jobs
+	WHERE
+		job_id = :job_id`
+
+	var dbJob dbJob
+	if err := db.NamedQueryStruct(ctx, log, ext, q, data, &dbJob); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return Job{}, fmt.Errorf("namedquerystruct: %w", ErrNotFound)
+		}
+		return Job{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toJob(dbJob), nil
+}
+
+// QueryDead retrieves the most recent dead-lettered jobs of the queue.
+func QueryDead(ctx context.Context, log *logger.Logger, ext sqlx.ExtContext, queue string, limit int) ([]Job, error) {
+	data := struct {
+		Queue  string `db:"queue"`
+		Status string `db:"status"`
+		Limit  int    `db:"limit"`
+	}{
+		Queue:  queue,
+		Status: StatusDead,
+		Limit:  limit,
+	}
+
+	const q = `
+	SELECT
+		job_id, queue, kind, payload, status, attempts, max_attempts, run_at, last_error, date_created, date_updated
+	FROM
+		jobs
+	WHERE
+		queue = :queue AND status = :status
+	ORDER BY
+		date_updated DESC
+	LIMIT :limit`
+
+	var dbJobs []dbJob
+	if err := db.NamedQuerySlice(ctx, log, ext, q, data, &dbJobs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toJobs(dbJobs), nil
+}
+
+// Requeue moves a dead-lettered job back to pending with a fresh set of
+// attempts so the workers pick it up again.
+func Requeue(ctx context.Context, log *logger.Logger, ext sqlx.ExtContext, jobID uuid.UUID) error {
+	job, err := QueryByID(ctx, log, ext, jobID)
+	if err != nil {
+		return err
+	}
+
+	if job.Status != StatusDead {
+		return fmt.Errorf("job[%s] is %s, only dead jobs can be requeued", jobID, job.Status)
+	}
+
+	data := struct {
+		ID     string `db:"job_id"`
+		Status string `db:"status"`
+		Dead   string `db:"dead"`
+	}{
+		ID:     jobID.String(),
+		Status: StatusPending,
+		Dead:   StatusDead,
+	}
+
+	const q = `
+	UPDATE
+		jobs
+	SET
+		"status" = :status,
+		"attempts" = 0,
+		"run_at" = now(),
+		"date_updated" = now()
+	WHERE
+		job_id = :job_id AND status = :dead`
+
+	if err := db.NamedExecContext(ctx, log, ext, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
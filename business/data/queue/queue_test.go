@@ -0,0 +1,240 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbtest"
+	"github.com/vikaskumar1187/publisher_saas/business/data/queue"
+	"github.com/vikaskumar1187/publisher_saas/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	code := m.Run()
+	dbtest.StopDB(c)
+
+	os.Exit(code)
+}
+
+func Test_Workers(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name        string
+		handler     func(calls int64) error
+		maxAttempts int
+		lease       func(t *testing.T, db *sqlx.DB, job queue.Job)
+		status      string
+		attempts    int
+		calls       int64
+	}{
+		{
+			name:        "succeeds",
+			handler:     func(calls int64) error { return nil },
+			maxAttempts: 3,
+			status:      queue.StatusSucceeded,
+			attempts:    1,
+			calls:       1,
+		},
+		{
+			name: "retries then succeeds",
+			handler: func(calls int64) error {
+				if calls < 3 {
+					return errFailed
+				}
+				return nil
+			},
+			maxAttempts: 3,
+			status:      queue.StatusSucceeded,
+			attempts:    3,
+			calls:       3,
+		},
+		{
+			name:        "dead-lettered after max attempts",
+			handler:     func(calls int64) error { return errFailed },
+			maxAttempts: 3,
+			status:      queue.StatusDead,
+			attempts:    3,
+			calls:       3,
+		},
+		{
+			name:        "dead-lettered when permanent",
+			handler:     func(calls int64) error { return queue.Permanent(errFailed) },
+			maxAttempts: 3,
+			status:      queue.StatusDead,
+			attempts:    1,
+			calls:       1,
+		},
+		{
+			name:        "expired lease reclaimed",
+			handler:     func(calls int64) error { return nil },
+			maxAttempts: 3,
+			lease:       expireLease(1),
+			status:      queue.StatusSucceeded,
+			attempts:    2,
+			calls:       1,
+		},
+		{
+			name:        "expired lease on final attempt",
+			handler:     func(calls int64) error { return nil },
+			maxAttempts: 3,
+			lease:       expireLease(3),
+			status:      queue.StatusDead,
+			attempts:    4,
+			calls:       0,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := dbtest.NewMigrated(t, c)
+			ctx := context.Background()
+
+			job, err := queue.Enqueue(ctx, dbtest.Log(), db, queue.NewJob{
+				Kind:        "test",
+				MaxAttempts: tt.maxAttempts,
+			})
+			if err != nil {
+				t.Fatalf("Should be able to enqueue a job: %v", err)
+			}
+
+			if tt.lease != nil {
+				tt.lease(t, db, job)
+			}
+
+			var calls atomic.Int64
+
+			workers := queue.NewWorkers(queue.WorkersConfig{
+				Log:          dbtest.Log(),
+				DB:           db,
+				PollInterval: 10 * time.Millisecond,
+				BackoffBase:  time.Millisecond,
+				BackoffMax:   time.Millisecond,
+			})
+			workers.Register("test", func(ctx context.Context, job queue.Job) error {
+				return tt.handler(calls.Add(1))
+			})
+			workers.Start()
+
+			got := waitForStatus(t, db, job, tt.status)
+
+			if err := workers.Shutdown(ctx); err != nil {
+				t.Fatalf("Should be able to stop the workers: %v", err)
+			}
+
+			if got.Attempt != tt.attempts || calls.Load() != tt.calls {
+				t.Fatalf("Should take %d attempts and %d calls: got %d and %d", tt.attempts, tt.calls, got.Attempt, calls.Load())
+			}
+
+			if tt.status == queue.StatusDead && got.LastError == "" {
+				t.Fatal("Should record the last error of a dead job")
+			}
+		})
+	}
+}
+
+func Test_Requeue(t *testing.T) {
+	t.Parallel()
+
+	db := dbtest.NewMigrated(t, c)
+	ctx := context.Background()
+
+	job, err := queue.Enqueue(ctx, dbtest.Log(), db, queue.NewJob{Kind: "test", MaxAttempts: 1})
+	if err != nil {
+		t.Fatalf("Should be able to enqueue a job: %v", err)
+	}
+
+	if err := queue.Requeue(ctx, dbtest.Log(), db, job.ID); err == nil {
+		t.Fatal("Should only requeue dead jobs")
+	}
+
+	var fail atomic.Bool
+	fail.Store(true)
+
+	workers := queue.NewWorkers(queue.WorkersConfig{
+		Log:          dbtest.Log(),
+		DB:           db,
+		PollInterval: 10 * time.Millisecond,
+	})
+	workers.Register("test", func(ctx context.Context, job queue.Job) error {
+		if fail.Load() {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	workers.Start()
+	defer workers.Shutdown(ctx)
+
+	waitForStatus(t, db, job, queue.StatusDead)
+
+	dead, err := queue.QueryDead(ctx, dbtest.Log(), db, queue.DefaultQueue, 10)
+	if err != nil || len(dead) != 1 || dead[0].ID != job.ID {
+		t.Fatalf("Should list the dead job: got %d: %v", len(dead), err)
+	}
+
+	fail.Store(false)
+
+	if err := queue.Requeue(ctx, dbtest.Log(), db, job.ID); err != nil {
+		t.Fatalf("Should be able to requeue the dead job: %v", err)
+	}
+
+	waitForStatus(t, db, job, queue.StatusSucceeded)
+}
+
+// =============================================================================
+
+// expireLease makes the job look claimed by a worker that went away, with
+// the lease already expired, after the specified number of attempts.
+func expireLease(attempts int) func(t *testing.T, db *sqlx.DB, job queue.Job) {
+	return func(t *testing.T, db *sqlx.DB, job queue.Job) {
+		const q = `
+		UPDATE jobs SET
+			status = 'running',
+			attempts = $1,
+			lock_token = gen_random_uuid(),
+			locked_until = now() - interval '1 second'
+		WHERE job_id = $2`
+
+		if _, err := db.Exec(q, attempts, job.ID); err != nil {
+			t.Fatalf("Should be able to expire the lease: %v", err)
+		}
+	}
+}
+
+func waitForStatus(t *testing.T, db *sqlx.DB, job queue.Job, status string) queue.Job {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		got, err := queue.QueryByID(context.Background(), dbtest.Log(), db, job.ID)
+		if err != nil {
+			t.Fatalf("Should be able to query the job: %v", err)
+		}
+
+		if got.Status == status {
+			return got
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Should reach status %s", status)
+	return queue.Job{}
+}
@@ -0,0 +1,539 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx/dbarray"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// This holds the metrics for the workers. The expvar package is based on a
+// singleton so these are registered once for the process.
+var qm = struct {
+	claimed   *expvar.Int
+	succeeded *expvar.Int
+	retried   *expvar.Int
+	dead      *expvar.Int
+	released  *expvar.Int
+	inFlight  *expvar.Int
+}{
+	claimed:   expvar.NewInt("jobs_claimed"),
+	succeeded: expvar.NewInt("jobs_succeeded"),
+	retried:   expvar.NewInt("jobs_retried"),
+	dead:      expvar.NewInt("jobs_dead"),
+	released:  expvar.NewInt("jobs_released"),
+	inFlight:  expvar.NewInt("jobs_in_flight"),
+}
+
+// This holds the open telemetry instruments for the workers. They are created
+// from the global meter provider, which hands the values to the provider set
+// at startup.
+var om struct {
+	processed metric.Int64Counter
+	duration  metric.Float64Histogram
+	inFlight  metric.Int64UpDownCounter
+}
+
+func init() {
+	meter := otel.Meter("github.com/vikaskumar1187/publisher_saas/business/data/queue")
+
+	var errs [3]error
+	om.processed, errs[0] = meter.Int64Counter("jobs.processed",
+		metric.WithDescription("Number of jobs processed by kind and outcome."),
+		metric.WithUnit("{job}"),
+	)
+	om.duration, errs[1] = meter.Float64Histogram("jobs.duration",
+		metric.WithDescription("Time the handlers took to run the jobs."),
+		metric.WithUnit("s"),
+	)
+	om.inFlight, errs[2] = meter.Int64UpDownCounter("jobs.in_flight",
+		metric.WithDescription("Number of jobs being run."),
+		metric.WithUnit("{job}"),
+	)
+	if err := errors.Join(errs[:]...); err != nil {
+		otel.Handle(err)
+	}
+}
+
+// HandlerFunc represents a function that processes a job. Returning an error
+// fails the attempt and the job is retried with backoff until it runs out of
+// attempts. The context is cancelled when the visibility timeout expires.
+type HandlerFunc func(ctx context.Context, job Job) error
+
+// permanentError marks an error that must not be retried.
+type permanentError struct {
+	err error
+}
+
+func (pe *permanentError) Error() string {
+	return pe.err.Error()
+}
+
+func (pe *permanentError) Unwrap() error {
+	return pe.err
+}
+
+// Permanent wraps the error so the job is dead-lettered immediately instead
+// of being retried. Use it for failures a retry can't fix, like a payload
+// that can't be decoded.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent checks if an error of type permanentError exists.
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// =============================================================================
+
+// WorkersConfig represents information required to initialize Workers.
+type WorkersConfig struct {
+	Log               *logger.Logger
+	DB                *sqlx.DB
+	Queue             string
+	Concurrency       int
+	PollInterval      time.Duration
+	VisibilityTimeout time.Duration
+	BackoffBase       time.Duration
+	BackoffMax        time.Duration
+}
+
+// Workers claims jobs from a queue and runs them with the registered handlers
+// using a bounded number of goroutines.
+type Workers struct {
+	log               *logger.Logger
+	db                *sqlx.DB
+	queue             string
+	concurrency       int
+	pollInterval      time.Duration
+	visibilityTimeout time.Duration
+	backoffBase       time.Duration
+	backoffMax        time.Duration
+
+	handlers map[string]HandlerFunc
+
+	jobCtx    context.Context
+	jobCancel context.CancelFunc
+	shutdown  chan struct{}
+	loop      sync.WaitGroup
+	inFlight  sync.WaitGroup
+}
+
+// NewWorkers constructs a set of workers for the configured queue. Handlers
+// must be registered before the workers are started.
+func NewWorkers(cfg WorkersConfig) *Workers {
+	if cfg.Queue == "" {
+		cfg.Queue = DefaultQueue
+	}
+
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = 5 * time.Minute
+	}
+
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = 5 * time.Second
+	}
+
+	if cfg.BackoffMax < cfg.BackoffBase {
+		cfg.BackoffMax = cfg.BackoffBase
+	}
+
+	jobCtx, jobCancel := context.WithCancel(context.Background())
+
+	return &Workers{
+		log:               cfg.Log,
+		db:                cfg.DB,
+		queue:             cfg.Queue,
+		concurrency:       cfg.Concurrency,
+		pollInterval:      cfg.PollInterval,
+		visibilityTimeout: cfg.VisibilityTimeout,
+		backoffBase:       cfg.BackoffBase,
+		backoffMax:        cfg.BackoffMax,
+		handlers:          make(map[string]HandlerFunc),
+		jobCtx:            jobCtx,
+		jobCancel:         jobCancel,
+		shutdown:          make(chan struct{}),
+	}
+}
+
+// Register binds a handler to a job kind. Only jobs of registered kinds are
+// claimed by these workers.
+func (w *Workers) Register(kind string, handler HandlerFunc) {
+	w.handlers[kind] = handler
+}
+
+// Start begins claiming and running jobs in the background.
+func (w *Workers) Start() {
+	w.loop.Add(1)
+	go func() {
+		defer w.loop.Done()
+		w.run()
+	}()
+}
+
+// Shutdown stops claiming new jobs and waits for the in-flight jobs to
+// finish. If the context expires first, the in-flight jobs are cancelled and
+// released back to the queue without using up an attempt.
+func (w *Workers) Shutdown(ctx context.Context) error {
+	close(w.shutdown)
+	w.loop.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		w.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.jobCancel()
+		return nil
+
+	case <-ctx.Done():
+		w.jobCancel()
+		<-done
+		return fmt.Errorf("released in-flight jobs: %w", ctx.Err())
+	}
+}
+
+// =============================================================================
+
+// run claims jobs while there is capacity until shutdown.
+func (w *Workers) run() {
+	ctx := db.WithPrimary(context.Background())
+
+	slots := make(chan struct{}, w.concurrency)
+
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-w.shutdown:
+			return
+		}
+
+		job, err := w.claim(ctx)
+		if err != nil {
+			<-slots
+
+			if !errors.Is(err, ErrNotFound) {
+				w.log.Error(ctx, "jobs", "status", "claiming job", "queue", w.queue, "msg", err)
+			}
+
+			select {
+			case <-time.After(w.pollInterval):
+			case <-w.shutdown:
+				return
+			}
+			continue
+		}
+
+		w.inFlight.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				w.inFlight.Done()
+			}()
+			w.process(job)
+		}()
+	}
+}
+
+// claim leases the next runnable job. A job is runnable when it is pending
+// and due, or when it is running and its lease has expired.
+func (w *Workers) claim(ctx context.Context) (Job, error) {
+	kinds := make(dbarray.String, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	data := struct {
+		Queue      string         `db:"queue"`
+		Kinds      dbarray.String `db:"kinds"`
+		LockToken  string         `db:"lock_token"`
+		Visibility float64        `db:"visibility"`
+		Pending    string         `db:"pending"`
+		Running    string         `db:"running"`
+	}{
+		Queue:      w.queue,
+		Kinds:      kinds,
+		LockToken:  uuid.NewString(),
+		Visibility: w.visibilityTimeout.Seconds(),
+		Pending:    StatusPending,
+		Running:    StatusRunning,
+	}
+
+	const q = `
+	UPDATE
+		jobs
+	SET
+		"status" = :running,
+		"attempts" = attempts + 1,
+		"lock_token" = :lock_token,
+		"locked_until" = now() + make_interval(secs => :visibility),
+		"date_updated" = now()
+	WHERE
+		job_id = (
+			SELECT
+				job_id
+			FROM
+				jobs
+			WHERE
+				queue = :queue AND
+				kind = ANY(:kinds) AND
+				((status = :pending AND run_at <= now()) OR (status = :running AND locked_until <= now()))
+			ORDER BY
+				run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+	RETURNING
+		job_id, queue, kind, payload, status, attempts, max_attempts, run_at, last_error, date_created, date_updated`
+
+	// The claim runs on every poll, so it is only logged at Debug.
+	ctx = db.WithLogLevel(ctx, logger.LevelDebug)
+
+	var dbJob dbJob
+	if err := db.NamedQueryStruct(ctx, w.log, w.db, q, data, &dbJob); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return Job{}, ErrNotFound
+		}
+		return Job{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	job := toJob(dbJob)
+	job.lockToken = uuid.MustParse(data.LockToken)
+
+	qm.claimed.Add(1)
+
+	return job, nil
+}
+
+// process runs the handler for the job and records the outcome.
+func (w *Workers) process(job Job) {
+	// A job is often enqueued with the data it acts on, which a replica may
+	// not have yet.
+	ctx := db.WithPrimary(context.Background())
+
+	kind := metric.WithAttributes(attribute.String("job.kind", job.Kind))
+
+	qm.inFlight.Add(1)
+	om.inFlight.Add(ctx, 1, kind)
+	defer func() {
+		qm.inFlight.Add(-1)
+		om.inFlight.Add(ctx, -1, kind)
+	}()
+
+	// A job that comes back around after its lease expired on the final
+	// attempt has already used up its attempts.
+	if job.Attempt > job.MaxAttempts {
+		w.dead(ctx, job, errors.New("visibility timeout expired on the final attempt"))
+		record(ctx, job, StatusDead, 0)
+		return
+	}
+
+	start := time.Now()
+	err := w.execute(job)
+	d := time.Since(start)
+
+	switch {
+	case err == nil:
+		w.complete(ctx, job)
+		record(ctx, job, StatusSucceeded, d)
+
+	case w.jobCtx.Err() != nil:
+		w.release(ctx, job, err)
+		record(ctx, job, "released", d)
+
+	case IsPermanent(err) || job.Attempt >= job.MaxAttempts:
+		w.dead(ctx, job, err)
+		record(ctx, job, StatusDead, d)
+
+	default:
+		w.retry(ctx, job, err)
+		record(ctx, job, "retried", d)
+	}
+}
+
+// record counts the outcome of the job and the time its handler took in the
+// open telemetry metrics.
+func record(ctx context.Context, job Job, outcome string, d time.Duration) {
+	attrs := metric.WithAttributes(
+		attribute.String("job.kind", job.Kind),
+		attribute.String("outcome", outcome),
+	)
+
+	om.processed.Add(ctx, 1, attrs)
+	if d > 0 {
+		om.duration.Record(ctx, d.Seconds(), attrs)
+	}
+}
+
+// execute calls the handler under the visibility timeout, converting a panic
+// into an error.
+func (w *Workers) execute(job Job) (err error) {
+	ctx, cancel := context.WithTimeout(w.jobCtx, w.visibilityTimeout)
+	defer cancel()
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("PANIC [%v] TRACE[%s]", rec, string(debug.Stack()))
+		}
+	}()
+
+	handler, exists := w.handlers[job.Kind]
+	if !exists {
+		return Permanent(fmt.Errorf("no handler registered for kind[%s]", job.Kind))
+	}
+
+	return handler(ctx, job)
+}
+
+// The updates below are guarded by the lock token. If the lease expired and
+// another worker claimed the job, the update matches no rows and the other
+// worker's outcome stands.
+
+func (w *Workers) complete(ctx context.Context, job Job) {
+	const q = `
+	UPDATE
+		jobs
+	SET
+		"status" = :status,
+		"lock_token" = NULL,
+		"locked_until" = NULL,
+		"date_updated" = now()
+	WHERE
+		job_id = :job_id AND lock_token = :lock_token`
+
+	w.update(ctx, job, q, StatusSucceeded, "", 0)
+
+	qm.succeeded.Add(1)
+	w.log.Info(ctx, "jobs", "status", "job succeeded", "jobID", job.ID, "kind", job.Kind, "attempt", job.Attempt)
+}
+
+func (w *Workers) retry(ctx context.Context, job Job, err error) {
+	const q = `
+	UPDATE
+		jobs
+	SET
+		"status" = :status,
+		"run_at" = now() + make_interval(secs => :delay),
+		"last_error" = :last_error,
+		"lock_token" = NULL,
+		"locked_until" = NULL,
+		"date_updated" = now()
+	WHERE
+		job_id = :job_id AND lock_token = :lock_token`
+
+	delay := w.backoff(job.Attempt)
+
+	w.update(ctx, job, q, StatusPending, err.Error(), delay)
+
+	qm.retried.Add(1)
+	w.log.Error(ctx, "jobs", "status", "job failed, retrying", "jobID", job.ID, "kind", job.Kind, "attempt", job.Attempt, "maxAttempts", job.MaxAttempts, "delay", delay, "msg", err)
+}
+
+func (w *Workers) dead(ctx context.Context, job Job, err error) {
+	const q = `
+	UPDATE
+		jobs
+	SET
+		"status" = :status,
+		"last_error" = :last_error,
+		"lock_token" = NULL,
+		"locked_until" = NULL,
+		"date_updated" = now()
+	WHERE
+		job_id = :job_id AND lock_token = :lock_token`
+
+	w.update(ctx, job, q, StatusDead, err.Error(), 0)
+
+	qm.dead.Add(1)
+	w.log.Error(ctx, "jobs", "status", "job dead-lettered", "jobID", job.ID, "kind", job.Kind, "attempt", job.Attempt, "msg", err)
+}
+
+func (w *Workers) release(ctx context.Context, job Job, err error) {
+	const q = `
+	UPDATE
+		jobs
+	SET
+		"status" = :status,
+		"attempts" = attempts - 1,
+		"run_at" = now(),
+		"last_error" = :last_error,
+		"lock_token" = NULL,
+		"locked_until" = NULL,
+		"date_updated" = now()
+	WHERE
+		job_id = :job_id AND lock_token = :lock_token`
+
+	w.update(ctx, job, q, StatusPending, err.Error(), 0)
+
+	qm.released.Add(1)
+	w.log.Info(ctx, "jobs", "status", "job released on shutdown", "jobID", job.ID, "kind", job.Kind)
+}
+
+// update records the outcome of a job. It runs on its own timeout so the
+// outcome is recorded even while the workers are shutting down.
+func (w *Workers) update(ctx context.Context, job Job, q string, status string, lastError string, delay time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	data := struct {
+		ID        string  `db:"job_id"`
+		LockToken string  `db:"lock_token"`
+		Status    string  `db:"status"`
+		LastError string  `db:"last_error"`
+		Delay     float64 `db:"delay"`
+	}{
+		ID:        job.ID.String(),
+		LockToken: job.lockToken.String(),
+		Status:    status,
+		LastError: lastError,
+		Delay:     delay.Seconds(),
+	}
+
+	if err := db.NamedExecContext(ctx, w.log, w.db, q, data); err != nil {
+		w.log.Error(ctx, "jobs", "status", "recording job outcome", "jobID", job.ID, "outcome", status, "msg", err)
+	}
+}
+
+// backoff calculates the delay before the next attempt. The delay doubles on
+// each attempt up to the maximum, with jitter so failed jobs don't retry in
+// lockstep.
+func (w *Workers) backoff(attempt int) time.Duration {
+	delay := w.backoffBase
+	for i := 1; i < attempt && delay < w.backoffMax; i++ {
+		delay *= 2
+	}
+
+	if delay > w.backoffMax {
+		delay = w.backoffMax
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+
+	return delay - delay/10 + jitter
+}
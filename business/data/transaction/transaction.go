@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
 )
@@ -16,9 +17,12 @@ type Transaction interface {
 	Rollback() error
 }
 
-// Beginner represents a value that can begin a transaction.
+// Beginner represents a value that can begin a transaction. When the context
+// passed to BeginTx already holds a transaction, a savepoint is created
+// inside it instead and the options are ignored.
 type Beginner interface {
 	Begin() (Transaction, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Transaction, error)
 }
 
 // =============================================================================
@@ -40,13 +44,70 @@ func Get(ctx context.Context) (Transaction, bool) {
 
 // =============================================================================
 
+// Set of SQLSTATE codes of the errors a transaction is retried on.
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// maxAttempts is the number of times a transaction is run before a retryable
+// error is returned to the caller.
+const maxAttempts = 3
+
+// IsRetryable reports whether the error is a serialization failure or a
+// deadlock, after which the whole transaction can be run again.
+func IsRetryable(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	switch pgErr.SQLState() {
+	case serializationFailure, deadlockDetected:
+		return true
+	}
+
+	return false
+}
+
 // ExecuteUnderTransaction is a helper function that can be used in tests and
 // other apps to execute the core APIs under a transaction.
 func ExecuteUnderTransaction(ctx context.Context, log *logger.Logger, bgn Beginner, fn func(tx Transaction) error) error {
+	return ExecuteUnderTransactionWithOptions(ctx, log, bgn, nil, fn)
+}
+
+// ExecuteUnderTransactionWithOptions executes the function under a
+// transaction started with the options, such as an isolation level or read
+// only. The transaction is run again when it fails with a serialization
+// failure or a deadlock, so the function must be safe to repeat. When the
+// context already holds a transaction the function runs in a savepoint
+// inside it and is not retried, since the outer transaction is aborted too.
+func ExecuteUnderTransactionWithOptions(ctx context.Context, log *logger.Logger, bgn Beginner, opts *sql.TxOptions, fn func(tx Transaction) error) error {
+	if _, nested := Get(ctx); nested {
+		return execute(ctx, log, bgn, opts, fn)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := execute(ctx, log, bgn, opts, fn)
+		if err == nil || attempt >= maxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		log.Info(ctx, "RETRY TRANSACTION", "attempt", attempt, "ERROR", err)
+
+		select {
+		case <-time.After(time.Duration(attempt) * 25 * time.Millisecond):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func execute(ctx context.Context, log *logger.Logger, bgn Beginner, opts *sql.TxOptions, fn func(tx Transaction) error) error {
 	hasCommited := false
 
 	log.Info(ctx, "BEGIN TRANSACTION")
-	tx, err := bgn.Begin()
+	tx, err := bgn.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
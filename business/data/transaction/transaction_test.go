@@ -0,0 +1,144 @@
+package transaction_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/dberr"
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+func Test_IsRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, retryable: true},
+		{name: "deadlock", err: &pgconn.PgError{Code: "40P01"}, retryable: true},
+		{name: "wrapped", err: fmt.Errorf("EXECUTE TRANSACTION: %w", &pgconn.PgError{Code: "40001"}), retryable: true},
+		{name: "classified", err: dberr.New(&pgconn.PgError{Code: "40P01"}, "40P01", "", "", "", "", ""), retryable: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}},
+		{name: "no rows", err: sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transaction.IsRetryable(tt.err); got != tt.retryable {
+				t.Fatalf("Should get retryable %t: got %t", tt.retryable, got)
+			}
+		})
+	}
+}
+
+func Test_ExecuteUnderTransaction(t *testing.T) {
+	errSerialization := &pgconn.PgError{Code: "40001"}
+	errDeadlock := &pgconn.PgError{Code: "40P01"}
+	errOther := errors.New("other")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		nested    bool
+		expErr    error
+		calls     int
+		commits   int
+		rollbacks int
+	}{
+		{name: "commits", errs: []error{nil}, calls: 1, commits: 1},
+		{name: "retries serialization failure", errs: []error{errSerialization, nil}, calls: 2, commits: 1, rollbacks: 1},
+		{name: "retries deadlock", errs: []error{errDeadlock, errDeadlock, nil}, calls: 3, commits: 1, rollbacks: 2},
+		{name: "gives up after max attempts", errs: []error{errDeadlock, errDeadlock, errDeadlock, nil}, expErr: errDeadlock, calls: 3, rollbacks: 3},
+		{name: "other error not retried", errs: []error{errOther, nil}, expErr: errOther, calls: 1, rollbacks: 1},
+		{name: "nested not retried", errs: []error{errSerialization, nil}, nested: true, expErr: errSerialization, calls: 1, rollbacks: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+			bgn := &beginner{}
+
+			ctx := context.Background()
+			if tt.nested {
+				ctx = transaction.Set(ctx, &tx{})
+			}
+
+			var calls int
+			err := transaction.ExecuteUnderTransaction(ctx, log, bgn, func(tx transaction.Transaction) error {
+				calls++
+				return tt.errs[calls-1]
+			})
+
+			if !errors.Is(err, tt.expErr) || (tt.expErr == nil && err != nil) {
+				t.Fatalf("Should get error %v: got %v", tt.expErr, err)
+			}
+
+			if calls != tt.calls {
+				t.Fatalf("Should run the function %d times: got %d", tt.calls, calls)
+			}
+
+			if commits, rollbacks := bgn.counts(); commits != tt.commits || rollbacks != tt.rollbacks {
+				t.Fatalf("Should commit %d and roll back %d times: got %d and %d", tt.commits, tt.rollbacks, commits, rollbacks)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// beginner hands out transactions that record how they ended.
+type beginner struct {
+	txs []*tx
+}
+
+func (b *beginner) Begin() (transaction.Transaction, error) {
+	return b.BeginTx(context.Background(), nil)
+}
+
+func (b *beginner) BeginTx(ctx context.Context, opts *sql.TxOptions) (transaction.Transaction, error) {
+	tx := tx{}
+	b.txs = append(b.txs, &tx)
+	return &tx, nil
+}
+
+func (b *beginner) counts() (int, int) {
+	var commits, rollbacks int
+	for _, tx := range b.txs {
+		switch {
+		case tx.committed:
+			commits++
+		case tx.rolledBack:
+			rollbacks++
+		}
+	}
+
+	return commits, rollbacks
+}
+
+// tx ends like a sql.Tx: only the first of Commit and Rollback has an effect.
+type tx struct {
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *tx) Commit() error {
+	if tx.committed || tx.rolledBack {
+		return sql.ErrTxDone
+	}
+	tx.committed = true
+	return nil
+}
+
+func (tx *tx) Rollback() error {
+	if tx.committed || tx.rolledBack {
+		return sql.ErrTxDone
+	}
+	tx.rolledBack = true
+	return nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/navigacontentlab/panurge/navigaid"
+	"github.com/vikaskumar1187/publisher_saas/business/core/apikey"
+)
+
+// APIKeyHeader is the request header machine clients use to send an api key.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyLookup represents behavior that can validate an api key.
+type APIKeyLookup interface {
+	Authenticate(ctx context.Context, secret string) (apikey.APIKey, error)
+}
+
+// AuthenticateAPIKey validates the api key and returns claims equivalent to
+// those of a token, so routes authorize machine clients the same way.
+func (a *Auth) AuthenticateAPIKey(ctx context.Context, secret string) (navigaid.Claims, error) {
+	if a.apiKeys == nil {
+		return navigaid.Claims{}, errors.New("api key authentication is not enabled")
+	}
+
+	key, err := a.apiKeys.Authenticate(ctx, secret)
+	if err != nil {
+		return navigaid.Claims{}, err
+	}
+
+	return APIKeyClaims(key), nil
+}
+
+// APIKeyClaims constructs the claims for the service account an api key
+// belongs to.
+func APIKeyClaims(key apikey.APIKey) navigaid.Claims {
+	var claims navigaid.Claims
+	claims.Subject = "apikey:" + key.ID.String()
+	claims.Org = key.Org
+	claims.TokenType = navigaid.TokenTypeAccessToken
+	claims.Userinfo.GivenName = key.Name
+	claims.Permissions.Org = key.Permissions
+
+	return claims
+}
@@ -9,39 +9,46 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/navigacontentlab/panurge/navigaid"
 	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
 )
 
+// ErrNoOrganisation is returned when the claims are not tied to an organisation.
+var ErrNoOrganisation = errors.New("claims hold no organisation")
+
+// Authenticator represents behavior that can validate a token and return the
+// claims it holds.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (navigaid.Claims, error)
+}
+
 // Config represents information required to initialize auth.
 type Config struct {
-	Log         *logger.Logger
-	Env         string
-	ImasURL     string
-	Permissions string
+	Log           *logger.Logger
+	Env           string
+	Authenticator Authenticator
+	APIKeys       APIKeyLookup
 }
 
 // Auth is used to authenticate clients.
 type Auth struct {
-	log         *logger.Logger
-	method      jwt.SigningMethod
-	parser      *jwt.Parser
-	env         string
-	imasURL     string
-	permissions string
+	log           *logger.Logger
+	env           string
+	authenticator Authenticator
+	apiKeys       APIKeyLookup
 }
 
 // New creates an Auth to support authentication/authorization.
 func New(cfg Config) (*Auth, error) {
+	if cfg.Authenticator == nil {
+		return nil, errors.New("authenticator is required")
+	}
 
 	a := Auth{
-		log:         cfg.Log,
-		method:      jwt.GetSigningMethod(jwt.SigningMethodRS256.Name),
-		parser:      jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name})),
-		env:         cfg.Env,
-		imasURL:     cfg.ImasURL,
-		permissions: cfg.Permissions,
+		log:           cfg.Log,
+		env:           cfg.Env,
+		authenticator: cfg.Authenticator,
+		apiKeys:       cfg.APIKeys,
 	}
 	return &a, nil
 }
@@ -53,25 +60,28 @@ func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (navigaid.C
 		return navigaid.Claims{}, errors.New("expected authorization header format: Bearer <token>")
 	}
 
-	jwks := navigaid.NewJWKS(
-		navigaid.ImasJWKSEndpoint(a.imasURL),
-	)
-
-	var claims navigaid.Claims
-	claims, err := jwks.Validate(bearerToken)
+	claims, err := a.authenticator.Authenticate(ctx, parts[1])
 	if err != nil {
-		return navigaid.Claims{}, fmt.Errorf("error parsing token: %w", err)
+		return navigaid.Claims{}, err
+	}
+
+	if claims.TokenType != navigaid.TokenTypeAccessToken {
+		return navigaid.Claims{}, fmt.Errorf("unexpected token type %q", claims.TokenType)
 	}
 
 	return claims, nil
 }
 
-// Check if the user has permissions in the organization
-func (a *Auth) Authorize(ctx context.Context, claims navigaid.Claims) error {
+// Authorize checks the claims against the specified rule. The claims must
+// belong to an organisation and hold the permissions required by the rule
+// within that organisation, or within the unit the rule is scoped to.
+func (a *Auth) Authorize(ctx context.Context, claims navigaid.Claims, rule Rule) error {
+	if claims.Org == "" {
+		return ErrNoOrganisation
+	}
 
-	hasPerm := claims.HasPermissionsInOrganisation(a.permissions)
-	if !hasPerm {
-		return fmt.Errorf("not enough permissions")
+	if !rule.isSatisfied(claims) {
+		return fmt.Errorf("not enough permissions in organisation[%s]", claims.Org)
 	}
 
 	return nil
@@ -0,0 +1,181 @@
+// Package authtest provides a local JWKS server that stands in for IMAS when
+// running tests. It can mint access tokens, rotate keys and simulate an outage.
+package authtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/navigacontentlab/panurge/navigaid"
+)
+
+// JWKS is an httptest server that serves a JWKS document in the same shape
+// and under the same path as IMAS.
+type JWKS struct {
+	Server *httptest.Server
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PrivateKey
+	activeKID string
+	down      bool
+
+	requests atomic.Int64
+}
+
+// NewJWKS starts a JWKS server with a single freshly generated key.
+func NewJWKS() (*JWKS, error) {
+	j := JWKS{
+		keys: make(map[string]*rsa.PrivateKey),
+	}
+
+	if _, err := j.Rotate(); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/jwks", j.serveJWKS)
+	j.Server = httptest.NewServer(mux)
+
+	return &j, nil
+}
+
+// URL returns the base url of the server, to be used in place of the IMAS url.
+func (j *JWKS) URL() string {
+	return j.Server.URL
+}
+
+// JWKSURL returns the url of the JWKS endpoint.
+func (j *JWKS) JWKSURL() string {
+	return navigaid.ImasJWKSEndpoint(j.Server.URL)
+}
+
+// Close shuts down the server.
+func (j *JWKS) Close() {
+	j.Server.Close()
+}
+
+// Requests returns the number of requests the JWKS endpoint has received.
+func (j *JWKS) Requests() int64 {
+	return j.requests.Load()
+}
+
+// SetDown controls whether the server responds with a 503 to simulate an
+// outage of IMAS.
+func (j *JWKS) SetDown(down bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.down = down
+}
+
+// Rotate generates a new key, adds it to the key set and makes it the key used
+// to sign new tokens. The key id of the new key is returned.
+func (j *JWKS) Rotate() (string, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", fmt.Errorf("generating key: %w", err)
+	}
+
+	kid := uuid.NewString()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.keys[kid] = privateKey
+	j.activeKID = kid
+
+	return kid, nil
+}
+
+// GenerateToken signs an access token for the specified claims using the
+// active key. Expiry, issue time and token type are filled in when missing.
+func (j *JWKS) GenerateToken(claims navigaid.Claims) (string, error) {
+	j.mu.RLock()
+	kid := j.activeKID
+	privateKey := j.keys[kid]
+	j.mu.RUnlock()
+
+	if claims.TokenType == "" {
+		claims.TokenType = navigaid.TokenTypeAccessToken
+	}
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshaling claims: %w", err)
+	}
+
+	mapClaims := make(jwt.MapClaims)
+	if err := json.Unmarshal(data, &mapClaims); err != nil {
+		return "", fmt.Errorf("unmarshaling claims: %w", err)
+	}
+
+	now := time.Now()
+	if _, exists := mapClaims["exp"]; !exists {
+		mapClaims["exp"] = now.Add(time.Hour).Unix()
+	}
+	if _, exists := mapClaims["iat"]; !exists {
+		mapClaims["iat"] = now.Unix()
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, mapClaims)
+	token.Header["kid"] = kid
+
+	str, err := token.SignedString(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("signing token: %w", err)
+	}
+
+	return str, nil
+}
+
+// =============================================================================
+
+func (j *JWKS) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	j.requests.Add(1)
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if j.down {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	type key struct {
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	}
+
+	doc := struct {
+		Keys []key `json:"keys"`
+	}{}
+
+	for kid, privateKey := range j.keys {
+		doc.Keys = append(doc.Keys, key{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"time"
+
+	jwtv4 "github.com/golang-jwt/jwt/v4"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/navigacontentlab/panurge/navigaid"
+)
+
+// jwtClaims wraps the navigaid claims so they can be parsed and validated by
+// the v5 jwt package. The navigaid claims are based on the v4 registered
+// claims which don't provide the v5 accessor methods.
+type jwtClaims struct {
+	navigaid.Claims
+}
+
+// GetExpirationTime implements the jwt.Claims interface.
+func (c jwtClaims) GetExpirationTime() (*jwt.NumericDate, error) {
+	if c.ExpiresAt == nil {
+		return nil, nil
+	}
+	return jwt.NewNumericDate(c.ExpiresAt.Time), nil
+}
+
+// GetIssuedAt implements the jwt.Claims interface.
+func (c jwtClaims) GetIssuedAt() (*jwt.NumericDate, error) {
+	if c.IssuedAt == nil {
+		return nil, nil
+	}
+	return jwt.NewNumericDate(c.IssuedAt.Time), nil
+}
+
+// GetNotBefore implements the jwt.Claims interface.
+func (c jwtClaims) GetNotBefore() (*jwt.NumericDate, error) {
+	if c.NotBefore == nil {
+		return nil, nil
+	}
+	return jwt.NewNumericDate(c.NotBefore.Time), nil
+}
+
+// GetIssuer implements the jwt.Claims interface.
+func (c jwtClaims) GetIssuer() (string, error) {
+	return c.Issuer, nil
+}
+
+// GetSubject implements the jwt.Claims interface.
+func (c jwtClaims) GetSubject() (string, error) {
+	return c.Subject, nil
+}
+
+// GetAudience implements the jwt.Claims interface.
+func (c jwtClaims) GetAudience() (jwt.ClaimStrings, error) {
+	return jwt.ClaimStrings(c.Audience), nil
+}
+
+// newV4NumericDate constructs a date in the form used by the navigaid claims.
+func newV4NumericDate(t time.Time) *jwtv4.NumericDate {
+	return jwtv4.NewNumericDate(t)
+}
@@ -8,16 +8,27 @@ import (
 // AuthError is used to pass an error during the request through the
 // application with auth specific context.
 type AuthError struct {
-	msg string
+	msg       string
+	forbidden bool
 }
 
-// NewAuthError creates an AuthError for the provided message.
+// NewAuthError creates an AuthError for the provided message. This is used
+// when the caller could not be authenticated.
 func NewAuthError(format string, args ...any) error {
 	return &AuthError{
 		msg: fmt.Sprintf(format, args...),
 	}
 }
 
+// NewForbiddenError creates an AuthError for the provided message. This is
+// used when the caller is authenticated but not allowed to perform the action.
+func NewForbiddenError(format string, args ...any) error {
+	return &AuthError{
+		msg:       fmt.Sprintf(format, args...),
+		forbidden: true,
+	}
+}
+
 // Error implements the error interface. It uses the default message of the
 // wrapped error. This is what will be shown in the services' logs.
 func (ae *AuthError) Error() string {
@@ -28,4 +39,11 @@ func (ae *AuthError) Error() string {
 func IsAuthError(err error) bool {
 	var ae *AuthError
 	return errors.As(err, &ae)
-}
\ No newline at end of file
+}
+
+// IsForbiddenError checks if an error of type AuthError exists and it
+// represents a caller that is not allowed to perform the action.
+func IsForbiddenError(err error) bool {
+	var ae *AuthError
+	return errors.As(err, &ae) && ae.forbidden
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/navigacontentlab/panurge/navigaid"
+)
+
+// IMAS validates tokens issued by IMAS against its published JWKS.
+type IMAS struct {
+	keySet *KeySet
+	parser *jwt.Parser
+}
+
+// NewIMAS constructs an IMAS authenticator using the specified key set.
+func NewIMAS(keySet *KeySet) *IMAS {
+	return &IMAS{
+		keySet: keySet,
+		parser: jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name})),
+	}
+}
+
+// Authenticate implements the Authenticator interface.
+func (im *IMAS) Authenticate(ctx context.Context, token string) (navigaid.Claims, error) {
+	keyFunc := func(t *jwt.Token) (any, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok {
+			return nil, errors.New("missing key id (kid) in token header")
+		}
+
+		key, alg, err := im.keySet.PublicKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if alg != "" && alg != t.Method.Alg() {
+			return nil, fmt.Errorf("algorithm %q does not match key algorithm %q", t.Method.Alg(), alg)
+		}
+
+		return key, nil
+	}
+
+	var claims jwtClaims
+	if _, err := im.parser.ParseWithClaims(token, &claims, keyFunc); err != nil {
+		return navigaid.Claims{}, fmt.Errorf("error parsing token: %w", err)
+	}
+
+	return claims.Claims, nil
+}
@@ -0,0 +1,334 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// ErrUnknownKID is returned when a key id can't be found in the key set, even
+// after a refetch of the JWKS.
+var ErrUnknownKID = errors.New("unknown key id")
+
+// This holds the metrics for the key set. The expvar package is based on a
+// singleton so these are registered once for the process.
+var ksm = struct {
+	refreshes     *expvar.Int
+	refreshErrors *expvar.Int
+	unknownKIDs   *expvar.Int
+	keys          *expvar.Int
+	lastRefresh   *expvar.String
+}{
+	refreshes:     expvar.NewInt("jwks_refreshes"),
+	refreshErrors: expvar.NewInt("jwks_refresh_errors"),
+	unknownKIDs:   expvar.NewInt("jwks_unknown_kids"),
+	keys:          expvar.NewInt("jwks_keys"),
+	lastRefresh:   expvar.NewString("jwks_last_refresh"),
+}
+
+// =============================================================================
+
+// KeySetConfig represents information required to initialize a KeySet.
+type KeySetConfig struct {
+	Log                *logger.Logger
+	URL                string
+	RefreshInterval    time.Duration
+	MinRefreshInterval time.Duration
+	RetryInterval      time.Duration
+	Client             *http.Client
+}
+
+// publicKey represents a single key from the JWKS with the algorithm it must
+// be used with.
+type publicKey struct {
+	alg string
+	key *rsa.PublicKey
+}
+
+// KeySet maintains a cached copy of a JWKS. The keys are loaded once at
+// startup and refreshed in the background. If the JWKS endpoint is not
+// reachable, the last good key set continues to be served.
+type KeySet struct {
+	log                *logger.Logger
+	url                string
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	retryInterval      time.Duration
+	client             *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]publicKey
+	lastFetched time.Time
+	lastFailed  time.Time
+
+	refreshMu    sync.Mutex
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+	wg           sync.WaitGroup
+}
+
+// NewKeySet constructs a KeySet and performs the initial load of the keys.
+// A background goroutine keeps the keys refreshed until Shutdown is called.
+func NewKeySet(ctx context.Context, cfg KeySetConfig) (*KeySet, error) {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 10 * time.Minute
+	}
+
+	if cfg.MinRefreshInterval <= 0 {
+		cfg.MinRefreshInterval = 30 * time.Second
+	}
+
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = min(5*time.Second, cfg.MinRefreshInterval)
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	ks := KeySet{
+		log:                cfg.Log,
+		url:                cfg.URL,
+		refreshInterval:    cfg.RefreshInterval,
+		minRefreshInterval: cfg.MinRefreshInterval,
+		retryInterval:      cfg.RetryInterval,
+		client:             cfg.Client,
+		keys:               make(map[string]publicKey),
+		shutdown:           make(chan struct{}),
+	}
+
+	if err := ks.refresh(ctx); err != nil {
+		return nil, fmt.Errorf("initial load: %w", err)
+	}
+
+	ks.wg.Add(1)
+	go func() {
+		defer ks.wg.Done()
+		ks.run()
+	}()
+
+	return &ks, nil
+}
+
+// Shutdown stops the background refresh of the keys. It is safe to call
+// more than once.
+func (ks *KeySet) Shutdown() {
+	ks.shutdownOnce.Do(func() {
+		close(ks.shutdown)
+	})
+	ks.wg.Wait()
+}
+
+// PublicKey returns the public key and algorithm for the specified key id. If
+// the key id is unknown, the JWKS is refetched once, limited by the minimum
+// refresh interval, in case the keys have been rotated.
+func (ks *KeySet) PublicKey(ctx context.Context, kid string) (*rsa.PublicKey, string, error) {
+	if pk, exists := ks.lookup(kid); exists {
+		return pk.key, pk.alg, nil
+	}
+
+	ksm.unknownKIDs.Add(1)
+
+	if err := ks.refetch(ctx, kid); err != nil {
+		ks.log.Error(ctx, "jwks", "status", "refetch on unknown kid", "kid", kid, "msg", err)
+	}
+
+	pk, exists := ks.lookup(kid)
+	if !exists {
+		return nil, "", ErrUnknownKID
+	}
+
+	return pk.key, pk.alg, nil
+}
+
+// =============================================================================
+
+// run refreshes the keys on the configured interval until shutdown.
+func (ks *KeySet) run() {
+	ticker := time.NewTicker(ks.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx := context.Background()
+			if err := ks.refresh(ctx); err != nil {
+				ks.log.Error(ctx, "jwks", "status", "background refresh, serving last good key set", "msg", err)
+			}
+
+		case <-ks.shutdown:
+			return
+		}
+	}
+}
+
+// lookup finds the key for the specified key id in the current key set.
+func (ks *KeySet) lookup(kid string) (publicKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	pk, exists := ks.keys[kid]
+	return pk, exists
+}
+
+// refetch refreshes the key set for an unknown key id unless the key set was
+// fetched within the minimum refresh interval, or the last fetch failed
+// within the retry interval. A failed fetch doesn't hold back the refetch for
+// the minimum refresh interval, so a key rotated during an outage is picked
+// up soon after the endpoint is back. Concurrent callers looking for the same
+// key id wait for a single fetch.
+func (ks *KeySet) refetch(ctx context.Context, kid string) error {
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+
+	if _, exists := ks.lookup(kid); exists {
+		return nil
+	}
+
+	ks.mu.RLock()
+	lastFetched := ks.lastFetched
+	lastFailed := ks.lastFailed
+	ks.mu.RUnlock()
+
+	if time.Since(lastFetched) < ks.minRefreshInterval || time.Since(lastFailed) < ks.retryInterval {
+		return nil
+	}
+
+	return ks.fetchAndStore(ctx)
+}
+
+// refresh fetches the JWKS and replaces the current key set. On failure the
+// current key set is left untouched.
+func (ks *KeySet) refresh(ctx context.Context) error {
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+
+	return ks.fetchAndStore(ctx)
+}
+
+// fetchAndStore performs the fetch and swaps in the new keys. A failed fetch
+// is recorded apart from the last good one. The caller must hold the refresh
+// lock.
+func (ks *KeySet) fetchAndStore(ctx context.Context) error {
+	keys, err := ks.fetch(ctx)
+
+	ks.mu.Lock()
+	switch {
+	case err != nil:
+		ks.lastFailed = time.Now()
+	default:
+		ks.lastFetched = time.Now()
+		ks.keys = keys
+	}
+	ks.mu.Unlock()
+
+	if err != nil {
+		ksm.refreshErrors.Add(1)
+		return err
+	}
+
+	ksm.refreshes.Add(1)
+	ksm.keys.Set(int64(len(keys)))
+	ksm.lastRefresh.Set(time.Now().UTC().Format(time.RFC3339))
+
+	return nil
+}
+
+// fetch retrieves and decodes the JWKS from the endpoint.
+func (ks *KeySet) fetch(ctx context.Context) (map[string]publicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching jwks: server responded with: %s", resp.Status)
+	}
+
+	var doc jwksDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding jwks: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("decoding key[%s]: %w", jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = publicKey{alg: jwk.Alg, key: key}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no usable keys")
+	}
+
+	return keys, nil
+}
+
+// =============================================================================
+
+// jwksDocument represents the document returned by a JWKS endpoint.
+type jwksDocument struct {
+	Keys []jwksKey `json:"keys"`
+}
+
+// jwksKey represents a single RSA key in a JWKS document.
+type jwksKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// publicKey converts the modulus and exponent into an RSA public key.
+func (k jwksKey) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("decoding modulus: %w", err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("decoding exponent: %w", err)
+	}
+
+	if len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid exponent size")
+	}
+
+	var exp int
+	for _, b := range e {
+		exp = exp<<8 | int(b)
+	}
+
+	key := rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: exp,
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,220 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/navigacontentlab/panurge/navigaid"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth/authtest"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+func Test_KeySet(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  auth.KeySetConfig
+		test func(t *testing.T, jwks *authtest.JWKS, ks *auth.KeySet, kid string)
+	}{
+		{
+			name: "startup",
+			cfg:  auth.KeySetConfig{RefreshInterval: time.Hour, MinRefreshInterval: time.Hour},
+			test: func(t *testing.T, jwks *authtest.JWKS, ks *auth.KeySet, kid string) {
+				if _, alg, err := ks.PublicKey(context.Background(), kid); err != nil || alg != "RS256" {
+					t.Fatalf("Should find the key loaded at startup: alg %q: %v", alg, err)
+				}
+
+				if n := jwks.Requests(); n != 1 {
+					t.Fatalf("Should fetch the JWKS once at startup: got %d requests", n)
+				}
+			},
+		},
+		{
+			name: "background refresh",
+			cfg:  auth.KeySetConfig{RefreshInterval: 20 * time.Millisecond, MinRefreshInterval: time.Hour},
+			test: func(t *testing.T, jwks *authtest.JWKS, ks *auth.KeySet, kid string) {
+				newKID, err := jwks.Rotate()
+				if err != nil {
+					t.Fatalf("Should be able to rotate the key: %v", err)
+				}
+
+				// The minimum refresh interval blocks the refetch, so the new
+				// key can only show up through the background refresh.
+				waitFor(t, func() bool {
+					_, _, err := ks.PublicKey(context.Background(), newKID)
+					return err == nil
+				})
+			},
+		},
+		{
+			name: "refetch on unknown kid",
+			cfg:  auth.KeySetConfig{RefreshInterval: time.Hour, MinRefreshInterval: time.Nanosecond},
+			test: func(t *testing.T, jwks *authtest.JWKS, ks *auth.KeySet, kid string) {
+				newKID, err := jwks.Rotate()
+				if err != nil {
+					t.Fatalf("Should be able to rotate the key: %v", err)
+				}
+
+				if _, _, err := ks.PublicKey(context.Background(), newKID); err != nil {
+					t.Fatalf("Should find the rotated key after a refetch: %v", err)
+				}
+
+				if n := jwks.Requests(); n != 2 {
+					t.Fatalf("Should refetch the JWKS once: got %d requests", n)
+				}
+
+				if _, _, err := ks.PublicKey(context.Background(), "unknown"); !errors.Is(err, auth.ErrUnknownKID) {
+					t.Fatalf("Should not find a key that isn't published: %v", err)
+				}
+			},
+		},
+		{
+			name: "refetch limited by min refresh interval",
+			cfg:  auth.KeySetConfig{RefreshInterval: time.Hour, MinRefreshInterval: time.Hour},
+			test: func(t *testing.T, jwks *authtest.JWKS, ks *auth.KeySet, kid string) {
+				for i := 0; i < 5; i++ {
+					if _, _, err := ks.PublicKey(context.Background(), "unknown"); !errors.Is(err, auth.ErrUnknownKID) {
+						t.Fatalf("Should not find a key that isn't published: %v", err)
+					}
+				}
+
+				if n := jwks.Requests(); n != 1 {
+					t.Fatalf("Should not refetch within the min refresh interval: got %d requests", n)
+				}
+			},
+		},
+		{
+			name: "refetch after failed fetch",
+			cfg:  auth.KeySetConfig{RefreshInterval: time.Hour, MinRefreshInterval: 200 * time.Millisecond, RetryInterval: 20 * time.Millisecond},
+			test: func(t *testing.T, jwks *authtest.JWKS, ks *auth.KeySet, kid string) {
+				time.Sleep(250 * time.Millisecond)
+				jwks.SetDown(true)
+
+				for i := 0; i < 5; i++ {
+					if _, _, err := ks.PublicKey(context.Background(), "unknown"); !errors.Is(err, auth.ErrUnknownKID) {
+						t.Fatalf("Should not find a key while down: %v", err)
+					}
+				}
+
+				if n := jwks.Requests(); n != 2 {
+					t.Fatalf("Should not retry a failed fetch within the retry interval: got %d requests", n)
+				}
+
+				jwks.SetDown(false)
+				newKID, err := jwks.Rotate()
+				if err != nil {
+					t.Fatalf("Should be able to rotate the key: %v", err)
+				}
+
+				// The failed fetch is within the min refresh interval, yet it
+				// doesn't hold back the refetch for the rotated key.
+				time.Sleep(30 * time.Millisecond)
+
+				if _, _, err := ks.PublicKey(context.Background(), newKID); err != nil {
+					t.Fatalf("Should find the rotated key once the endpoint is back: %v", err)
+				}
+			},
+		},
+		{
+			name: "shutdown twice",
+			cfg:  auth.KeySetConfig{RefreshInterval: time.Hour, MinRefreshInterval: time.Hour},
+			test: func(t *testing.T, jwks *authtest.JWKS, ks *auth.KeySet, kid string) {
+				ks.Shutdown()
+				ks.Shutdown()
+			},
+		},
+		{
+			name: "last good key set while down",
+			cfg:  auth.KeySetConfig{RefreshInterval: 10 * time.Millisecond, MinRefreshInterval: time.Nanosecond},
+			test: func(t *testing.T, jwks *authtest.JWKS, ks *auth.KeySet, kid string) {
+				jwks.SetDown(true)
+
+				waitFor(t, func() bool {
+					return jwks.Requests() > 3
+				})
+
+				if _, _, err := ks.PublicKey(context.Background(), kid); err != nil {
+					t.Fatalf("Should serve the last good key set while down: %v", err)
+				}
+
+				token, err := jwks.GenerateToken(navigaid.Claims{Org: "sample"})
+				if err != nil {
+					t.Fatalf("Should be able to generate a token: %v", err)
+				}
+
+				claims, err := auth.NewIMAS(ks).Authenticate(context.Background(), token)
+				if err != nil {
+					t.Fatalf("Should authenticate against the last good key set: %v", err)
+				}
+
+				if claims.Org != "sample" {
+					t.Fatalf("Should get back the claims: got org %q", claims.Org)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwks, err := authtest.NewJWKS()
+			if err != nil {
+				t.Fatalf("Should be able to start the JWKS server: %v", err)
+			}
+			defer jwks.Close()
+
+			kid, err := jwks.Rotate()
+			if err != nil {
+				t.Fatalf("Should be able to rotate the key: %v", err)
+			}
+
+			cfg := tt.cfg
+			cfg.Log = logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+			cfg.URL = jwks.JWKSURL()
+
+			ks, err := auth.NewKeySet(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("Should be able to load the key set: %v", err)
+			}
+			defer ks.Shutdown()
+
+			tt.test(t, jwks, ks, kid)
+		})
+	}
+}
+
+func Test_KeySetStartupDown(t *testing.T) {
+	jwks, err := authtest.NewJWKS()
+	if err != nil {
+		t.Fatalf("Should be able to start the JWKS server: %v", err)
+	}
+	defer jwks.Close()
+
+	jwks.SetDown(true)
+
+	cfg := auth.KeySetConfig{
+		Log: logger.New(io.Discard, logger.LevelInfo, "TEST", nil),
+		URL: jwks.JWKSURL(),
+	}
+
+	if _, err := auth.NewKeySet(context.Background(), cfg); err == nil {
+		t.Fatal("Should fail to start without an initial key set")
+	}
+}
+
+// waitFor polls the condition until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatal("Condition not met before the deadline")
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/navigacontentlab/panurge/navigaid"
+)
+
+// Set of permissions known to the publisher api.
+const (
+	PermissionRead    = "pagehub:read"
+	PermissionPublish = "pagehub:publish"
+	PermissionAdmin   = "pagehub:admin"
+)
+
+// Set of rules routes can use for authorization.
+var (
+	RuleRead    = RuleAny(PermissionRead, PermissionPublish, PermissionAdmin)
+	RulePublish = RuleAny(PermissionPublish, PermissionAdmin)
+	RuleAdmin   = RuleAll(PermissionAdmin)
+)
+
+// =============================================================================
+
+// ruleMode determines how the permissions of a rule are evaluated.
+type ruleMode int
+
+const (
+	modeAny ruleMode = iota
+	modeAll
+)
+
+// Rule represents the permissions a route requires. The permissions are
+// evaluated in the organisation of the claims, or in a unit of the
+// organisation when the rule is scoped to a unit.
+type Rule struct {
+	mode        ruleMode
+	permissions []string
+	unit        string
+	unitParam   string
+}
+
+// RuleAny constructs a rule that is satisfied when the claims hold at least
+// one of the specified permissions.
+func RuleAny(permissions ...string) Rule {
+	return Rule{
+		mode:        modeAny,
+		permissions: permissions,
+	}
+}
+
+// RuleAll constructs a rule that is satisfied when the claims hold all of the
+// specified permissions.
+func RuleAll(permissions ...string) Rule {
+	return Rule{
+		mode:        modeAll,
+		permissions: permissions,
+	}
+}
+
+// InUnit scopes the rule to the specified unit. Permissions held in the
+// organisation are inherited by the unit.
+func (r Rule) InUnit(unit string) Rule {
+	r.unit = unit
+	r.unitParam = ""
+	return r
+}
+
+// InUnitParam scopes the rule to the unit named by the specified route
+// parameter. The parameter is resolved for each request.
+func (r Rule) InUnitParam(param string) Rule {
+	r.unitParam = param
+	r.unit = ""
+	return r
+}
+
+// UnitParam returns the name of the route parameter holding the unit, if the
+// rule is scoped by a route parameter.
+func (r Rule) UnitParam() string {
+	return r.unitParam
+}
+
+// String implements the fmt.Stringer interface.
+func (r Rule) String() string {
+	mode := "any"
+	if r.mode == modeAll {
+		mode = "all"
+	}
+
+	var scope string
+	switch {
+	case r.unit != "":
+		scope = fmt.Sprintf(" unit[%s]", r.unit)
+	case r.unitParam != "":
+		scope = fmt.Sprintf(" unit[:%s]", r.unitParam)
+	}
+
+	return fmt.Sprintf("%s(%s)%s", mode, strings.Join(r.permissions, ","), scope)
+}
+
+// isSatisfied checks the claims against the rule.
+func (r Rule) isSatisfied(claims navigaid.Claims) bool {
+	if len(r.permissions) == 0 {
+		return true
+	}
+
+	perms := claims.Permissions.PermissionsInOrganisation()
+	if r.unit != "" {
+		perms = claims.Permissions.PermissionsInUnit(r.unit)
+	}
+
+	switch r.mode {
+	case modeAll:
+		for _, p := range r.permissions {
+			if !perms[p] {
+				return false
+			}
+		}
+		return true
+
+	default:
+		for _, p := range r.permissions {
+			if perms[p] {
+				return true
+			}
+		}
+		return false
+	}
+}
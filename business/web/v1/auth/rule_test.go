@@ -0,0 +1,80 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/navigacontentlab/panurge/navigaid"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+)
+
+func Test_Authorize(t *testing.T) {
+	claims := func(org []string, units map[string][]string) navigaid.Claims {
+		return navigaid.Claims{
+			Org: "sample",
+			Permissions: navigaid.PermissionsClaim{
+				Org:   org,
+				Units: units,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		claims  navigaid.Claims
+		rule    auth.Rule
+		allowed bool
+	}{
+		{"any one held", claims([]string{auth.PermissionRead}, nil), auth.RuleRead, true},
+		{"any none held", claims([]string{"other:read"}, nil), auth.RuleRead, false},
+		{"any higher held", claims([]string{auth.PermissionAdmin}, nil), auth.RulePublish, true},
+		{"all held", claims([]string{auth.PermissionRead, auth.PermissionPublish}, nil), auth.RuleAll(auth.PermissionRead, auth.PermissionPublish), true},
+		{"all partly held", claims([]string{auth.PermissionRead}, nil), auth.RuleAll(auth.PermissionRead, auth.PermissionPublish), false},
+		{"no permissions required", claims(nil, nil), auth.RuleAny(), true},
+		{"unit held in unit", claims(nil, map[string][]string{"news": {auth.PermissionPublish}}), auth.RulePublish.InUnit("news"), true},
+		{"unit held in other unit", claims(nil, map[string][]string{"sport": {auth.PermissionPublish}}), auth.RulePublish.InUnit("news"), false},
+		{"unit inherits org", claims([]string{auth.PermissionPublish}, nil), auth.RulePublish.InUnit("news"), true},
+		{"org ignores unit", claims(nil, map[string][]string{"news": {auth.PermissionPublish}}), auth.RulePublish, false},
+		{"no organisation", navigaid.Claims{Permissions: navigaid.PermissionsClaim{Org: []string{auth.PermissionAdmin}}}, auth.RuleRead, false},
+	}
+
+	a, err := auth.New(auth.Config{Authenticator: authenticator{}})
+	if err != nil {
+		t.Fatalf("Should be able to construct auth: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := a.Authorize(context.Background(), tt.claims, tt.rule)
+
+			if allowed := err == nil; allowed != tt.allowed {
+				t.Fatalf("Should get allowed %t for rule %s: got %t: %v", tt.allowed, tt.rule, allowed, err)
+			}
+		})
+	}
+}
+
+func Test_RuleString(t *testing.T) {
+	tests := []struct {
+		rule auth.Rule
+		want string
+	}{
+		{auth.RulePublish, "any(pagehub:publish,pagehub:admin)"},
+		{auth.RuleAdmin.InUnit("news"), "all(pagehub:admin) unit[news]"},
+		{auth.RuleRead.InUnitParam("unit"), "any(pagehub:read,pagehub:publish,pagehub:admin) unit[:unit]"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rule.String(); got != tt.want {
+			t.Errorf("Should format the rule as %q: got %q", tt.want, got)
+		}
+	}
+}
+
+// =============================================================================
+
+type authenticator struct{}
+
+func (authenticator) Authenticate(ctx context.Context, token string) (navigaid.Claims, error) {
+	return navigaid.Claims{}, nil
+}
@@ -5,12 +5,14 @@ import (
 	"expvar"
 	"net/http"
 	"net/http/pprof"
+
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/metrics"
 )
 
 // Mux registers all the debug routes from the standard library into a new mux
 // bypassing the use of the DefaultServerMux. Using the DefaultServerMux would
 // be a security risk since a dependency could inject a handler into our service
-// without us knowing it.
+// without us knowing it. The metrics are exposed for prometheus to scrape.
 func Mux() *http.ServeMux {
 	mux := http.NewServeMux()
 
@@ -20,6 +22,7 @@ func Mux() *http.ServeMux {
 	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/metrics", metrics.Handler())
 
 	return mux
 }
@@ -3,8 +3,21 @@ package metrics
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"expvar"
+	"net/http"
 	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // This holds the single instance of the metrics value needed for
@@ -17,11 +30,22 @@ var m *metrics
 
 // metrics represents the set of metrics we gather. These fields are
 // safe to be accessed concurrently thanks to expvar. No extra abstraction is required.
+// The prometheus metrics are kept in their own registry, so a dependency
+// can't add metrics to the exposition behind our back. The request metrics
+// are also recorded through open telemetry.
 type metrics struct {
 	goroutines *expvar.Int
 	requests   *expvar.Int
 	errors     *expvar.Int
 	panics     *expvar.Int
+
+	registry *prometheus.Registry
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+	panicked prometheus.Counter
+
+	otelDuration metric.Float64Histogram
+	otelInFlight metric.Int64UpDownCounter
 }
 
 // init constructs the metrics value that will be used to capture metrics.
@@ -34,7 +58,73 @@ func init() {
 		requests:   expvar.NewInt("requests"),
 		errors:     expvar.NewInt("errors"),
 		panics:     expvar.NewInt("panics"),
+
+		registry: prometheus.NewRegistry(),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of the requests handled by the api.",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"route", "method", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of requests being handled by the api.",
+		}, []string{"route", "method"}),
+		panicked: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "http_request_panics_total",
+			Help: "Number of requests that panicked.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.duration,
+		m.inFlight,
+		m.panicked,
+	)
+
+	// The instruments are created from the global meter provider, which
+	// hands the values to the provider set at startup.
+	meter := otel.Meter("github.com/vikaskumar1187/publisher_saas/business/web/v1/metrics")
+
+	var errs [2]error
+	m.otelDuration, errs[0] = meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Latency of the requests handled by the api."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10),
+	)
+	m.otelInFlight, errs[1] = meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithDescription("Number of requests being handled by the api."),
+		metric.WithUnit("{request}"),
+	)
+	if err := errors.Join(errs[:]...); err != nil {
+		otel.Handle(err)
+	}
+}
+
+// Handler returns the handler for the prometheus exposition of the metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		Registry:          m.registry,
+		EnableOpenMetrics: true,
+	})
+}
+
+// Register adds the collectors to the prometheus exposition.
+func Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := m.registry.Register(c); err != nil {
+			return err
+		}
 	}
+
+	return nil
+}
+
+// RegisterDB adds the connection pool stats of the database to the
+// prometheus exposition. The name tells the databases apart.
+func RegisterDB(name string, db *sql.DB) error {
+	return Register(collectors.NewDBStatsCollector(db, name))
 }
 
 // =============================================================================
@@ -48,14 +138,12 @@ func Set(ctx context.Context) context.Context {
 	return context.WithValue(ctx, key, m)
 }
 
-// AddGoroutines refreshes the goroutine metric every 100 requests.
+// AddGoroutines refreshes the goroutine metric.
 func AddGoroutines(ctx context.Context) int64 {
 	if v, ok := ctx.Value(key).(*metrics); ok {
-		if v.requests.Value()%100 == 0 {
-			g := int64(runtime.NumGoroutine())
-			v.goroutines.Set(g)
-			return g
-		}
+		g := int64(runtime.NumGoroutine())
+		v.goroutines.Set(g)
+		return g
 	}
 
 	return 0
@@ -66,7 +154,7 @@ func AddRequests(ctx context.Context) int64 {
 	v, ok := ctx.Value(key).(*metrics)
 	if ok {
 		v.requests.Add(1)
-		return v.goroutines.Value()
+		return v.requests.Value()
 	}
 
 	return 0
@@ -86,8 +174,56 @@ func AddErrors(ctx context.Context) int64 {
 func AddPanics(ctx context.Context) int64 {
 	if v, ok := ctx.Value(key).(*metrics); ok {
 		v.panics.Add(1)
+		v.panicked.Inc()
 		return v.panics.Value()
 	}
 
 	return 0
 }
+
+// AddInFlight adds the delta to the number of requests in flight for the
+// route and method.
+func AddInFlight(ctx context.Context, route string, method string, delta float64) {
+	if v, ok := ctx.Value(key).(*metrics); ok {
+		v.inFlight.WithLabelValues(routeLabel(route), method).Add(delta)
+		v.otelInFlight.Add(ctx, int64(delta), metric.WithAttributes(
+			attribute.String("http.route", routeLabel(route)),
+			attribute.String("http.request.method", method),
+		))
+	}
+}
+
+// ObserveRequest records the latency of a request by route, method and
+// status. A request that is part of a sampled trace is recorded with the
+// trace id as an exemplar.
+func ObserveRequest(ctx context.Context, route string, method string, status int, d time.Duration) {
+	v, ok := ctx.Value(key).(*metrics)
+	if !ok {
+		return
+	}
+
+	observer := v.duration.WithLabelValues(routeLabel(route), method, strconv.Itoa(status))
+
+	switch sc := trace.SpanContextFromContext(ctx); {
+	case sc.IsSampled():
+		observer.(prometheus.ExemplarObserver).ObserveWithExemplar(d.Seconds(), prometheus.Labels{"trace_id": sc.TraceID().String()})
+	default:
+		observer.Observe(d.Seconds())
+	}
+
+	v.otelDuration.Record(ctx, d.Seconds(), metric.WithAttributes(
+		attribute.String("http.route", routeLabel(route)),
+		attribute.String("http.request.method", method),
+		attribute.Int("http.response.status_code", status),
+	))
+}
+
+// routeLabel keeps the requests handled outside of a route, such as the
+// CORS preflight requests, under a single label.
+func routeLabel(route string) string {
+	if route == "" {
+		return "other"
+	}
+
+	return route
+}
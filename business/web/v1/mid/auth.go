@@ -4,15 +4,26 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/navigacontentlab/panurge/navigaid"
 	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
 	"github.com/vikaskumar1187/publisher_saas/foundation/web"
 )
 
-// Authenticate validates a JWT from the `Authorization` header.
+// Authenticate validates an api key from the `X-API-Key` header if present,
+// otherwise a JWT from the `Authorization` header.
 func Authenticate(a *auth.Auth) web.Middleware {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-			claims, err := a.Authenticate(ctx, r.Header.Get("authorization"))
+			var claims navigaid.Claims
+			var err error
+
+			switch key := r.Header.Get(auth.APIKeyHeader); key {
+			case "":
+				claims, err = a.Authenticate(ctx, r.Header.Get("authorization"))
+			default:
+				claims, err = a.AuthenticateAPIKey(ctx, key)
+			}
+
 			if err != nil {
 				return auth.NewAuthError("authenticate: failed: %s", err)
 			}
@@ -28,15 +39,24 @@ func Authenticate(a *auth.Auth) web.Middleware {
 	return m
 }
 
-// Authorize validates that an authenticated user has at least one role from a
-// specified list. This method constructs the actual function that is used.
-func Authorize(a *auth.Auth, rule string) web.Middleware {
+// Authorize validates that an authenticated user satisfies the specified
+// rule. A rule scoped to a route parameter is resolved against the request.
+// This method constructs the actual function that is used.
+func Authorize(a *auth.Auth, rule auth.Rule) web.Middleware {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			claims := auth.GetClaims(ctx)
+			if claims.Subject == "" {
+				return auth.NewAuthError("authorize: you are not authorized for that action, no claims")
+			}
+
+			rule := rule
+			if param := rule.UnitParam(); param != "" {
+				rule = rule.InUnit(web.Param(r, param))
+			}
 
-			if err := a.Authorize(ctx, claims); err != nil {
-				return auth.NewAuthError("authorize: you are not authorized for that action, claims[%v] : %s", err)
+			if err := a.Authorize(ctx, claims, rule); err != nil {
+				return auth.NewForbiddenError("authorize: you are not authorized for that action, rule[%s] subject[%s]: %s", rule, claims.Subject, err)
 			}
 
 			return handler(ctx, w, r)
@@ -0,0 +1,112 @@
+package mid_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/navigacontentlab/panurge/navigaid"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/mid"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+func Test_AuthStatus(t *testing.T) {
+	tokens := map[string]navigaid.Claims{
+		"reader":    claims(auth.PermissionRead),
+		"publisher": claims(auth.PermissionPublish),
+		"admin":     claims(auth.PermissionAdmin),
+		"unit": {
+			RegisteredClaims: jwt.RegisteredClaims{Subject: "unit"},
+			Org:              "sample",
+			TokenType:        navigaid.TokenTypeAccessToken,
+			Permissions: navigaid.PermissionsClaim{
+				Units: map[string][]string{"news": {auth.PermissionPublish}},
+			},
+		},
+	}
+
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	a, err := auth.New(auth.Config{Log: log, Authenticator: authenticator(tokens)})
+	if err != nil {
+		t.Fatalf("Should be able to construct auth: %v", err)
+	}
+
+	ok := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+
+	app := web.NewApp(make(chan os.Signal, 1), nil, mid.Errors(log))
+	app.Handle(http.MethodGet, "v1", "/pages", ok, mid.Authenticate(a), mid.Authorize(a, auth.RuleRead))
+	app.Handle(http.MethodPost, "v1", "/pages", ok, mid.Authenticate(a), mid.Authorize(a, auth.RulePublish))
+	app.Handle(http.MethodDelete, "v1", "/pages", ok, mid.Authenticate(a), mid.Authorize(a, auth.RuleAdmin))
+	app.Handle(http.MethodPost, "v1", "/units/:unit/pages", ok, mid.Authenticate(a), mid.Authorize(a, auth.RulePublish.InUnitParam("unit")))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		status int
+	}{
+		{"no header", http.MethodGet, "/v1/pages", "", http.StatusUnauthorized},
+		{"malformed header", http.MethodGet, "/v1/pages", "reader", http.StatusUnauthorized},
+		{"invalid token", http.MethodGet, "/v1/pages", "Bearer unknown", http.StatusUnauthorized},
+		{"read as reader", http.MethodGet, "/v1/pages", "Bearer reader", http.StatusNoContent},
+		{"publish as reader", http.MethodPost, "/v1/pages", "Bearer reader", http.StatusForbidden},
+		{"publish as publisher", http.MethodPost, "/v1/pages", "Bearer publisher", http.StatusNoContent},
+		{"publish as admin", http.MethodPost, "/v1/pages", "Bearer admin", http.StatusNoContent},
+		{"admin as publisher", http.MethodDelete, "/v1/pages", "Bearer publisher", http.StatusForbidden},
+		{"admin as admin", http.MethodDelete, "/v1/pages", "Bearer admin", http.StatusNoContent},
+		{"unit param held", http.MethodPost, "/v1/units/news/pages", "Bearer unit", http.StatusNoContent},
+		{"unit param not held", http.MethodPost, "/v1/units/sport/pages", "Bearer unit", http.StatusForbidden},
+		{"unit param inherits org", http.MethodPost, "/v1/units/sport/pages", "Bearer publisher", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			app.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("Should receive status %d: got %d: %s", tt.status, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+type authenticator map[string]navigaid.Claims
+
+func (a authenticator) Authenticate(ctx context.Context, token string) (navigaid.Claims, error) {
+	claims, exists := a[token]
+	if !exists {
+		return navigaid.Claims{}, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
+func claims(permissions ...string) navigaid.Claims {
+	return navigaid.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: permissions[0]},
+		Org:              "sample",
+		TokenType:        navigaid.TokenTypeAccessToken,
+		Permissions: navigaid.PermissionsClaim{
+			Org: permissions,
+		},
+	}
+}
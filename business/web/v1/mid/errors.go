@@ -2,8 +2,10 @@ package mid
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/dberr"
 	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
 	"github.com/vikaskumar1187/publisher_saas/business/web/v1/response"
 	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
@@ -13,17 +15,23 @@ import (
 
 // Errors handles errors coming out of the call chain. It detects normal
 // application errors which are used to respond to the client in a uniform way.
-// Unexpected errors (status >= 500) are logged.
+// Unexpected errors (status >= 500) are logged at Error and the errors of the
+// client's request at Info, both with the status. Database errors that
+// reached the handler unmapped are reported as 409, 422 or 503 by their kind.
+// A stream that failed part way is only logged, since its status was sent.
 func Errors(log *logger.Logger) web.Middleware {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			if err := handler(ctx, w, r); err != nil {
-				log.Error(ctx, "message", "msg", err)
-
 				ctx, span := web.AddSpan(ctx, "business.web.request.mid.error")
 				span.RecordError(err)
 				span.End()
 
+				if web.IsStreamError(err) {
+					log.Error(ctx, "message", "status", web.GetValues(ctx).StatusCode, "msg", err)
+					return nil
+				}
+
 				var er response.ErrorDocument
 				var status int
 
@@ -46,12 +54,37 @@ func Errors(log *logger.Logger) web.Middleware {
 					}
 					status = reqErr.Status
 
+				case auth.IsForbiddenError(err):
+					er = response.ErrorDocument{
+						Error: http.StatusText(http.StatusForbidden),
+					}
+					status = http.StatusForbidden
+
 				case auth.IsAuthError(err):
 					er = response.ErrorDocument{
 						Error: http.StatusText(http.StatusUnauthorized),
 					}
 					status = http.StatusUnauthorized
 
+				case dberr.IsConflict(err):
+					er = response.ErrorDocument{
+						Error: dbErrorKind(err),
+					}
+					status = http.StatusConflict
+
+				case dberr.IsInvalid(err):
+					er = response.ErrorDocument{
+						Error: dbErrorKind(err),
+					}
+					status = http.StatusUnprocessableEntity
+
+				case dberr.IsUnavailable(err):
+					w.Header().Set("Retry-After", "1")
+					er = response.ErrorDocument{
+						Error: http.StatusText(http.StatusServiceUnavailable),
+					}
+					status = http.StatusServiceUnavailable
+
 				default:
 					er = response.ErrorDocument{
 						Error: http.StatusText(http.StatusInternalServerError),
@@ -59,6 +92,13 @@ func Errors(log *logger.Logger) web.Middleware {
 					status = http.StatusInternalServerError
 				}
 
+				switch {
+				case status >= http.StatusInternalServerError:
+					log.Error(ctx, "message", "status", status, "msg", err)
+				default:
+					log.Info(ctx, "message", "status", status, "msg", err)
+				}
+
 				if err := web.Respond(ctx, w, er, status); err != nil {
 					return err
 				}
@@ -78,3 +118,14 @@ func Errors(log *logger.Logger) web.Middleware {
 
 	return m
 }
+
+// dbErrorKind returns the kind of the database error without the details of
+// the schema.
+func dbErrorKind(err error) string {
+	var dbErr *dberr.Error
+	if errors.As(err, &dbErr) {
+		return dbErr.Kind.Error()
+	}
+
+	return err.Error()
+}
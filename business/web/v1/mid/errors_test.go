@@ -0,0 +1,90 @@
+package mid_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/vikaskumar1187/publisher_saas/business/data/dbsql/dberr"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/mid"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/response"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+func Test_ErrorsStatus(t *testing.T) {
+	dbError := func(code string) error {
+		pgErr := &pgconn.PgError{Code: code, Message: "failed", ConstraintName: "pages_name_key"}
+		return fmt.Errorf("create: %w", dberr.New(pgErr, pgErr.Code, pgErr.Message, "", "pages", "", pgErr.ConstraintName))
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		status     int
+		message    string
+		retryAfter string
+	}{
+		{name: "request error", err: response.NewError(errors.New("page not found"), http.StatusNotFound), status: http.StatusNotFound, message: "page not found"},
+		{name: "duplicated entry", err: dbError("23505"), status: http.StatusConflict, message: "duplicated entry"},
+		{name: "foreign key", err: dbError("23503"), status: http.StatusConflict, message: "foreign key violation"},
+		{name: "check violation", err: dbError("23514"), status: http.StatusUnprocessableEntity, message: "check violation"},
+		{name: "deadlock", err: dbError("40P01"), status: http.StatusServiceUnavailable, message: "Service Unavailable", retryAfter: "1"},
+		{name: "connection", err: dbError("08006"), status: http.StatusServiceUnavailable, message: "Service Unavailable", retryAfter: "1"},
+		{name: "unclassified", err: errors.New("boom"), status: http.StatusInternalServerError, message: "Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errorStatus any
+			events := logger.Events{
+				Error: func(ctx context.Context, r logger.Record) { errorStatus = r.Attributes["status"] },
+			}
+			log := logger.NewWithEvents(io.Discard, logger.LevelInfo, "TEST", nil, events)
+
+			app := web.NewApp(make(chan os.Signal, 1), nil, mid.Errors(log))
+			app.Handle(http.MethodGet, "v1", "/pages", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return tt.err
+			})
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/pages", nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("Should respond %d: got %d", tt.status, w.Code)
+			}
+
+			var doc response.ErrorDocument
+			if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+				t.Fatalf("Should respond with an error document: %v", err)
+			}
+
+			if doc.Error != tt.message {
+				t.Fatalf("Should respond with %q without the schema details: got %q", tt.message, doc.Error)
+			}
+
+			if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Fatalf("Should set Retry-After %q: got %q", tt.retryAfter, got)
+			}
+
+			// Only the failures of the service are logged as errors.
+			switch {
+			case tt.status >= http.StatusInternalServerError:
+				if fmt.Sprint(errorStatus) != fmt.Sprint(tt.status) {
+					t.Fatalf("Should log an error with status %d: got %v", tt.status, errorStatus)
+				}
+			default:
+				if errorStatus != nil {
+					t.Fatalf("Should not log an error for status %d: got %v", tt.status, errorStatus)
+				}
+			}
+		})
+	}
+}
@@ -3,28 +3,37 @@ package mid
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/vikaskumar1187/publisher_saas/business/web/v1/metrics"
 	"github.com/vikaskumar1187/publisher_saas/foundation/web"
 )
 
-// Metrics updates program counters.
+// Metrics updates program counters and records the latency of the request
+// by route, method and status. It runs outside of the Errors middleware so
+// the status of a failed request is known.
 func Metrics() web.Middleware {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			ctx = metrics.Set(ctx)
 
+			route := web.GetRoute(ctx)
+			start := time.Now()
+
+			metrics.AddInFlight(ctx, route, r.Method, 1)
 			err := handler(ctx, w, r)
+			metrics.AddInFlight(ctx, route, r.Method, -1)
 
-			n := metrics.AddRequests(ctx)
-			if n%1000 == 0 {
-				metrics.AddGoroutines(ctx)
-			}
+			metrics.AddRequests(ctx)
+			metrics.AddGoroutines(ctx)
 
-			if err != nil {
+			status := web.GetValues(ctx).StatusCode
+			if err != nil || status >= http.StatusBadRequest {
 				metrics.AddErrors(ctx)
 			}
 
+			metrics.ObserveRequest(ctx, route, r.Method, status, time.Since(start))
+
 			return err
 		}
 
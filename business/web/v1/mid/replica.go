@@ -0,0 +1,33 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+// ReadYourWrites runs every query of the requests that change data on the
+// primary, so what they read before writing is never older than the data
+// they write over. A client that must read its own writes can send the
+// X-Read-Your-Writes header to get the same for a read.
+func ReadYourWrites() web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			switch {
+			case r.Method != http.MethodGet && r.Method != http.MethodHead:
+				ctx = db.WithPrimary(ctx)
+
+			case r.Header.Get("X-Read-Your-Writes") != "":
+				ctx = db.WithPrimary(ctx)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
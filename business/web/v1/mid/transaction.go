@@ -1,6 +1,7 @@
 package mid
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
@@ -13,14 +14,18 @@ import (
 )
 
 // ExecuteInTransation starts a transaction around all the storage calls within
-// the scope of the handler function.
+// the scope of the handler function. The transaction is committed only when
+// the handler succeeds with a status below 400. It is bound to the request
+// context so it is rolled back when the client goes away. The response of
+// the handler is held back until the commit, so a client is never told the
+// request succeeded when the commit failed.
 func ExecuteInTransation(log *logger.Logger, bgn transaction.Beginner) web.Middleware {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 			hasCommited := false
 
 			log.Info(ctx, "BEGIN TRANSACTION")
-			tx, err := bgn.Begin()
+			tx, err := bgn.BeginTx(ctx, nil)
 			if err != nil {
 				return fmt.Errorf("BEGIN TRANSACTION: %w", err)
 			}
@@ -40,10 +45,17 @@ func ExecuteInTransation(log *logger.Logger, bgn transaction.Beginner) web.Middl
 
 			ctx = transaction.Set(ctx, tx)
 
-			if err := handler(ctx, w, r); err != nil {
+			var resp txResponse
+			if err := handler(ctx, &resp, r); err != nil {
 				return fmt.Errorf("EXECUTE TRANSACTION: %w", err)
 			}
 
+			// A handler can respond with an error status without returning an
+			// error, so the status decides whether the work is kept.
+			if web.GetValues(ctx).StatusCode >= http.StatusBadRequest {
+				return resp.send(w)
+			}
+
 			log.Info(ctx, "COMMIT TRANSACTION")
 			if err := tx.Commit(); err != nil {
 				return fmt.Errorf("COMMIT TRANSACTION: %w", err)
@@ -51,7 +63,7 @@ func ExecuteInTransation(log *logger.Logger, bgn transaction.Beginner) web.Middl
 
 			hasCommited = true
 
-			return nil
+			return resp.send(w)
 		}
 
 		return h
@@ -59,3 +71,52 @@ func ExecuteInTransation(log *logger.Logger, bgn transaction.Beginner) web.Middl
 
 	return m
 }
+
+// txResponse holds the response of a handler running in a transaction until
+// the transaction is done.
+type txResponse struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+// Header implements the http.ResponseWriter interface.
+func (tr *txResponse) Header() http.Header {
+	if tr.header == nil {
+		tr.header = make(http.Header)
+	}
+
+	return tr.header
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (tr *txResponse) WriteHeader(statusCode int) {
+	if tr.statusCode == 0 {
+		tr.statusCode = statusCode
+	}
+}
+
+// Write implements the http.ResponseWriter interface.
+func (tr *txResponse) Write(b []byte) (int, error) {
+	tr.WriteHeader(http.StatusOK)
+	return tr.body.Write(b)
+}
+
+// send writes the held response to the client.
+func (tr *txResponse) send(w http.ResponseWriter) error {
+	for k, v := range tr.header {
+		w.Header()[k] = v
+	}
+
+	if tr.statusCode == 0 {
+		return nil
+	}
+
+	w.WriteHeader(tr.statusCode)
+
+	if _, err := w.Write(tr.body.Bytes()); err != nil {
+		return fmt.Errorf("SEND RESPONSE: %w", err)
+	}
+
+	return nil
+}
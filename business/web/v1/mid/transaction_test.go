@@ -0,0 +1,116 @@
+package mid_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/vikaskumar1187/publisher_saas/business/data/transaction"
+	"github.com/vikaskumar1187/publisher_saas/business/web/v1/mid"
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+func Test_ExecuteInTransaction(t *testing.T) {
+	created := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if _, ok := transaction.Get(ctx); !ok {
+			return errors.New("no transaction")
+		}
+		return web.Respond(ctx, w, map[string]string{"id": "1"}, http.StatusCreated)
+	}
+
+	invalid := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.Respond(ctx, w, map[string]string{"error": "bad"}, http.StatusBadRequest)
+	}
+
+	failed := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusCreated)
+		return errors.New("failed after writing")
+	}
+
+	tests := []struct {
+		name      string
+		handler   web.Handler
+		commitErr error
+		status    int
+		body      string
+		committed bool
+	}{
+		{name: "committed", handler: created, status: http.StatusCreated, body: `"id":"1"`, committed: true},
+		{name: "commit fails", handler: created, commitErr: errors.New("connection reset"), status: http.StatusInternalServerError},
+		{name: "error status", handler: invalid, status: http.StatusBadRequest, body: `"error":"bad"`},
+		{name: "handler error", handler: failed, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+			tx := fakeTx{commitErr: tt.commitErr}
+
+			app := web.NewApp(make(chan os.Signal, 1), nil, mid.Errors(log))
+			app.Handle(http.MethodPost, "v1", "/pages", tt.handler, mid.ExecuteInTransation(log, fakeBeginner{tx: &tx}))
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/pages", nil)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("Should respond %d: got %d", tt.status, w.Code)
+			}
+
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Fatalf("Should respond with %s: got %s", tt.body, w.Body.String())
+			}
+
+			if tx.committed != tt.committed {
+				t.Fatalf("Should commit %t: got %t", tt.committed, tx.committed)
+			}
+
+			if !tt.committed && !tx.rolledBack {
+				t.Fatal("Should roll back the transaction")
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+type fakeBeginner struct {
+	tx *fakeTx
+}
+
+func (b fakeBeginner) Begin() (transaction.Transaction, error) {
+	return b.tx, nil
+}
+
+func (b fakeBeginner) BeginTx(ctx context.Context, opts *sql.TxOptions) (transaction.Transaction, error) {
+	return b.tx, nil
+}
+
+type fakeTx struct {
+	commitErr  error
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakeTx) Commit() error {
+	if tx.commitErr != nil {
+		return tx.commitErr
+	}
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	if tx.committed {
+		return sql.ErrTxDone
+	}
+	tx.rolledBack = true
+	return nil
+}
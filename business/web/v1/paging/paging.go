@@ -0,0 +1,53 @@
+// Package paging provides support for query paging.
+package paging
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vikaskumar1187/publisher_saas/foundation/validate"
+)
+
+// Set of limits applied to paging requests.
+const (
+	DefaultRowsPerPage = 10
+	MaxRowsPerPage     = 100
+)
+
+// Page represents the requested page and rows per page.
+type Page struct {
+	Number      int
+	RowsPerPage int
+}
+
+// ParseRequest parses the request for the page and rows query string. The
+// defaults are provided as well.
+func ParseRequest(r *http.Request) (Page, error) {
+	values := r.URL.Query()
+
+	number := 1
+	if page := values.Get("page"); page != "" {
+		var err error
+		number, err = strconv.Atoi(page)
+		if err != nil || number <= 0 {
+			return Page{}, validate.NewFieldsError("page", fmt.Errorf("must be a positive number"))
+		}
+	}
+
+	rowsPerPage := DefaultRowsPerPage
+	if rows := values.Get("rows"); rows != "" {
+		var err error
+		rowsPerPage, err = strconv.Atoi(rows)
+		if err != nil || rowsPerPage <= 0 || rowsPerPage > MaxRowsPerPage {
+			return Page{}, validate.NewFieldsError("rows", fmt.Errorf("must be between 1 and %d", MaxRowsPerPage))
+		}
+	}
+
+	p := Page{
+		Number:      number,
+		RowsPerPage: rowsPerPage,
+	}
+
+	return p, nil
+}
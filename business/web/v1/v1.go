@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"github.com/jmoiron/sqlx"
+	db "github.com/vikaskumar1187/publisher_saas/business/data/dbsql/pgx"
+	"github.com/vikaskumar1187/publisher_saas/business/data/migrate"
 	"github.com/vikaskumar1187/publisher_saas/business/web/v1/auth"
 	"github.com/vikaskumar1187/publisher_saas/business/web/v1/mid"
 	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
@@ -34,7 +36,13 @@ type APIMuxConfig struct {
 	Log         *logger.Logger
 	Auth        *auth.Auth
 	DB          *sqlx.DB
+	Replica     *db.Replica
+	Migrations  *migrate.Gate
 	Tracer      trace.Tracer
+
+	// Tran is the transaction middleware routes opt in to. APIMux constructs
+	// it from the DB when it isn't provided.
+	Tran web.Middleware
 }
 
 // RouteAdder defines behavior that sets the routes to bind for an instance
@@ -54,15 +62,20 @@ func APIMux(cfg APIMuxConfig, routeAdder RouteAdder, options ...func(opts *Optio
 		cfg.Shutdown,
 		cfg.Tracer,
 		mid.Logger(cfg.Log),
-		mid.Errors(cfg.Log),
 		mid.Metrics(),
+		mid.Errors(cfg.Log),
 		mid.Panics(),
+		mid.ReadYourWrites(),
 	)
 
 	if opts.corsOrigin != "" {
 		app.EnableCORS(mid.Cors(opts.corsOrigin))
 	}
 
+	if cfg.Tran == nil {
+		cfg.Tran = mid.ExecuteInTransation(cfg.Log, db.NewBeginner(cfg.DB))
+	}
+
 	routeAdder.Add(app, cfg)
 
 	return app
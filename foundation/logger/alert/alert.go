@@ -0,0 +1,456 @@
+// Package alert turns the error records of the logger into notifications.
+// Repeats of a record are folded together, the alerts are sent in batches
+// and every notifier only receives the levels routed to it, at a limited
+// rate.
+package alert
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+// This holds the metrics for the alerts. The expvar package is based on a
+// singleton so these are registered once for the process.
+var am = struct {
+	sent    *expvar.Int
+	failed  *expvar.Int
+	dropped *expvar.Int
+}{
+	sent:    expvar.NewInt("alerts_sent"),
+	failed:  expvar.NewInt("alerts_failed"),
+	dropped: expvar.NewInt("alerts_dropped"),
+}
+
+// Alert represents a log record to notify about. Count is the number of
+// times the record was logged since the last alert for it.
+type Alert struct {
+	Time       time.Time      `json:"time"`
+	Level      string         `json:"level"`
+	Message    string         `json:"message"`
+	Error      string         `json:"error,omitempty"`
+	File       string         `json:"file"`
+	TraceID    string         `json:"traceID,omitempty"`
+	Count      int            `json:"count"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+
+	level logger.Level
+}
+
+func toAlert(r logger.Record) Alert {
+	a := Alert{
+		Time:       r.Time,
+		Level:      levelName(r.Level),
+		Message:    r.Message,
+		File:       r.File,
+		Count:      1,
+		Attributes: make(map[string]any, len(r.Attributes)),
+		level:      r.Level,
+	}
+
+	for k, v := range r.Attributes {
+		switch k {
+		case "msg":
+			a.Error = fmt.Sprint(v)
+		case "trace_id":
+			a.TraceID = fmt.Sprint(v)
+		case "span_id", "trace_flags":
+		default:
+			a.Attributes[k] = v
+		}
+	}
+
+	return a
+}
+
+// key identifies the repeats of an alert. Records logged from the same
+// place with a different level or status, such as a handler error that
+// is a 503 rather than a 500, are alerted on separately.
+func (a Alert) key() string {
+	key := a.Level + "|" + a.Message + "|" + a.File
+
+	if status, exists := a.Attributes["status"]; exists {
+		key += "|" + fmt.Sprint(status)
+	}
+
+	return key
+}
+
+// Notifier represents a destination for the alerts.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, service string, alerts []Alert) error
+}
+
+// =============================================================================
+
+// Route sends the alerts at or above the level to the notifier.
+type Route struct {
+	Notifier Notifier
+	Level    logger.Level
+}
+
+// ParseLevel parses the minimum level of a route, warn or error.
+func ParseLevel(level string) (logger.Level, error) {
+	switch strings.ToLower(level) {
+	case "warn":
+		return logger.LevelWarn, nil
+	case "error":
+		return logger.LevelError, nil
+	}
+
+	return 0, fmt.Errorf("unknown alert level %q", level)
+}
+
+func levelName(level logger.Level) string {
+	switch {
+	case level >= logger.LevelError:
+		return "ERROR"
+	case level >= logger.LevelWarn:
+		return "WARN"
+	case level >= logger.LevelInfo:
+		return "INFO"
+	}
+
+	return "DEBUG"
+}
+
+// =============================================================================
+
+// Config represents the settings of the alerts.
+type Config struct {
+	Log           *logger.Logger
+	Service       string
+	Routes        []Route
+	BatchInterval time.Duration
+	BatchSize     int
+	DedupWindow   time.Duration
+	RateLimit     int
+	MaxPending    int
+	Timeout       time.Duration
+}
+
+// sent holds an alert that was sent and the repeats held back since.
+type sent struct {
+	at    time.Time
+	alert Alert
+	held  int
+}
+
+// route holds the rate limit state of a notifier.
+type route struct {
+	Route
+	windowStart time.Time
+	sent        int
+}
+
+// Alerter collects the records handed to Alert and sends them to the
+// notifiers on the batch interval. A record logged again before its alert
+// was sent only raises the count. Once sent, the repeats within the dedup
+// window are held back and sent as one alert when the window ends.
+type Alerter struct {
+	log           *logger.Logger
+	service       string
+	routes        []*route
+	batchInterval time.Duration
+	batchSize     int
+	dedupWindow   time.Duration
+	rateLimit     int
+	maxPending    int
+	timeout       time.Duration
+
+	mu      sync.Mutex
+	started bool
+	pending map[string]*Alert
+	order   []string
+	sent    map[string]*sent
+
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New constructs an Alerter that ignores the records until it is started.
+// This lets the logger be built with the Alert events before the
+// configuration of the alerts is known.
+func New() *Alerter {
+	return &Alerter{
+		pending:  make(map[string]*Alert),
+		sent:     make(map[string]*sent),
+		shutdown: make(chan struct{}),
+	}
+}
+
+// Start applies the configuration and begins sending the alerts in the
+// background. The alerter keeps ignoring the records when no route is
+// configured.
+func (a *Alerter) Start(cfg Config) error {
+	if cfg.BatchInterval <= 0 {
+		cfg.BatchInterval = 10 * time.Second
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = 1000
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	routes := make([]*route, len(cfg.Routes))
+	for i, r := range cfg.Routes {
+		if r.Notifier == nil {
+			return errors.New("route without a notifier")
+		}
+		routes[i] = &route{Route: r}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.started {
+		return errors.New("alerter already started")
+	}
+
+	a.log = cfg.Log
+	a.service = cfg.Service
+	a.routes = routes
+	a.batchInterval = cfg.BatchInterval
+	a.batchSize = cfg.BatchSize
+	a.dedupWindow = cfg.DedupWindow
+	a.rateLimit = cfg.RateLimit
+	a.maxPending = cfg.MaxPending
+	a.timeout = cfg.Timeout
+
+	if len(routes) == 0 {
+		return nil
+	}
+
+	a.started = true
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.run()
+	}()
+
+	return nil
+}
+
+// Shutdown stops the alerter and sends the pending alerts.
+func (a *Alerter) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	started := a.started
+	a.started = false
+	a.mu.Unlock()
+
+	if !started {
+		return nil
+	}
+
+	close(a.shutdown)
+
+	ch := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(ch)
+	}()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// =============================================================================
+
+type ctxKey int
+
+const quietKey ctxKey = 1
+
+// quiet marks the records the alerter logs about itself, so a notifier that
+// is down doesn't raise alerts about failing to send alerts.
+func quiet(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quietKey, true)
+}
+
+// Alert collects the record to be sent with the next batch. It matches the
+// logger.EventFunc signature and never blocks on a notifier.
+func (a *Alerter) Alert(ctx context.Context, r logger.Record) {
+	if q, _ := ctx.Value(quietKey).(bool); q {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.started || !a.routed(r.Level) {
+		return
+	}
+
+	alert := toAlert(r)
+	key := alert.key()
+
+	if p, exists := a.pending[key]; exists {
+		p.Count++
+		return
+	}
+
+	if s, exists := a.sent[key]; exists {
+		s.held++
+		return
+	}
+
+	a.add(key, alert)
+}
+
+// add makes the alert pending unless too many already are.
+func (a *Alerter) add(key string, alert Alert) {
+	if len(a.pending) >= a.maxPending {
+		am.dropped.Add(int64(alert.Count))
+		return
+	}
+
+	a.pending[key] = &alert
+	a.order = append(a.order, key)
+}
+
+// routed reports whether any notifier receives alerts at the level.
+func (a *Alerter) routed(level logger.Level) bool {
+	for _, r := range a.routes {
+		if level >= r.Level {
+			return true
+		}
+	}
+
+	return false
+}
+
+// run sends the pending alerts on the batch interval until the alerter is
+// shut down, then sends what is left.
+func (a *Alerter) run() {
+	ticker := time.NewTicker(a.batchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.flush()
+		case <-a.shutdown:
+			a.flush()
+			return
+		}
+	}
+}
+
+// flush takes the pending alerts and sends them to the notifiers of their
+// level, in batches of the batch size.
+func (a *Alerter) flush() {
+	now := time.Now()
+
+	a.mu.Lock()
+
+	// The repeats held back in a window that has ended go out as one alert,
+	// which starts a new window.
+	for key, s := range a.sent {
+		if now.Sub(s.at) < a.dedupWindow {
+			continue
+		}
+
+		delete(a.sent, key)
+
+		if _, exists := a.pending[key]; !exists && s.held > 0 {
+			alert := s.alert
+			alert.Time = now
+			alert.Count = s.held
+			a.add(key, alert)
+		}
+	}
+
+	alerts := make([]Alert, 0, len(a.order))
+	for _, key := range a.order {
+		alert := *a.pending[key]
+		alerts = append(alerts, alert)
+
+		if a.dedupWindow > 0 {
+			a.sent[key] = &sent{at: now, alert: alert}
+		}
+	}
+	a.pending = make(map[string]*Alert)
+	a.order = nil
+
+	a.mu.Unlock()
+
+	if len(alerts) == 0 {
+		return
+	}
+
+	ctx := quiet(context.Background())
+
+	for _, r := range a.routes {
+		routed := make([]Alert, 0, len(alerts))
+		for _, alert := range alerts {
+			if alert.level >= r.Level {
+				routed = append(routed, alert)
+			}
+		}
+
+		for len(routed) > 0 {
+			n := min(len(routed), a.batchSize)
+			batch := routed[:n]
+			routed = routed[n:]
+
+			if !a.allow(r, now) {
+				am.dropped.Add(int64(len(batch)))
+				a.log.Warn(ctx, "alerts", "status", "rate limited", "notifier", r.Notifier.Name(), "dropped", len(batch))
+				continue
+			}
+
+			a.notify(ctx, r, batch)
+		}
+	}
+}
+
+// allow reports whether the notifier can be sent another batch, allowing
+// the rate limit of batches per minute.
+func (a *Alerter) allow(r *route, now time.Time) bool {
+	if a.rateLimit <= 0 {
+		return true
+	}
+
+	if now.Sub(r.windowStart) >= time.Minute {
+		r.windowStart = now
+		r.sent = 0
+	}
+
+	if r.sent >= a.rateLimit {
+		return false
+	}
+	r.sent++
+
+	return true
+}
+
+func (a *Alerter) notify(ctx context.Context, r *route, batch []Alert) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	if err := r.Notifier.Notify(ctx, a.service, batch); err != nil {
+		am.failed.Add(int64(len(batch)))
+		a.log.Warn(ctx, "alerts", "status", "notifying", "notifier", r.Notifier.Name(), "alerts", len(batch), "msg", err)
+		return
+	}
+
+	am.sent.Add(int64(len(batch)))
+}
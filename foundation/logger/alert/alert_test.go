@@ -0,0 +1,212 @@
+package alert
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vikaskumar1187/publisher_saas/foundation/logger"
+)
+
+func Test_AlertDedup(t *testing.T) {
+	n := &notifier{}
+	a := start(t, Config{Routes: []Route{{Notifier: n, Level: logger.LevelError}}, DedupWindow: time.Hour})
+
+	ctx := context.Background()
+
+	a.Alert(ctx, record(logger.LevelError, "handler.go:10", 500))
+	a.Alert(ctx, record(logger.LevelError, "handler.go:10", 500))
+	a.Alert(ctx, record(logger.LevelError, "handler.go:10", 503))
+	a.Alert(ctx, record(logger.LevelError, "relay.go:20", nil))
+	a.flush()
+
+	batches := n.take()
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Fatalf("Should send one batch of 3 alerts: got %v", batches)
+	}
+
+	if got := batches[0][0]; got.Count != 2 || got.Attributes["status"] != 500 {
+		t.Fatalf("Should fold the repeats of the 500 into one alert: got %+v", got)
+	}
+
+	if got := batches[0][1]; got.Count != 1 || got.Attributes["status"] != 503 {
+		t.Fatalf("Should alert on the 503 separately: got %+v", got)
+	}
+
+	// The repeats within the dedup window are held back.
+	a.Alert(ctx, record(logger.LevelError, "handler.go:10", 500))
+	a.Alert(ctx, record(logger.LevelError, "handler.go:10", 500))
+	a.flush()
+
+	if batches := n.take(); len(batches) != 0 {
+		t.Fatalf("Should hold back the repeats within the dedup window: got %v", batches)
+	}
+
+	// Once the window ends they go out as one alert.
+	a.mu.Lock()
+	for _, s := range a.sent {
+		s.at = s.at.Add(-time.Hour)
+	}
+	a.mu.Unlock()
+	a.flush()
+
+	batches = n.take()
+	if len(batches) != 1 || len(batches[0]) != 1 || batches[0][0].Count != 2 {
+		t.Fatalf("Should send the held repeats as one alert with count 2: got %v", batches)
+	}
+}
+
+func Test_AlertRateLimit(t *testing.T) {
+	n := &notifier{}
+	a := start(t, Config{Routes: []Route{{Notifier: n, Level: logger.LevelError}}, BatchSize: 1, RateLimit: 2})
+
+	ctx := context.Background()
+
+	a.Alert(ctx, record(logger.LevelError, "a.go:1", nil))
+	a.Alert(ctx, record(logger.LevelError, "b.go:1", nil))
+	a.Alert(ctx, record(logger.LevelError, "c.go:1", nil))
+	a.flush()
+
+	if batches := n.take(); len(batches) != 2 {
+		t.Fatalf("Should send 2 batches a minute: got %d", len(batches))
+	}
+
+	a.Alert(ctx, record(logger.LevelError, "d.go:1", nil))
+	a.flush()
+
+	if batches := n.take(); len(batches) != 0 {
+		t.Fatalf("Should drop the batches over the rate limit: got %d", len(batches))
+	}
+
+	// A new minute starts a new window.
+	a.mu.Lock()
+	a.routes[0].windowStart = a.routes[0].windowStart.Add(-time.Minute)
+	a.mu.Unlock()
+
+	a.Alert(ctx, record(logger.LevelError, "e.go:1", nil))
+	a.flush()
+
+	if batches := n.take(); len(batches) != 1 {
+		t.Fatalf("Should send again in the next minute: got %d", len(batches))
+	}
+}
+
+func Test_AlertRoutes(t *testing.T) {
+	warn := &notifier{}
+	errs := &notifier{}
+	a := start(t, Config{Routes: []Route{
+		{Notifier: warn, Level: logger.LevelWarn},
+		{Notifier: errs, Level: logger.LevelError},
+	}})
+
+	ctx := context.Background()
+
+	a.Alert(ctx, record(logger.LevelInfo, "a.go:1", 404))
+	a.Alert(ctx, record(logger.LevelWarn, "b.go:1", nil))
+	a.Alert(ctx, record(logger.LevelError, "c.go:1", 500))
+	a.flush()
+
+	if got := warn.take(); len(got) != 1 || len(got[0]) != 2 {
+		t.Fatalf("Should send the warn and error alerts to the warn route: got %v", got)
+	}
+
+	if got := errs.take(); len(got) != 1 || len(got[0]) != 1 || got[0][0].Level != "ERROR" {
+		t.Fatalf("Should send only the error alert to the error route: got %v", got)
+	}
+}
+
+func Test_AlertMaxPending(t *testing.T) {
+	n := &notifier{}
+	a := start(t, Config{Routes: []Route{{Notifier: n, Level: logger.LevelError}}, MaxPending: 2})
+
+	ctx := context.Background()
+
+	a.Alert(ctx, record(logger.LevelError, "a.go:1", nil))
+	a.Alert(ctx, record(logger.LevelError, "b.go:1", nil))
+	a.Alert(ctx, record(logger.LevelError, "c.go:1", nil))
+	a.Alert(ctx, record(logger.LevelError, "a.go:1", nil))
+	a.flush()
+
+	batches := n.take()
+	if len(batches) != 1 || len(batches[0]) != 2 || batches[0][0].Count != 2 {
+		t.Fatalf("Should drop the alerts over the max pending and still count repeats: got %v", batches)
+	}
+}
+
+func Test_AlertQuiet(t *testing.T) {
+	n := &notifier{}
+	a := start(t, Config{Routes: []Route{{Notifier: n, Level: logger.LevelError}}})
+
+	a.Alert(quiet(context.Background()), record(logger.LevelError, "a.go:1", nil))
+	a.flush()
+
+	if batches := n.take(); len(batches) != 0 {
+		t.Fatalf("Should ignore the records the alerter logs about itself: got %v", batches)
+	}
+}
+
+// =============================================================================
+
+// start starts an alerter whose batches are only sent by calling flush.
+func start(t *testing.T, cfg Config) *Alerter {
+	t.Helper()
+
+	cfg.Log = logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+	cfg.Service = "TEST"
+	cfg.BatchInterval = time.Hour
+
+	a := New()
+	if err := a.Start(cfg); err != nil {
+		t.Fatalf("Should be able to start the alerter: %v", err)
+	}
+
+	t.Cleanup(func() { a.Shutdown(context.Background()) })
+
+	return a
+}
+
+func record(level logger.Level, file string, status any) logger.Record {
+	r := logger.Record{
+		Time:       time.Now(),
+		Message:    "message",
+		Level:      level,
+		File:       file,
+		Attributes: map[string]any{"msg": "failed"},
+	}
+
+	if status != nil {
+		r.Attributes["status"] = status
+	}
+
+	return r
+}
+
+// notifier records the batches it is sent.
+type notifier struct {
+	mu      sync.Mutex
+	batches [][]Alert
+}
+
+func (n *notifier) Name() string {
+	return "test"
+}
+
+func (n *notifier) Notify(ctx context.Context, service string, alerts []Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.batches = append(n.batches, alerts)
+	return nil
+}
+
+func (n *notifier) take() [][]Alert {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	batches := n.batches
+	n.batches = nil
+
+	return batches
+}
@@ -0,0 +1,242 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// maxErrorBody is the most of a failed response that is kept for the error.
+const maxErrorBody = 1024
+
+// post sends the document as JSON to the url. Any status outside of 2xx is
+// an error.
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, doc any) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "publisher-alerts/1.0")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
+
+// summary formats the alerts as lines of text, one per alert.
+func summary(alerts []Alert) string {
+	var b strings.Builder
+
+	for _, a := range alerts {
+		fmt.Fprintf(&b, "[%s] %s", a.Level, a.Message)
+		if a.Error != "" {
+			fmt.Fprintf(&b, ": %s", a.Error)
+		}
+		fmt.Fprintf(&b, " (%s)", a.File)
+		if a.Count > 1 {
+			fmt.Fprintf(&b, " x%d", a.Count)
+		}
+		if a.TraceID != "" {
+			fmt.Fprintf(&b, " trace_id=%s", a.TraceID)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// =============================================================================
+
+// Webhook posts the alerts as a JSON document to a url.
+type Webhook struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+// NewWebhook constructs a notifier for the url. The headers are sent with
+// every request, such as an auth header.
+func NewWebhook(url string, headers map[string]string) *Webhook {
+	return &Webhook{
+		client:  &http.Client{},
+		url:     url,
+		headers: headers,
+	}
+}
+
+// Name implements the Notifier interface.
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Notify implements the Notifier interface.
+func (w *Webhook) Notify(ctx context.Context, service string, alerts []Alert) error {
+	doc := struct {
+		Service string  `json:"service"`
+		Alerts  []Alert `json:"alerts"`
+	}{
+		Service: service,
+		Alerts:  alerts,
+	}
+
+	return post(ctx, w.client, w.url, w.headers, doc)
+}
+
+// =============================================================================
+
+// Slack posts the alerts as a message to a Slack compatible incoming webhook.
+type Slack struct {
+	client *http.Client
+	url    string
+}
+
+// NewSlack constructs a notifier for the incoming webhook url.
+func NewSlack(url string) *Slack {
+	return &Slack{
+		client: &http.Client{},
+		url:    url,
+	}
+}
+
+// Name implements the Notifier interface.
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+// Notify implements the Notifier interface.
+func (s *Slack) Notify(ctx context.Context, service string, alerts []Alert) error {
+	doc := struct {
+		Text string `json:"text"`
+	}{
+		Text: fmt.Sprintf("*%s* raised %d alert(s)\n```\n%s```", service, len(alerts), summary(alerts)),
+	}
+
+	return post(ctx, s.client, s.url, nil, doc)
+}
+
+// =============================================================================
+
+// SMTPConfig represents the settings of the mail server and the message.
+type SMTPConfig struct {
+	Addr     string
+	User     string
+	Password string
+	From     string
+	To       []string
+}
+
+// SMTP mails the alerts through a mail server. The credentials are only
+// used when a user is set.
+type SMTP struct {
+	cfg SMTPConfig
+}
+
+// NewSMTP constructs a notifier for the mail server.
+func NewSMTP(cfg SMTPConfig) *SMTP {
+	return &SMTP{
+		cfg: cfg,
+	}
+}
+
+// Name implements the Notifier interface.
+func (s *SMTP) Name() string {
+	return "smtp"
+}
+
+// Notify implements the Notifier interface. The message is sent over a
+// connection that is closed when the context is done.
+func (s *SMTP) Notify(ctx context.Context, service string, alerts []Alert) error {
+	if len(s.cfg.To) == 0 {
+		return errors.New("no recipients")
+	}
+
+	host, _, err := net.SplitHostPort(s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("addr: %w", err)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("client: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	if s.cfg.User != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.User, s.cfg.Password, host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(s.cfg.From); err != nil {
+		return fmt.Errorf("mail: %w", err)
+	}
+
+	for _, to := range s.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("rcpt[%s]: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+
+	fmt.Fprintf(w, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(w, "To: %s\r\n", strings.Join(s.cfg.To, ", "))
+	fmt.Fprintf(w, "Subject: [%s] %d alert(s)\r\n", service, len(alerts))
+	fmt.Fprintf(w, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(w, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprint(w, strings.ReplaceAll(summary(alerts), "\n", "\r\n"))
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	return c.Quit()
+}
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TraceIDFunc represents a function that can return the trace id from
@@ -38,6 +40,16 @@ func NewWithHandler(h slog.Handler) *Logger {
 	return &Logger{handler: h}
 }
 
+// WithHandler returns a new log that also writes every record to the
+// handler, such as a bridge to another logging backend. The handler only
+// receives the records at the levels it has enabled.
+func (log *Logger) WithHandler(h slog.Handler) *Logger {
+	return &Logger{
+		handler:     newTeeHandler(log.handler, h),
+		traceIDFunc: log.traceIDFunc,
+	}
+}
+
 // NewStdLogger returns a standard library Logger that wraps the slog Logger.
 func NewStdLogger(logger *Logger, level Level) *log.Logger {
 	return slog.NewLogLogger(logger.handler, slog.Level(level))
@@ -98,8 +110,16 @@ func (log *Logger) write(ctx context.Context, level Level, caller int, msg strin
 	if log.traceIDFunc != nil {
 		args = append(args, "trace_id", log.traceIDFunc(ctx))
 	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		args = append(args, "span_id", sc.SpanID().String(), "trace_flags", sc.TraceFlags().String())
+	}
 	r.Add(args...)
 
+	if level >= LevelError {
+		addSpanEvent(ctx, r)
+	}
+
 	log.handler.Handle(ctx, r)
 }
 
@@ -2,6 +2,9 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
 	"time"
 
 	"log/slog"
@@ -25,6 +28,7 @@ type Record struct {
 	Time       time.Time
 	Message    string
 	Level      Level
+	File       string
 	Attributes map[string]any
 }
 
@@ -41,10 +45,24 @@ func toRecord(r slog.Record) Record {
 		Time:       r.Time,
 		Message:    r.Message,
 		Level:      Level(r.Level),
+		File:       sourceFile(r.PC),
 		Attributes: atts,
 	}
 }
 
+// sourceFile returns the name.ext:line of the code the record was logged
+// from, the same way the file is logged.
+func sourceFile(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+
+	return fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line)
+}
+
 // EventFunc is a function to be executed when configured against a log level.
 type EventFunc func(ctx context.Context, r Record)
 
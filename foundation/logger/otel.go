@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// addSpanEvent adds the record as an event on the span in the context, so
+// the error shows up in the trace of the request that logged it.
+func addSpanEvent(ctx context.Context, r slog.Record) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := make([]attribute.KeyValue, 0, r.NumAttrs()+3)
+	attrs = append(attrs,
+		attribute.String("log.severity", r.Level.String()),
+		attribute.String("log.message", r.Message),
+	)
+
+	if file := sourceFile(r.PC); file != "" {
+		attrs = append(attrs, attribute.String("log.file", file))
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "trace_id", "span_id", "trace_flags":
+		default:
+			attrs = append(attrs, attribute.String(a.Key, a.Value.String()))
+		}
+		return true
+	})
+
+	span.AddEvent("log", trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"context"
+	"errors"
+
+	"log/slog"
+)
+
+// teeHandler writes the records to a set of handlers.
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func newTeeHandler(handlers ...slog.Handler) *teeHandler {
+	return &teeHandler{
+		handlers: handlers,
+	}
+}
+
+// Enabled reports whether any of the handlers handles records at the given
+// level.
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithAttrs returns a new handler whose handlers have the attributes added.
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+
+	return &teeHandler{handlers: handlers}
+}
+
+// WithGroup returns a new handler whose handlers have the group added.
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+
+	return &teeHandler{handlers: handlers}
+}
+
+// Handle writes the record to the handlers that have its level enabled.
+func (h *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+
+		if err := handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -18,6 +18,7 @@ type Values struct {
 	Tracer     trace.Tracer
 	Now        time.Time
 	StatusCode int
+	Route      string
 }
 
 // SetValues sets the specified Values in the context.
@@ -72,6 +73,15 @@ func AddSpan(ctx context.Context, spanName string, keyValues ...attribute.KeyVal
 	return ctx, span
 }
 
+// GetRoute returns the path pattern of the route handling the request.
+func GetRoute(ctx context.Context) string {
+	v, ok := ctx.Value(key).(*Values)
+	if !ok {
+		return ""
+	}
+	return v.Route
+}
+
 // SetStatusCode sets the status code back into the context.
 func SetStatusCode(ctx context.Context, statusCode int) {
 	v, ok := ctx.Value(key).(*Values)
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// pathKey holds the path of the request for the sampler, since the span of
+// the request is started before the route is known.
+const pathKey ctxKey = 2
+
+func setPath(ctx context.Context, path string) context.Context {
+	return context.WithValue(ctx, pathKey, path)
+}
+
+// routeSampler is the sampler used for the paths under a prefix.
+type routeSampler struct {
+	prefix  string
+	sampler sdktrace.Sampler
+}
+
+// RouteSampler samples the traces of the requests by path, falling back to
+// a default probability for the paths it has no override for. The spans that
+// aren't the start of a request follow the decision of their parent.
+type RouteSampler struct {
+	routes   []routeSampler
+	fallback sdktrace.Sampler
+}
+
+// NewRouteSampler constructs a sampler with the default probability and the
+// probability overrides by path prefix, where the longest prefix wins. A
+// probability of zero never samples the path, even under a sampled parent.
+func NewRouteSampler(probability float64, overrides map[string]float64) *RouteSampler {
+	routes := make([]routeSampler, 0, len(overrides))
+	for prefix, p := range overrides {
+		routes = append(routes, routeSampler{
+			prefix:  strings.TrimSuffix(prefix, "/"),
+			sampler: newSampler(p),
+		})
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		return len(routes[i].prefix) > len(routes[j].prefix)
+	})
+
+	return &RouteSampler{
+		routes:   routes,
+		fallback: newSampler(probability),
+	}
+}
+
+func newSampler(probability float64) sdktrace.Sampler {
+	if probability <= 0 {
+		return sdktrace.NeverSample()
+	}
+
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(probability))
+}
+
+// ShouldSample implements the sdktrace.Sampler interface.
+func (s *RouteSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	path, ok := p.ParentContext.Value(pathKey).(string)
+	if !ok {
+		return s.fallback.ShouldSample(p)
+	}
+
+	for _, r := range s.routes {
+		if path == r.prefix || strings.HasPrefix(path, r.prefix+"/") {
+			return r.sampler.ShouldSample(p)
+		}
+	}
+
+	return s.fallback.ShouldSample(p)
+}
+
+// Description implements the sdktrace.Sampler interface.
+func (s *RouteSampler) Description() string {
+	routes := make([]string, len(s.routes))
+	for i, r := range s.routes {
+		routes[i] = fmt.Sprintf("%s:%s", r.prefix, r.sampler.Description())
+	}
+
+	return fmt.Sprintf("RouteSampler{%s,routes:[%s]}", s.fallback.Description(), strings.Join(routes, ","))
+}
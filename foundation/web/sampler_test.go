@@ -0,0 +1,117 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Test_RouteSampler(t *testing.T) {
+	s := NewRouteSampler(1, map[string]float64{
+		"/v1":          0,
+		"/v1/pages/":   1,
+		"/v1/liveness": 0,
+	})
+
+	sampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+
+	notSampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+		Remote:  true,
+	}))
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		path   string
+		sample bool
+	}{
+		{name: "no path", ctx: context.Background(), sample: true},
+		{name: "no override", ctx: context.Background(), path: "/metrics", sample: true},
+		{name: "prefix", ctx: context.Background(), path: "/v1/editions", sample: false},
+		{name: "longest prefix wins", ctx: context.Background(), path: "/v1/pages/123", sample: true},
+		{name: "exact path", ctx: context.Background(), path: "/v1/pages", sample: true},
+		{name: "prefix is a whole segment", ctx: context.Background(), path: "/v1x", sample: true},
+		{name: "zero under sampled parent", ctx: sampled, path: "/v1/liveness", sample: false},
+		{name: "follows sampled parent", ctx: sampled, path: "/metrics", sample: true},
+		{name: "follows parent not sampled", ctx: notSampled, path: "/v1/pages", sample: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if tt.path != "" {
+				ctx = setPath(ctx, tt.path)
+			}
+
+			p := sdktrace.SamplingParameters{
+				ParentContext: ctx,
+				TraceID:       trace.TraceID{2},
+				Name:          "request",
+			}
+
+			got := s.ShouldSample(p).Decision == sdktrace.RecordAndSample
+			if got != tt.sample {
+				t.Fatalf("Should sample %t: got %t", tt.sample, got)
+			}
+		})
+	}
+
+	if d := s.Description(); !strings.Contains(d, "/v1/pages:") || !strings.Contains(d, "/v1:AlwaysOffSampler") {
+		t.Fatalf("Should describe the routes: got %s", d)
+	}
+}
+
+func Test_RouteSamplerRequests(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(NewRouteSampler(1, map[string]float64{"/v1/liveness": 0})),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+
+	global := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(global)
+
+	ok := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return Respond(ctx, w, nil, http.StatusNoContent)
+	}
+
+	app := NewApp(make(chan os.Signal, 1), tp.Tracer("test"))
+	app.Handle(http.MethodGet, "v1", "/liveness", ok)
+	app.Handle(http.MethodGet, "v1", "/pages", ok)
+
+	tests := []struct {
+		path  string
+		spans int
+	}{
+		{path: "/v1/liveness", spans: 0},
+		{path: "/v1/pages", spans: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			before := len(recorder.Ended())
+
+			app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if got := len(recorder.Ended()) - before; got != tt.spans {
+				t.Fatalf("Should record %d spans: got %d", tt.spans, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package web
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// streamError is returned by Close when the stream failed after the first
+// record was sent. The status was already written, so the client can only
+// see the response end early.
+type streamError struct {
+	err error
+}
+
+// Error is the implementation of the error interface.
+func (se *streamError) Error() string {
+	return "stream: " + se.err.Error()
+}
+
+// Unwrap returns the error the stream failed with.
+func (se *streamError) Unwrap() error {
+	return se.err
+}
+
+// IsStreamError checks if an error of type streamError exists. There is no
+// response left to send for these errors.
+func IsStreamError(err error) bool {
+	var se *streamError
+	return errors.As(err, &se)
+}
+
+// =============================================================================
+
+// stream holds the state shared by the streaming responses. The status and
+// headers are sent with the first record, so until then the handler can still
+// respond with an error.
+type stream struct {
+	ctx         context.Context
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	statusCode  int
+	contentType string
+	started     bool
+}
+
+func newStream(ctx context.Context, w http.ResponseWriter, statusCode int, contentType string) stream {
+	return stream{
+		ctx:         ctx,
+		w:           w,
+		rc:          http.NewResponseController(w),
+		statusCode:  statusCode,
+		contentType: contentType,
+	}
+}
+
+func (s *stream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	SetStatusCode(s.ctx, s.statusCode)
+
+	// A stream can run past the write timeout of the server, the client
+	// receives every record as soon as it is written.
+	s.rc.SetWriteDeadline(time.Time{})
+
+	s.w.Header().Set("Content-Type", s.contentType)
+	s.w.WriteHeader(s.statusCode)
+}
+
+func (s *stream) flush() error {
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *stream) close(err error) error {
+	if err != nil {
+		if s.started {
+			return &streamError{err: err}
+		}
+		return err
+	}
+
+	s.start()
+
+	return s.flush()
+}
+
+// =============================================================================
+
+// NDJSONStream writes a response as newline delimited JSON, one value per
+// line, flushing every value to the client as it is sent.
+type NDJSONStream struct {
+	stream
+	enc *json.Encoder
+}
+
+// NewNDJSONStream constructs a stream of JSON values for the response.
+func NewNDJSONStream(ctx context.Context, w http.ResponseWriter, statusCode int) *NDJSONStream {
+	return &NDJSONStream{
+		stream: newStream(ctx, w, statusCode, "application/x-ndjson"),
+		enc:    json.NewEncoder(w),
+	}
+}
+
+// Send writes the value to the client.
+func (s *NDJSONStream) Send(v any) error {
+	s.start()
+
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+
+	return s.flush()
+}
+
+// Close ends the stream with the error the records were produced with, if
+// any. An error before the first record is returned as is, so it can be
+// responded to. Otherwise it is returned as a stream error.
+func (s *NDJSONStream) Close(err error) error {
+	return s.close(err)
+}
+
+// =============================================================================
+
+// CSVStream writes a response as CSV, one record per line after the header,
+// flushing every record to the client as it is sent.
+type CSVStream struct {
+	stream
+	cw     *csv.Writer
+	header []string
+}
+
+// NewCSVStream constructs a stream of CSV records for the response. The
+// header is written ahead of the first record.
+func NewCSVStream(ctx context.Context, w http.ResponseWriter, statusCode int, header []string) *CSVStream {
+	return &CSVStream{
+		stream: newStream(ctx, w, statusCode, "text/csv; charset=utf-8"),
+		cw:     csv.NewWriter(w),
+		header: header,
+	}
+}
+
+// Send writes the record to the client.
+func (s *CSVStream) Send(record []string) error {
+	if err := s.writeHeader(); err != nil {
+		return err
+	}
+
+	return s.write(record)
+}
+
+// Close ends the stream with the error the records were produced with, if
+// any. An error before the first record is returned as is, so it can be
+// responded to. Otherwise it is returned as a stream error.
+func (s *CSVStream) Close(err error) error {
+	if err == nil {
+		err = s.writeHeader()
+	}
+
+	return s.close(err)
+}
+
+func (s *CSVStream) writeHeader() error {
+	if s.started {
+		return nil
+	}
+
+	s.start()
+
+	if len(s.header) == 0 {
+		return nil
+	}
+
+	return s.write(s.header)
+}
+
+func (s *CSVStream) write(record []string) error {
+	if err := s.cw.Write(record); err != nil {
+		return err
+	}
+
+	s.cw.Flush()
+	if err := s.cw.Error(); err != nil {
+		return err
+	}
+
+	return s.flush()
+}
@@ -0,0 +1,131 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vikaskumar1187/publisher_saas/foundation/web"
+)
+
+func Test_NDJSONStream(t *testing.T) {
+	type page struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	v := web.Values{}
+	ctx := web.SetValues(context.Background(), &v)
+	w := httptest.NewRecorder()
+
+	stream := web.NewNDJSONStream(ctx, w, http.StatusOK)
+
+	for _, p := range []page{{1, "front"}, {2, "sport"}} {
+		if err := stream.Send(p); err != nil {
+			t.Fatalf("Should be able to send a value: %v", err)
+		}
+
+		if !w.Flushed {
+			t.Fatal("Should flush every value")
+		}
+	}
+
+	if err := stream.Close(nil); err != nil {
+		t.Fatalf("Should be able to close the stream: %v", err)
+	}
+
+	if exp := "{\"id\":1,\"name\":\"front\"}\n{\"id\":2,\"name\":\"sport\"}\n"; w.Body.String() != exp {
+		t.Fatalf("Should write one value per line:\ngot %q\nexp %q", w.Body.String(), exp)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("Should set the content type: got %q", ct)
+	}
+
+	if v.StatusCode != http.StatusOK {
+		t.Fatalf("Should record the status: got %d", v.StatusCode)
+	}
+}
+
+func Test_CSVStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		records [][]string
+		exp     string
+	}{
+		{
+			name:    "records",
+			records: [][]string{{"1", "front"}, {"2", "sport, \"weekend\""}},
+			exp:     "id,name\n1,front\n2,\"sport, \"\"weekend\"\"\"\n",
+		},
+		{
+			name: "no records",
+			exp:  "id,name\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			stream := web.NewCSVStream(context.Background(), w, http.StatusOK, []string{"id", "name"})
+
+			for _, record := range tt.records {
+				if err := stream.Send(record); err != nil {
+					t.Fatalf("Should be able to send a record: %v", err)
+				}
+			}
+
+			if err := stream.Close(nil); err != nil {
+				t.Fatalf("Should be able to close the stream: %v", err)
+			}
+
+			if w.Body.String() != tt.exp {
+				t.Fatalf("Should write the header and records:\ngot %q\nexp %q", w.Body.String(), tt.exp)
+			}
+
+			if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+				t.Fatalf("Should set the content type: got %q", ct)
+			}
+		})
+	}
+}
+
+func Test_StreamErrors(t *testing.T) {
+	errQuery := errors.New("query failed")
+
+	t.Run("before the first record", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		stream := web.NewNDJSONStream(context.Background(), w, http.StatusOK)
+
+		err := stream.Close(errQuery)
+		if !errors.Is(err, errQuery) || web.IsStreamError(err) {
+			t.Fatalf("Should return the error as is so it can be responded to: got %v", err)
+		}
+
+		if w.Body.Len() != 0 || len(w.Header()) != 0 {
+			t.Fatal("Should not write anything")
+		}
+	})
+
+	t.Run("after the first record", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		stream := web.NewCSVStream(context.Background(), w, http.StatusOK, []string{"id"})
+		if err := stream.Send([]string{"1"}); err != nil {
+			t.Fatalf("Should be able to send a record: %v", err)
+		}
+
+		err := stream.Close(errQuery)
+		if !errors.Is(err, errQuery) || !web.IsStreamError(err) {
+			t.Fatalf("Should return a stream error: got %v", err)
+		}
+
+		if w.Code != http.StatusOK || w.Body.String() != "id\n1\n" {
+			t.Fatalf("Should keep what was sent: got %d %q", w.Code, w.Body.String())
+		}
+	})
+}
@@ -63,7 +63,9 @@ func (a *App) SignalShutdown() {
 // all http traffic and allows the opentelemetry mux to run first to handle
 // tracing. The opentelemetry mux then calls the application mux to handle
 // application traffic. This was set up on line 44 in the NewApp function.
+// The path is added to the context for the RouteSampler to decide on.
 func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(setPath(r.Context(), r.URL.Path))
 	a.otmux.ServeHTTP(w, r)
 }
 
@@ -113,6 +115,11 @@ func (a *App) Handle(method string, group string, path string, handler Handler,
 // handle sets a handler function for a given HTTP method and path pair
 // to the application server mux.
 func (a *App) handle(method string, group string, path string, handler Handler) {
+	finalPath := path
+	if group != "" {
+		finalPath = "/" + group + path
+	}
+
 	h := func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := a.startSpan(w, r)
 		defer span.End()
@@ -121,6 +128,7 @@ func (a *App) handle(method string, group string, path string, handler Handler)
 			TraceID: span.SpanContext().TraceID().String(),
 			Tracer:  a.tracer,
 			Now:     time.Now().UTC(),
+			Route:   finalPath,
 		}
 		ctx = SetValues(ctx, &v)
 
@@ -132,11 +140,6 @@ func (a *App) handle(method string, group string, path string, handler Handler)
 		}
 	}
 
-	finalPath := path
-	if group != "" {
-		finalPath = "/" + group + path
-	}
-
 	a.mux.Handle(method, finalPath, h)
 }
 